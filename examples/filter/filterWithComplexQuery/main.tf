@@ -9,39 +9,33 @@ data "secureworkload_scope" "scope" {
 }
 resource "secureworkload_filter" "filter" {
   name         = "Terraform created filter"
-  query        = <<EOF
-            {
-               "type": "and",
-               "filters": [
-                  {
-                     "field": "vrf_id",
-                     "type": "eq",
-                     "value": 700056
-                  },
-                  {
-                     "type": "or",
-                     "filters": [
-                        {
-                           "field": "ip",
-                           "type": "eq",
-                           "value": "10.254.252.43"
-                        },
-                        {
-                           "field": "ip",
-                           "type": "eq",
-                           "value": "10.254.252.51"
-                        },
-                        {
-                           "field": "ip",
-                           "type": "eq",
-                           "value": "10.254.252.52"
-                        }
-                     ]
-                  }
-               ]
-            }
-          EOF
   app_scope_id = data.secureworkload_scope.scope.id
   primary      = true
   public       = false
+  query {
+    type = "and"
+    filter {
+      type  = "eq"
+      field = "vrf_id"
+      value = "700056"
+    }
+    filter {
+      type = "or"
+      filter {
+        type  = "eq"
+        field = "ip"
+        value = "10.254.252.43"
+      }
+      filter {
+        type  = "eq"
+        field = "ip"
+        value = "10.254.252.51"
+      }
+      filter {
+        type  = "eq"
+        field = "ip"
+        value = "10.254.252.52"
+      }
+    }
+  }
 }
@@ -9,14 +9,12 @@ data "secureworkload_scope" "scope" {
 }
 resource "secureworkload_filter" "filter" {
   name         = "Terraform created filter"
-  query        = <<EOF
-                    {
-                      "type": "eq",
-                      "field": "ip",
-                      "value": "10.0.0.1"
-                    }
-          EOF
   app_scope_id = data.secureworkload_scope.scope.id
   primary      = true
   public       = false
+  query {
+    type  = "eq"
+    field = "ip"
+    value = "10.0.0.1"
+  }
 }
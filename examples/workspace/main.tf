@@ -64,7 +64,7 @@ resource "secureworkload_workspace" "socially_distant_workspace" {
     action             = "ALLOW"
     layer_4_network_policy {
       port_range = [80, 80]
-      protocol   = 6
+      protocol   = "tcp"
     }
   }
   absolute_policy {
@@ -73,7 +73,7 @@ resource "secureworkload_workspace" "socially_distant_workspace" {
     action             = "ALLOW"
     layer_4_network_policy {
       port_range = [443, 443]
-      protocol   = 6
+      protocol   = "tcp"
     }
   }
   default_policy {
@@ -82,7 +82,7 @@ resource "secureworkload_workspace" "socially_distant_workspace" {
     action             = "DENY"
     layer_4_network_policy {
       port_range = [8080, 8080]
-      protocol   = 6
+      protocol   = "tcp"
     }
   }
   default_policy {
@@ -91,7 +91,7 @@ resource "secureworkload_workspace" "socially_distant_workspace" {
     action             = "DENY"
     layer_4_network_policy {
       port_range = [8000, 8000]
-      protocol   = 6
+      protocol   = "tcp"
     }
   }
   catch_all_action = "DENY"
@@ -60,38 +60,38 @@ resource "secureworkload_application" "socially_distant_application" {
   }
   absolute_policy {
     consumer_filter_name = "Development Workloads"
-    provider_scope_name  = "AWS"
+    provider_scope_name  = "RootScope:AWS"
     action               = "ALLOW"
     layer_4_network_policy {
       port_range = [80, 80]
-      protocol   = 6
+      protocol   = "tcp"
     }
   }
   absolute_policy {
     consumer_filter_name = "Development Workloads"
-    provider_scope_name  = "AWS"
+    provider_scope_name  = "RootScope:AWS"
     action               = "ALLOW"
     layer_4_network_policy {
       port_range = [443, 443]
-      protocol   = 6
+      protocol   = "tcp"
     }
   }
   default_policy {
     consumer_filter_name = "Development Workloads"
-    provider_scope_name  = "AWS"
+    provider_scope_name  = "RootScope:AWS"
     action               = "DENY"
     layer_4_network_policy {
       port_range = [8080, 8080]
-      protocol   = 6
+      protocol   = "tcp"
     }
   }
   default_policy {
     consumer_filter_name = "Development Workloads"
-    provider_scope_name  = "AWS"
+    provider_scope_name  = "RootScope:AWS"
     action               = "DENY"
     layer_4_network_policy {
       port_range = [8000, 8000]
-      protocol   = 6
+      protocol   = "tcp"
     }
   }
   catch_all_action = "DENY"
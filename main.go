@@ -1,16 +1,32 @@
 package main
+
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"context"
+	"flag"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
 	// "github.com/secureworkload-exchange/terraform-provider/secureworkload"
 	"terraform-provider-secureworkload/secureworkload"
 )
+
 func main() {
-	plugin.Serve(&plugin.ServeOpts{
-		ProviderFunc: func() *schema.Provider {
-			return secureworkload.Provider()
-		},
-	})
+	var debug bool
+	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with support for debuggers like delve")
+	flag.Parse()
+	ctx := context.Background()
+	server, err := secureworkload.ProviderServer(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var serveOpts []tf5server.ServeOpt
+	if debug {
+		serveOpts = append(serveOpts, tf5server.WithManagedDebug())
+	}
+	err = tf5server.Serve("registry.terraform.io/CiscoDevNet/secureworkload", server, serveOpts...)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 // Package secureworkload provides a golang SDK interface
 // for managing a SecureWorkload installation via the the SecureWorkload HTTP API.
@@ -1,7 +1,6 @@
 package secureworkload
 
 import (
-	"strings"
 	"context"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -48,19 +47,19 @@ func dataSourceSecureWorkloadApplication() *schema.Resource {
 }
 
 func dataSourceSecureWorkloadApplicationRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	c := meta.(Client)
+	c := meta.(Client).WithContext(ctx)
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
-	// Logic for query param
-
-	getUrl := "?"
-	if val, ok := d.GetOk("name"); ok {
-		temp := strings.Replace(val.(string), " ", "%20", -1)
-		getUrl = getUrl + "exact_name=" +  temp
+	// Workspaces are listed once per plan or apply and matched here
+	applications, err := c.LookupApplications()
+	var resApp []Application
+	for _, application := range applications {
+		if application.Name == d.Get("name").(string) {
+			resApp = append(resApp, application)
+		}
 	}
-	resApp, err := c.GetApplicationByParam( getUrl)
 	if (err != nil) {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
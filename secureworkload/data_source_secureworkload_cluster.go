@@ -41,7 +41,7 @@ func dataSourceSecureWorkloadCluster() *schema.Resource {
 }
 
 func dataSourceSecureWorkloadClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	c := meta.(Client)
+	c := meta.(Client).WithContext(ctx)
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
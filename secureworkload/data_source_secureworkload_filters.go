@@ -2,6 +2,7 @@ package secureworkload
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -18,7 +19,7 @@ func dataSourceSecureWorkloadFilter() *schema.Resource {
 			"	name = \"filter1\" \n" +
 			"}\n" +
 			"```" +
-			"**Note:** If filter with the given name is not found, this data source block will respond with the first cluster in the list.\n" +
+			"**Note:** Reading the data source fails unless exactly one filter with the given name exists. This is a breaking change: earlier versions responded with the first filter in the list when no filter had the name, configurations relying on it must now name an existing filter.\n" +
 			"```",
 		ReadContext: dataSourceSecureWorkloadFilterRead,
 		Schema: map[string]*schema.Schema{
@@ -42,20 +43,13 @@ func dataSourceSecureWorkloadFilter() *schema.Resource {
 }
 
 func dataSourceSecureWorkloadFilterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	c := meta.(Client)
+	c := meta.(Client).WithContext(ctx)
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
-	var index int
 
-	// Logic for query param
-
-	getUrl := "?"
-	if val, ok := d.GetOk("name"); ok {
-		temp := strings.Replace(val.(string), " ", "%20", -1)
-		getUrl = getUrl + "exact_name=" + temp
-	}
-	resFilter, err := c.GetFilterByParam(getUrl)
+	// Filters are listed once per plan or apply and matched here
+	resFilter, err := c.LookupFilters()
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
@@ -64,29 +58,41 @@ func dataSourceSecureWorkloadFilterRead(ctx context.Context, d *schema.ResourceD
 		})
 		return diags
 	}
-	if len(resFilter) > 0 {
-		for i, j := range resFilter {
-			if j.Name == d.Get("name").(string) {
-				index = i
-				break
-			}
+	name := d.Get("name").(string)
+	var matches []Filter
+	for _, filter := range resFilter {
+		if filter.Name == name {
+			matches = append(matches, filter)
 		}
-		d.SetId(resFilter[index].Id)
-		if err := d.Set("name", resFilter[index].Name); err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "unable to read cluster",
-				Detail:   err.Error(),
-			})
-			return diags
+	}
+	if len(matches) == 0 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "filter with the given name is not present",
+			Detail:   fmt.Sprintf("no filter named %q exists", name),
+		})
+		return diags
+	}
+	if len(matches) > 1 {
+		var candidates []string
+		for _, filter := range matches {
+			candidates = append(candidates, fmt.Sprintf("%s (scope %s)", filter.Id, filter.AppScopeId))
 		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "more than one filter with the given name is present",
+			Detail:   fmt.Sprintf("filters named %q: %s", name, strings.Join(candidates, ", ")),
+		})
 		return diags
 	}
-	diags = append(diags, diag.Diagnostic{
-		Severity: diag.Error,
-		Summary:  "filter with the given name is not present",
-		Detail:   "filter with the given name is not present",
-	})
+	d.SetId(matches[0].Id)
+	d.Set("app_scope_id", matches[0].AppScopeId)
+	if err := d.Set("name", matches[0].Name); err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "unable to read filter",
+			Detail:   err.Error(),
+		})
+	}
 	return diags
-
 }
@@ -0,0 +1,60 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func readFilterDataSource(t *testing.T, name string) (*schema.ResourceData, error) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"id": "1", "name": "web", "app_scope_id": "root"},
+			{"id": "2", "name": "db", "app_scope_id": "root"},
+			{"id": "3", "name": "db", "app_scope_id": "app"}
+		]`)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = nil
+	d := schema.TestResourceDataRaw(t, dataSourceSecureWorkloadFilter().Schema, map[string]interface{}{
+		"name": name,
+	})
+	diags := dataSourceSecureWorkloadFilterRead(context.Background(), d, client)
+	if diags.HasError() {
+		return d, fmt.Errorf("%s: %s", diags[0].Summary, diags[0].Detail)
+	}
+	return d, nil
+}
+
+func TestFilterDataSourceFindsFilterByName(t *testing.T) {
+	d, err := readFilterDataSource(t, "web")
+	if err != nil {
+		t.Fatalf("Expected filter web to be found, got %s", err)
+	}
+	if d.Id() != "1" || d.Get("app_scope_id").(string) != "root" {
+		t.Errorf("Expected filter 1 of scope root, got %q of scope %q", d.Id(), d.Get("app_scope_id"))
+	}
+}
+
+func TestFilterDataSourceFailsWithoutMatch(t *testing.T) {
+	d, err := readFilterDataSource(t, "missing")
+	if err == nil {
+		t.Fatalf("Expected an error reading a missing filter, got id %q", d.Id())
+	}
+	if d.Id() != "" {
+		t.Errorf("Expected no id, got %q", d.Id())
+	}
+}
+
+func TestFilterDataSourceFailsWithDuplicateNames(t *testing.T) {
+	if _, err := readFilterDataSource(t, "db"); err == nil {
+		t.Error("Expected an error reading a filter name used twice")
+	}
+}
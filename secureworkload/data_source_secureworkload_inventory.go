@@ -0,0 +1,306 @@
+package secureworkload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// Default largest number of items returned by the inventory data source.
+	defaultInventoryMaxResults = 10000
+)
+
+var (
+	_ datasource.DataSourceWithConfigure      = &inventoryDataSource{}
+	_ datasource.DataSourceWithValidateConfig = &inventoryDataSource{}
+)
+
+// inventoryDataSource searches the inventory.
+type inventoryDataSource struct {
+	client Client
+}
+
+// inventoryDataSourceModel maps the secureworkload_inventory schema.
+type inventoryDataSourceModel struct {
+	Id         types.String `tfsdk:"id"`
+	Query      types.List   `tfsdk:"query"`
+	QueryJSON  types.String `tfsdk:"query_json"`
+	ScopeName  types.String `tfsdk:"scope_name"`
+	StartTime  types.String `tfsdk:"start_time"`
+	EndTime    types.String `tfsdk:"end_time"`
+	Dimensions types.List   `tfsdk:"dimensions"`
+	MaxResults types.Int64  `tfsdk:"max_results"`
+	Count      types.Int64  `tfsdk:"item_count"`
+	Truncated  types.Bool   `tfsdk:"truncated"`
+	IPs        types.List   `tfsdk:"ips"`
+	Items      types.List   `tfsdk:"items"`
+}
+
+// inventoryItemModel maps the items of the secureworkload_inventory schema.
+type inventoryItemModel struct {
+	IP         types.String `tfsdk:"ip"`
+	Hostname   types.String `tfsdk:"hostname"`
+	OS         types.String `tfsdk:"os"`
+	OSVersion  types.String `tfsdk:"os_version"`
+	VRFName    types.String `tfsdk:"vrf_name"`
+	AgentType  types.String `tfsdk:"agent_type"`
+	Labels     types.Map    `tfsdk:"labels"`
+	Attributes types.Map    `tfsdk:"attributes"`
+}
+
+// inventoryItemType is the type of the items of the secureworkload_inventory schema.
+var inventoryItemType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"ip":         types.StringType,
+		"hostname":   types.StringType,
+		"os":         types.StringType,
+		"os_version": types.StringType,
+		"vrf_name":   types.StringType,
+		"agent_type": types.StringType,
+		"labels":     types.MapType{ElemType: types.StringType},
+		"attributes": types.MapType{ElemType: types.StringType},
+	},
+}
+
+// NewInventoryDataSource returns the secureworkload_inventory data source.
+func NewInventoryDataSource() datasource.DataSource {
+	return &inventoryDataSource{}
+}
+
+func (d *inventoryDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_inventory"
+}
+
+func (d *inventoryDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Data source for searching the inventory of Secure Workload\n" +
+			"\n" +
+			"Returns the addresses and subnets matching an inventory filter query, along with their hostname, OS, labels and agent type, " +
+			"for example to check the members of a scope or to feed other resources.\n" +
+			"\n" +
+			"## Example\n" +
+			"An example is shown below: \n" +
+			"```hcl\n" +
+			"data \"secureworkload_inventory\" \"web\" {\n" +
+			"    scope_name = \"acme:web\"\n" +
+			"    query {\n" +
+			"        type = \"and\"\n" +
+			"        filter {\n" +
+			"            type  = \"subnet\"\n" +
+			"            field = \"ip\"\n" +
+			"            value = \"10.0.1.0/24\"\n" +
+			"        }\n" +
+			"        filter {\n" +
+			"            type       = \"eq\"\n" +
+			"            annotation = \"Environment\"\n" +
+			"            value      = \"production\"\n" +
+			"        }\n" +
+			"    }\n" +
+			"}\n" +
+			"\n" +
+			"output \"web_ips\" {\n" +
+			"    value = data.secureworkload_inventory.web.ips\n" +
+			"}\n" +
+			"```\n" +
+			"**Note:** The search returns at most `max_results` items, see `truncated`.\n",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The ID of this data source.",
+			},
+			"query_json": schema.StringAttribute{
+				Optional:    true,
+				Validators:  []validator.String{jsonValidator{}},
+				Description: "JSON object representation of the inventory filter query matched by the items. Conflicts with query.",
+			},
+			"scope_name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Fully qualified name of the scope the search is restricted to, for example acme:web.",
+			},
+			"start_time": schema.StringAttribute{
+				Optional:    true,
+				Description: "Start of the time range of the search, in RFC 3339 format. Defaults to an hour before end_time.",
+			},
+			"end_time": schema.StringAttribute{
+				Optional:    true,
+				Description: "End of the time range of the search, in RFC 3339 format. Defaults to now.",
+			},
+			"dimensions": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Fields returned for each item in attributes, all of them when empty. The ip field is always returned, the other attributes of items are empty unless their field, e.g. host_name, os, os_version, vrf_name or agent_type, is returned.",
+			},
+			"max_results": schema.Int64Attribute{
+				Optional:    true,
+				Computed:    true,
+				Description: fmt.Sprintf("Largest number of items returned. Default is %d.", defaultInventoryMaxResults),
+			},
+			"item_count": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Number of items returned.",
+			},
+			"truncated": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether more items match than max_results.",
+			},
+			"ips": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Addresses and subnets of the items, sorted.",
+			},
+			// Nested attributes need protocol version 6, so the
+			// fields of items are described here instead
+			"items": schema.ListAttribute{
+				Computed:    true,
+				ElementType: inventoryItemType,
+				Description: "Inventory items matching the query, sorted by address. " +
+					"Each item has its IPv4/IPv6 address or subnet in ip, the hostname, os and os_version of the workload, if known, " +
+					"the vrf_name of the item, the agent_type installed on the workload, empty when there is none, " +
+					"its labels, also known as user annotations, by key, and the attributes holding the values of all the returned fields of the item, by field name.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"query": frameworkDataSourceQueryBlock("Inventory filter query matched by the items, built out of nested filter blocks. Conflicts with query_json."),
+		},
+	}
+}
+
+func (d *inventoryDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	d.client, _ = clientFromProviderData(req.ProviderData, &resp.Diagnostics)
+}
+
+func (d *inventoryDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var config inventoryDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	hasQuery := config.Query.IsUnknown() || len(config.Query.Elements()) > 0
+	if hasQuery == !config.QueryJSON.IsNull() {
+		resp.Diagnostics.AddError("Invalid query", "exactly one of query or query_json must be specified")
+	}
+	if !config.MaxResults.IsNull() && !config.MaxResults.IsUnknown() && config.MaxResults.ValueInt64() < 1 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_results"), "Invalid max_results",
+			fmt.Sprintf("max_results must be positive, got %d", config.MaxResults.ValueInt64()))
+	}
+}
+
+func (d *inventoryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config inventoryDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	c := d.client.WithContext(ctx)
+	filter, err := inventoryFilterFromFramework(config.Query, "query", config.QueryJSON.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid query", err.Error())
+		return
+	}
+	t0, t1, err := inventoryTimeRange(config.StartTime.ValueString(), config.EndTime.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid time range", err.Error())
+		return
+	}
+	search := InventorySearchRequest{
+		Filter:    filter,
+		ScopeName: config.ScopeName.ValueString(),
+		T0:        t0,
+		T1:        t1,
+	}
+	var dimensions []string
+	resp.Diagnostics.Append(config.Dimensions.ElementsAs(ctx, &dimensions, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	hasIP := false
+	for _, dimension := range dimensions {
+		search.Dimensions = append(search.Dimensions, dimension)
+		hasIP = hasIP || dimension == "ip"
+	}
+	if len(search.Dimensions) > 0 && !hasIP {
+		search.Dimensions = append(search.Dimensions, "ip")
+	}
+	maxResults := defaultInventoryMaxResults
+	if !config.MaxResults.IsNull() {
+		maxResults = int(config.MaxResults.ValueInt64())
+	}
+	items, truncated, err := c.SearchInventoryTruncated(search, maxResults)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to search the inventory", err.Error())
+		return
+	}
+	tfItems := []inventoryItemModel{}
+	ips := []string{}
+	for _, item := range items {
+		tfItems = append(tfItems, inventoryItemToFramework(item))
+		ips = append(ips, item.String("ip"))
+	}
+	encoded, err := json.Marshal(search)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to encode the search", err.Error())
+		return
+	}
+	state := config
+	state.Id = types.StringValue(fmt.Sprintf("%x", sha256.Sum256(encoded)))
+	state.MaxResults = types.Int64Value(int64(maxResults))
+	state.Count = types.Int64Value(int64(len(items)))
+	state.Truncated = types.BoolValue(truncated)
+	var diags diag.Diagnostics
+	state.IPs, diags = types.ListValueFrom(ctx, types.StringType, ips)
+	resp.Diagnostics.Append(diags...)
+	state.Items, diags = types.ListValueFrom(ctx, inventoryItemType, tfItems)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// inventoryFilterFromFramework returns the JSON of the query built by the
+// query block found under blockKey, if any, or else the normalized queryJSON.
+func inventoryFilterFromFramework(tfQuery types.List, blockKey string, queryJSON string) (json.RawMessage, error) {
+	query, ok, err := scopeQueryFromFramework(tfQuery, blockKey)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return json.Marshal(query)
+	}
+	normalized, err := normalizeQueryJSON(queryJSON)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(normalized), nil
+}
+
+func inventoryItemToFramework(item InventoryItem) inventoryItemModel {
+	attributes := map[string]attr.Value{}
+	for field := range item {
+		attributes[field] = types.StringValue(item.String(field))
+	}
+	labels := map[string]attr.Value{}
+	for key, value := range item.Labels() {
+		labels[key] = types.StringValue(value)
+	}
+	return inventoryItemModel{
+		IP:         types.StringValue(item.String("ip")),
+		Hostname:   types.StringValue(item.String("host_name")),
+		OS:         types.StringValue(item.String("os")),
+		OSVersion:  types.StringValue(item.String("os_version")),
+		VRFName:    types.StringValue(item.String("vrf_name")),
+		AgentType:  types.StringValue(item.String("agent_type")),
+		Labels:     types.MapValueMust(types.StringType, labels),
+		Attributes: types.MapValueMust(types.StringType, attributes),
+	}
+}
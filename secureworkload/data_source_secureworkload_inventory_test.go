@@ -0,0 +1,71 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestInventoryDataSourceReadsSortedItems(t *testing.T) {
+	var searches []InventorySearchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var search InventorySearchRequest
+		json.NewDecoder(r.Body).Decode(&search)
+		searches = append(searches, search)
+		fmt.Fprint(w, `{"results": [
+			{"ip": "10.0.0.2", "host_name": "db", "user_Environment": "production"},
+			{"ip": "10.0.0.1", "host_name": "web"}
+		]}`)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	ctx := context.Background()
+	d := &inventoryDataSource{client: client}
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attributeType, nil)
+	}
+	values["query_json"] = tftypes.NewValue(tftypes.String, `{"type": "subnet", "field": "ip", "value": "10.0.0.0/24"}`)
+	values["scope_name"] = tftypes.NewValue(tftypes.String, "acme")
+	values["dimensions"] = tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+		tftypes.NewValue(tftypes.String, "host_name"),
+	})
+	req := datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, values)}}
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+	d.Read(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Unexpected diagnostics %v", resp.Diagnostics)
+	}
+	if len(searches) != 1 || searches[0].ScopeName != "acme" || !reflect.DeepEqual(searches[0].Dimensions, []string{"host_name", "ip"}) {
+		t.Errorf("Unexpected searches %+v", searches)
+	}
+	var state inventoryDataSourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+	var ips []string
+	resp.Diagnostics.Append(state.IPs.ElementsAs(ctx, &ips, false)...)
+	var items []inventoryItemModel
+	resp.Diagnostics.Append(state.Items.ElementsAs(ctx, &items, false)...)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Unexpected diagnostics %v", resp.Diagnostics)
+	}
+	if !reflect.DeepEqual(ips, []string{"10.0.0.1", "10.0.0.2"}) || state.Count.ValueInt64() != 2 || state.Truncated.ValueBool() ||
+		state.MaxResults.ValueInt64() != defaultInventoryMaxResults || state.Id.IsNull() {
+		t.Errorf("Unexpected state %+v", state)
+	}
+	if len(items) != 2 || items[1].Hostname.ValueString() != "db" || items[1].Labels.Elements()["Environment"].String() != `"production"` {
+		t.Errorf("Unexpected items %+v", items)
+	}
+}
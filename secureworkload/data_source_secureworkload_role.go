@@ -1,7 +1,6 @@
 package secureworkload
 
 import (
-	"strings"
 	"context"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -46,19 +45,19 @@ func dataSourceSecureWorkloadRole() *schema.Resource {
 }
 
 func dataSourceSecureWorkloadRoleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	c := meta.(Client)
+	c := meta.(Client).WithContext(ctx)
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
-	// Logic for query param
-
-	getUrl := "?"
-	if val, ok := d.GetOk("app_scope_id"); ok {
-		temp := strings.Replace(val.(string), " ", "%20", -1)
-		getUrl = getUrl + "app_scope_id=" +  temp
+	// Roles are listed once per plan or apply and matched here
+	roles, err := c.LookupRoles()
+	var resRole []Role
+	for _, role := range roles {
+		if role.AppScopeId == d.Get("app_scope_id").(string) {
+			resRole = append(resRole, role)
+		}
 	}
-	resRole, err := c.GetRoleByParam( getUrl)
 	if (err != nil) {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
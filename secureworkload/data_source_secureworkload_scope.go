@@ -1,7 +1,7 @@
 package secureworkload
 
 import (
-	"strings"
+	"strconv"
 	"context"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -43,26 +43,32 @@ func dataSourceSecureWorkloadScope() *schema.Resource {
 }
 
 func dataSourceSecureWorkloadScopeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	c := meta.(Client)
+	c := meta.(Client).WithContext(ctx)
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
-	// Logic for query param
-
-	getUrl := "?"
-	if val, ok := d.GetOk("exact_name"); ok {
-		temp := strings.Replace(val.(string), " ", "%20", -1)
-		getUrl = getUrl + "exact_name=" +  temp
-	}
-	if val, ok := d.GetOk("exact_short_name"); ok {
-		temp := strings.Replace(val.(string), " ", "%20", -1)
-		getUrl = getUrl + "&exact_short_name=" + temp
-	}
-	if val, ok := d.GetOk("vrf_id"); ok {
-		getUrl = getUrl + "&vrf_id=" + val.(string)
+	// Scopes are listed once per plan or apply and matched here
+	scopes, err := c.LookupScopes()
+	var resScope []GetScope
+	for _, scope := range scopes {
+		if val, ok := d.GetOk("exact_name"); ok && scope.Name != val.(string) {
+			continue
+		}
+		if val, ok := d.GetOk("exact_short_name"); ok && scope.ShortName != val.(string) {
+			continue
+		}
+		if val, ok := d.GetOk("vrf_id"); ok && strconv.Itoa(scope.VRFId) != val.(string) {
+			continue
+		}
+		resScope = append(resScope, GetScope{
+			Id:             scope.Id,
+			ExactShortName: scope.ShortName,
+			ExactName:      scope.Name,
+			VRFId:          scope.VRFId,
+			RootAppScopeId: scope.RootAppScopeId,
+		})
 	}
-	resScope, err := c.GetScopeByParam( getUrl)
 	if (err != nil) {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
@@ -1,9 +1,16 @@
 package secureworkload
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	// client "github.com/secureworkload-exchange/terraform-go-sdk"
 	// secureworkload "github.com/secureworkload-exchange/terraform-go-sdk"
@@ -49,7 +56,7 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 			"    	 action = \"ALLOW\"\n" +
 			"        layer_4_network_policy {\n" +
 			"            port_range = [80,80]\n" +
-			"        	 protocol = 6\n" +
+			"        	 protocol = \"tcp\"\n" +
 			"        }\n" +
 			"	 }\n" +
 			"    default_policy {\n" +
@@ -58,16 +65,33 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 			"    	 action = \"DENY\"\n" +
 			"        layer_4_network_policy {\n" +
 			"            port_range = [80,80]\n" +
-			"        	 protocol = 6\n" +
+			"        	 protocol = \"tcp\"\n" +
 			"        }\n" +
 			"	 }\n" +
 			"    catch all action  = false \n" +
 			"}\n" +
 			"```\n" +
-			"**Note:** If creating multiple resources for workspaces during a single `terraform apply`, you may have to use `depends_on` to chain the resources so that terraform creates it in the same order that you intended.\n",
-		Create:        resourceSecureWorkloadApplicationCreate,
-		Read:          resourceSecureWorkloadApplicationRead,
-		Delete:        resourceSecureWorkloadApplicationDelete,
+			"**Note:** If creating multiple resources for workspaces during a single `terraform apply`, you may have to use `depends_on` to chain the resources so that terraform creates it in the same order that you intended.\n" +
+			"\n" +
+			"The consumer and provider of a policy are referenced by exactly one of `*_filter_id`, `*_scope_name` (the fully qualified name, e.g. `Root:App:Web`), " +
+			"`*_filter_name` (along with `*_filter_scope_name` when several filters share the name) or `*_cluster_name` (a cluster of the workspace).\n" +
+			"\n" +
+			"The ports of a `layer_4_network_policy` block are set with exactly one of `port_range`, `ports`, a comma separated list of ports and port ranges " +
+			"such as `\"80,443,8000-8100\"`, or `service`, the name of a service of the default catalog or of the `service` blocks of the provider configuration.\n" +
+			"\n" +
+			"## Import\n" +
+			"Workspaces can be imported using their ID:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_workspace.workspace1 5f3d4c2e497d4f7c3f8e9a11\n" +
+			"```\n",
+		CustomizeDiff: customizeApplicationPortRangesDiff,
+		CreateContext: resourceSecureWorkloadApplicationCreate,
+		ReadContext:   resourceSecureWorkloadApplicationRead,
+		UpdateContext: resourceSecureWorkloadApplicationUpdate,
+		DeleteContext: resourceSecureWorkloadApplicationDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceSecureWorkloadApplicationImport,
+		},
 		SchemaVersion: 1,
 		Schema: map[string]*schema.Schema{
 			"app_scope_id": {
@@ -79,14 +103,12 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 			"name": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				ForceNew:    true,
 				Computed:    true,
 				Description: "(Optional) User-specified name for the application.",
 			},
 			"description": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				ForceNew:    true,
 				Computed:    true,
 				Description: "(Optional) User-specified description of the application.",
 			},
@@ -105,7 +127,6 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 			"primary": {
 				Type:        schema.TypeBool,
 				Optional:    true,
-				ForceNew:    true,
 				Default:     true,
 				Description: "(Optional) Set to true to indicate this application is primary for the given scope. Default value is true.",
 			},
@@ -113,7 +134,6 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 				Type:        schema.TypeList,
 				Optional:    true,
 				Description: "Cluster wraps a groups of nodes to be used to define policies.",
-				ForceNew:    true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"id": {
@@ -160,7 +180,6 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 			},
 			"filter": {
 				Type:        schema.TypeList,
-				ForceNew:    true,
 				Optional:    true,
 				Description: "Filter wrap a collection of inventory filters on data center assets used to define an                application policy.",
 				Elem: &schema.Resource{
@@ -176,9 +195,12 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 							Description: "Displayed name of the cluster.",
 						},
 						"query": {
-							Type:        schema.TypeString,
-							Required:    true,
-							Description: "JSON object representation of an inventory filter query.",
+							Type:             schema.TypeString,
+							Required:         true,
+							ValidateFunc:     validateJSON,
+							StateFunc:        normalizeQueryJSONState,
+							DiffSuppressFunc: suppressEquivalentQueryJSON,
+							Description:      "JSON object representation of an inventory filter query.",
 						},
 					},
 				},
@@ -186,7 +208,6 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 			"absolute_policy": {
 				Type:        schema.TypeList,
 				Optional:    true,
-				ForceNew:    true,
 				Description: "Ordered application policy to be created with the absolute rank.",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
@@ -195,25 +216,56 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 							Optional:    true,
 							Description: "ID of a cluster, user inventory filter, or application scope.",
 						},
+						"consumer_scope_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Fully qualified name of a scope, for example Root:App:Web.",
+						},
 						"consumer_filter_name": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Description: "Named filter. If more than one filter with the same name exists you must specify consumer_filter_id. ",
+							Description: "Name of a user inventory filter. If more than one filter with the same name exists you must specify consumer_filter_scope_name or consumer_filter_id.",
+						},
+						"consumer_filter_scope_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Fully qualified name of the scope owning the filter named by consumer_filter_name, to choose between filters with the same name.",
+						},
+						"consumer_cluster_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Name of a cluster of the workspace.",
 						},
 						"provider_filter_id": {
 							Type:        schema.TypeString,
 							Optional:    true,
 							Description: "ID of a cluster, user inventory filter, or application scope.",
 						},
+						"provider_scope_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Fully qualified name of a scope, for example Root:App:Web.",
+						},
 						"provider_filter_name": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Description: "Named filter. If more than one filter with the same name exists you must specify consumer_filter_id. ",
+							Description: "Name of a user inventory filter. If more than one filter with the same name exists you must specify provider_filter_scope_name or provider_filter_id.",
 						},
-						"action": {
+						"provider_filter_scope_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Fully qualified name of the scope owning the filter named by provider_filter_name, to choose between filters with the same name.",
+						},
+						"provider_cluster_name": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Description: "“ALLOW” or “DENY”",
+							Description: "Name of a cluster of the workspace.",
+						},
+						"action": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateStringInSlice(policyActions, true),
+							Description:  "“ALLOW” or “DENY”",
 						},
 						"layer_4_network_policy": {
 							Type:        schema.TypeList,
@@ -222,20 +274,34 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 							Elem: &schema.Resource{
 								Schema: map[string]*schema.Schema{
 									"protocol": {
-										Type:        schema.TypeInt,
-										Required:    true,
-										Description: "Protocol integer value (NULL means all protocols).",
+										Type:             schema.TypeString,
+										Optional:         true,
+										ValidateFunc:     validateProtocol,
+										DiffSuppressFunc: suppressEquivalentProtocol,
+										Description:      "Protocol name (any, icmp, tcp, udp or icmpv6) or number. Empty or any means all protocols.",
 									},
 									"port_range": {
 										Type:        schema.TypeList,
-										Required:    true,
+										Optional:    true,
 										MinItems:    2,
 										MaxItems:    2,
 										Description: "Inclusive range of ports; for example, [80, 80] or [5000, 6000].",
 										Elem: &schema.Schema{
-											Type: schema.TypeInt,
+											Type:         schema.TypeInt,
+											ValidateFunc: validatePort,
 										},
 									},
+									"ports": {
+										Type:         schema.TypeString,
+										Optional:     true,
+										ValidateFunc: validatePorts,
+										Description:  "Comma separated list of ports and port ranges, e.g. 80,443,8000-8100, instead of port_range.",
+									},
+									"service": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "Name of a service of the catalog, e.g. https or ssh, instead of protocol and ports. Services can be added to the catalog in the provider configuration.",
+									},
 									"approved": {
 										Type:        schema.TypeBool,
 										Optional:    true,
@@ -251,7 +317,6 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 			"default_policy": {
 				Type:        schema.TypeList,
 				Optional:    true,
-				ForceNew:    true,
 				Description: "Ordered application policy to be created with the default rank.",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
@@ -260,25 +325,56 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 							Optional:    true,
 							Description: "ID of a cluster, user inventory filter, or application scope.",
 						},
+						"consumer_scope_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Fully qualified name of a scope, for example Root:App:Web.",
+						},
 						"consumer_filter_name": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Description: "Named filter. If more than one filter with the same name exists you must specify consumer_filter_id.",
+							Description: "Name of a user inventory filter. If more than one filter with the same name exists you must specify consumer_filter_scope_name or consumer_filter_id.",
+						},
+						"consumer_filter_scope_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Fully qualified name of the scope owning the filter named by consumer_filter_name, to choose between filters with the same name.",
+						},
+						"consumer_cluster_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Name of a cluster of the workspace.",
 						},
 						"provider_filter_id": {
 							Type:        schema.TypeString,
 							Optional:    true,
 							Description: "ID of a cluster, user inventory filter, or application scope.",
 						},
+						"provider_scope_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Fully qualified name of a scope, for example Root:App:Web.",
+						},
 						"provider_filter_name": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Description: "Named filter. If more than one filter with the same name exists you must specify consumer_filter_id.",
+							Description: "Name of a user inventory filter. If more than one filter with the same name exists you must specify provider_filter_scope_name or provider_filter_id.",
 						},
-						"action": {
+						"provider_filter_scope_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Fully qualified name of the scope owning the filter named by provider_filter_name, to choose between filters with the same name.",
+						},
+						"provider_cluster_name": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Description: "“ALLOW” or “DENY”",
+							Description: "Name of a cluster of the workspace.",
+						},
+						"action": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateStringInSlice(policyActions, true),
+							Description:  "“ALLOW” or “DENY”",
 						},
 						"layer_4_network_policy": {
 							Type:        schema.TypeList,
@@ -287,21 +383,35 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 							Elem: &schema.Resource{
 								Schema: map[string]*schema.Schema{
 									"protocol": {
-										Type:        schema.TypeInt,
-										Optional:    true,
-										Default:     nil,
-										Description: "Protocol integer value (NULL means all protocols).",
+										Type:             schema.TypeString,
+										Optional:         true,
+										Default:          nil,
+										ValidateFunc:     validateProtocol,
+										DiffSuppressFunc: suppressEquivalentProtocol,
+										Description:      "Protocol name (any, icmp, tcp, udp or icmpv6) or number. Empty or any means all protocols.",
 									},
 									"port_range": {
 										Type:        schema.TypeList,
-										Required:    true,
+										Optional:    true,
 										MinItems:    2,
 										MaxItems:    2,
 										Description: "Inclusive range of ports; for example, [80, 80] or [5000, 6000].",
 										Elem: &schema.Schema{
-											Type: schema.TypeInt,
+											Type:         schema.TypeInt,
+											ValidateFunc: validatePort,
 										},
 									},
+									"ports": {
+										Type:         schema.TypeString,
+										Optional:     true,
+										ValidateFunc: validatePorts,
+										Description:  "Comma separated list of ports and port ranges, e.g. 80,443,8000-8100, instead of port_range.",
+									},
+									"service": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "Name of a service of the catalog, e.g. https or ssh, instead of protocol and ports. Services can be added to the catalog in the provider configuration.",
+									},
 									"approved": {
 										Type:        schema.TypeBool,
 										Optional:    true,
@@ -315,11 +425,21 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 				},
 			},
 			"catch_all_action": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "DENY",
+				ValidateFunc: validateStringInSlice(policyActions, false),
+				Description:  "“ALLOW” or “DENY”",
+			},
+			"version": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Default:     "DENY",
-				ForceNew:    true,
-				Description: "“ALLOW” or “DENY”",
+				Description: "(Optional) Version of the application policies to manage, for example p1 or v2. Defaults to the latest version.",
+			},
+			"current_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Version of the application policies read from the API.",
 			},
 			"author": {
 				Type:        schema.TypeString,
@@ -350,18 +470,18 @@ func resourceSecureWorkloadApplication() *schema.Resource {
 	}
 }
 
-func resourceSecureWorkloadApplicationCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadApplicationCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	isPrimaryApplication := d.Get("primary").(bool)
 	tempAppScopeId := d.Get("app_scope_id").(string)
 	if isPrimaryApplication {
 		existingApplications, err := client.ListApplications(tempAppScopeId)
 		if err != nil {
-			return err
+			return diag.FromErr(err)
 		}
 		for _, existingApplication := range existingApplications {
 			if existingApplication.Primary {
-				return errors.New(fmt.Sprintf("Existing application '' %s '' exists for scope '' %s '' that is marked as primary. Please demote the workspace to secondary before continuing.", existingApplication.Name, existingApplication.AppScopeId))
+				return diag.Errorf("Existing application '' %s '' exists for scope '' %s '' that is marked as primary. Please demote the workspace to secondary before continuing.", existingApplication.Name, existingApplication.AppScopeId)
 			}
 		}
 	}
@@ -383,7 +503,7 @@ func resourceSecureWorkloadApplicationCreate(d *schema.ResourceData, meta interf
 			}
 			cluster, err := clusterFromTerraform(tfCluster.(terraformObject))
 			if err != nil {
-				return err
+				return diag.FromErr(err)
 			}
 			clusters = append(clusters, cluster)
 		}
@@ -398,12 +518,18 @@ func resourceSecureWorkloadApplicationCreate(d *schema.ResourceData, meta interf
 			}
 			filter, err := filterFromTerraform(tfFilter.(terraformObject))
 			if err != nil {
-				return err
+				return diag.FromErr(err)
 			}
 			filters = append(filters, filter)
 		}
 		createApplicationParams.Filters = filters
 	}
+	// The workspace does not exist yet, so clusters can only be
+	// referenced by the ids of their blocks
+	references := policyReferences{
+		client:     client,
+		clusterIds: workspaceClusterIds(d, nil),
+	}
 	if value, ok := d.GetOk("absolute_policy"); ok {
 		var absolutePolicies []Policy
 		tfAbsolutePolicies := value.([]interface{})
@@ -411,9 +537,9 @@ func resourceSecureWorkloadApplicationCreate(d *schema.ResourceData, meta interf
 			if tfAbsolutePolicy == nil {
 				continue
 			}
-			abosolutePolicy, err := policyFromTerraform(client, tfAbsolutePolicy.(terraformObject))
+			abosolutePolicy, err := policyFromTerraform(references, tfAbsolutePolicy.(terraformObject))
 			if err != nil {
-				return err
+				return diag.FromErr(err)
 			}
 			absolutePolicies = append(absolutePolicies, abosolutePolicy)
 		}
@@ -426,9 +552,9 @@ func resourceSecureWorkloadApplicationCreate(d *schema.ResourceData, meta interf
 			if tfDefaultPolicy == nil {
 				continue
 			}
-			abosolutePolicy, err := policyFromTerraform(client, tfDefaultPolicy.(terraformObject))
+			abosolutePolicy, err := policyFromTerraform(references, tfDefaultPolicy.(terraformObject))
 			if err != nil {
-				return err
+				return diag.FromErr(err)
 			}
 			defaultPolicies = append(defaultPolicies, abosolutePolicy)
 		}
@@ -436,7 +562,7 @@ func resourceSecureWorkloadApplicationCreate(d *schema.ResourceData, meta interf
 	}
 	application, err := client.CreateApplication(createApplicationParams)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("author", application.Author)
 	d.Set("created_at", application.CreatedAt)
@@ -444,7 +570,7 @@ func resourceSecureWorkloadApplicationCreate(d *schema.ResourceData, meta interf
 	d.Set("enforcement_enabled", application.EnforcementEnabled)
 	d.Set("enforced_version", application.EnforcedVersion)
 	d.SetId(application.Id)
-	return nil
+	return resourceSecureWorkloadApplicationRead(ctx, d, meta)
 }
 
 type terraformObject = map[string]interface{}
@@ -484,81 +610,216 @@ func filterFromTerraform(tf terraformObject) (PolicyFilter, error) {
 	}, nil
 }
 
+// policyReferences resolves the consumers and providers of the
+// policy blocks of a workspace to the ids of filters.
+type policyReferences struct {
+	client Client
+	// clusterIds maps the names of the clusters of the
+	// workspace to their ids, see workspaceClusterIds.
+	clusterIds map[string]string
+	// filterIds maps the ids of the cluster and filter blocks to
+	// the ids assigned by the API, see workspaceFilterIds.
+	filterIds map[string]string
+}
+
+// policyFilterQuery is the reference to the consumer or provider of a
+// policy, where exactly one of AbsoluteId, ScopeName, FilterName and
+// ClusterName is set.
 type policyFilterQuery struct {
+	// consumer or provider, used to name the attributes in errors.
+	Side       string
 	AbsoluteId string
-	FilterName string
+	// Fully qualified name of a scope, e.g. Root:App:Web.
 	ScopeName  string
+	FilterName string
+	// Fully qualified name of the scope owning the filter, to tell
+	// apart filters with the same name.
+	FilterScopeName string
+	// Name of a cluster of the workspace.
+	ClusterName string
 }
 
-func policyFilterIdForQuery(apiClient Client, query policyFilterQuery) (string, error) {
-	if query.AbsoluteId == "" && query.FilterName == "" && query.ScopeName == "" {
-		return "", errors.New("One  of policy filter id, filter name or scope name must be specified")
+// policyFilterQueryFromTerraform reads the reference to
+// the consumer or provider of a policy block.
+func policyFilterQueryFromTerraform(tf terraformObject, side string) policyFilterQuery {
+	attribute := func(name string) string {
+		value, _ := tf[side+"_"+name].(string)
+		return value
 	}
-	if query.AbsoluteId != "" && (query.FilterName != "" || query.ScopeName != "") {
-		return "", errors.New("Only one of policy filter id, filter name or scope name can be specified")
+	return policyFilterQuery{
+		Side:            side,
+		AbsoluteId:      attribute("filter_id"),
+		ScopeName:       attribute("scope_name"),
+		FilterName:      attribute("filter_name"),
+		FilterScopeName: attribute("filter_scope_name"),
+		ClusterName:     attribute("cluster_name"),
 	}
-	if query.FilterName != "" && query.ScopeName != "" {
-		return "", errors.New("Only one of policy filter id, filter name or scope name can be specified")
+}
+
+func policyFilterIdForQuery(references policyReferences, query policyFilterQuery) (string, error) {
+	var set []string
+	for attribute, value := range map[string]string{
+		"filter_id":    query.AbsoluteId,
+		"scope_name":   query.ScopeName,
+		"filter_name":  query.FilterName,
+		"cluster_name": query.ClusterName,
+	} {
+		if value != "" {
+			set = append(set, query.Side+"_"+attribute)
+		}
 	}
-	if query.AbsoluteId != "" {
-		return query.AbsoluteId, nil
+	if len(set) == 0 {
+		return "", fmt.Errorf("one of %[1]s_filter_id, %[1]s_scope_name, %[1]s_filter_name or %[1]s_cluster_name must be specified", query.Side)
+	}
+	if len(set) > 1 {
+		sort.Strings(set)
+		return "", fmt.Errorf("only one of %[1]s_filter_id, %[1]s_scope_name, %[1]s_filter_name or %[1]s_cluster_name can be specified, got %[2]s", query.Side, strings.Join(set, " and "))
 	}
-	var secureworkloadPolicyFilterId string
-	if query.FilterName != "" {
-		inventoryFilters, err := apiClient.ListFilters()
+	if query.FilterScopeName != "" && query.FilterName == "" {
+		return "", fmt.Errorf("%[1]s_filter_scope_name can only be specified along with %[1]s_filter_name", query.Side)
+	}
+	switch {
+	case query.AbsoluteId != "":
+		return query.AbsoluteId, nil
+	case query.ScopeName != "":
+		scope, err := scopeByName(references.client, query.ScopeName)
 		if err != nil {
-			return "", err
+			return "", fmt.Errorf("%s_scope_name: %w", query.Side, err)
+		}
+		return scope.Id, nil
+	case query.ClusterName != "":
+		if clusterId, ok := references.clusterIds[query.ClusterName]; ok {
+			return clusterId, nil
 		}
-		var filtersWithMatchingName []Filter
+		var names []string
+		for name := range references.clusterIds {
+			names = append(names, fmt.Sprintf("%q", name))
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return "", unresolvedReference("%s_cluster_name: no cluster named %q exists in the workspace, it has no clusters", query.Side, query.ClusterName)
+		}
+		return "", unresolvedReference("%s_cluster_name: no cluster named %q exists in the workspace, it has the clusters %s", query.Side, query.ClusterName, strings.Join(names, ", "))
+	}
+	return policyFilterIdForFilterName(references.client, query)
+}
+
+// policyFilterIdForFilterName resolves a reference to an inventory
+// filter by name, and by owning scope when one is given.
+func policyFilterIdForFilterName(apiClient Client, query policyFilterQuery) (string, error) {
+	inventoryFilters, err := apiClient.LookupFilters()
+	if err != nil {
+		return "", err
+	}
+	var filtersWithMatchingName []Filter
+	for _, inventoryFilter := range inventoryFilters {
+		if inventoryFilter.Name == query.FilterName {
+			filtersWithMatchingName = append(filtersWithMatchingName, inventoryFilter)
+		}
+	}
+	if len(filtersWithMatchingName) == 0 {
+		var similar []string
 		for _, inventoryFilter := range inventoryFilters {
-			if inventoryFilter.Name == query.FilterName {
-				filtersWithMatchingName = append(filtersWithMatchingName, inventoryFilter)
+			if strings.Contains(strings.ToLower(inventoryFilter.Name), strings.ToLower(query.FilterName)) {
+				similar = append(similar, fmt.Sprintf("%q", inventoryFilter.Name))
 			}
 		}
-		if len(filtersWithMatchingName) > 1 {
-			return "", errors.New(fmt.Sprintf("More than one filter exists with name %s, please use policy filter id to specify the exact one to use.", query.FilterName))
+		if len(similar) == 0 {
+			return "", unresolvedReference("%s_filter_name: no inventory filter named %q exists", query.Side, query.FilterName)
 		}
-		secureworkloadPolicyFilterId = filtersWithMatchingName[0].Id
+		sort.Strings(similar)
+		return "", unresolvedReference("%s_filter_name: no inventory filter named %q exists, similar filters are %s", query.Side, query.FilterName, strings.Join(similar, ", "))
 	}
-	if query.ScopeName != "" {
-		scopes, err := apiClient.ListScopes()
-		if err != nil {
-			return "", err
-		}
-		var scopesWithMatchingName []Scope
-		for _, scope := range scopes {
-			if scope.ShortName == query.ScopeName {
-				scopesWithMatchingName = append(scopesWithMatchingName, scope)
+	scopes, err := apiClient.LookupScopes()
+	if err != nil {
+		return "", err
+	}
+	scopeNames := map[string]string{}
+	for _, scope := range scopes {
+		scopeNames[scope.Id] = scope.Name
+	}
+	if query.FilterScopeName != "" {
+		var filtersInScope []Filter
+		for _, inventoryFilter := range filtersWithMatchingName {
+			if scopeNames[inventoryFilter.AppScopeId] == query.FilterScopeName {
+				filtersInScope = append(filtersInScope, inventoryFilter)
 			}
 		}
-		if len(scopesWithMatchingName) > 1 {
-			return "", errors.New(fmt.Sprintf("More than one scope exists with name %s, please use policy filter id to specify the exact one to use.", query.ScopeName))
+		if len(filtersInScope) == 0 {
+			return "", unresolvedReference("%s_filter_scope_name: no inventory filter named %q is owned by scope %q, it is owned by:\n%s",
+				query.Side, query.FilterName, query.FilterScopeName, filterCandidates(filtersWithMatchingName, scopeNames))
 		}
-		secureworkloadPolicyFilterId = scopesWithMatchingName[0].Id
+		filtersWithMatchingName = filtersInScope
 	}
-	return secureworkloadPolicyFilterId, nil
+	if len(filtersWithMatchingName) > 1 {
+		return "", unresolvedReference("%[1]s_filter_name: %[2]d inventory filters are named %[3]q, set %[1]s_filter_scope_name or %[1]s_filter_id to choose one of:\n%[4]s",
+			query.Side, len(filtersWithMatchingName), query.FilterName, filterCandidates(filtersWithMatchingName, scopeNames))
+	}
+	return filtersWithMatchingName[0].Id, nil
+}
+
+// filterCandidates lists inventory filters along with
+// the name of their owning scope, one per line.
+func filterCandidates(filters []Filter, scopeNames map[string]string) string {
+	var candidates []string
+	for _, filter := range filters {
+		candidates = append(candidates, fmt.Sprintf("  - %s owned by scope %q", filter.Id, scopeNames[filter.AppScopeId]))
+	}
+	sort.Strings(candidates)
+	return strings.Join(candidates, "\n")
+}
+
+// scopeByName looks up a scope by its fully qualified name,
+// suggesting scopes with the same short name when there is none.
+func scopeByName(apiClient Client, name string) (Scope, error) {
+	scopes, err := apiClient.LookupScopes()
+	if err != nil {
+		return Scope{}, err
+	}
+	shortName := name[strings.LastIndex(name, ":")+1:]
+	var similar []string
+	for _, scope := range scopes {
+		if scope.Name == name {
+			return scope, nil
+		}
+		if strings.EqualFold(scope.ShortName, shortName) || strings.EqualFold(scope.Name, name) {
+			similar = append(similar, fmt.Sprintf("%q", scope.Name))
+		}
+	}
+	if len(similar) == 0 {
+		return Scope{}, unresolvedReference("no scope named %q exists, scope names are fully qualified, e.g. Root:App:Web", name)
+	}
+	sort.Strings(similar)
+	return Scope{}, unresolvedReference("no scope named %q exists, similar scopes are %s", name, strings.Join(similar, ", "))
+}
+
+// unresolvedReferenceError reports a reference by name that matches no
+// scope, filter or cluster, or several filters, as opposed to an error
+// looking it up.
+type unresolvedReferenceError struct {
+	message string
 }
 
-func policyFromTerraform(apiClient Client, tf terraformObject) (Policy, error) {
+func (e unresolvedReferenceError) Error() string {
+	return e.message
+}
+
+func unresolvedReference(format string, args ...interface{}) error {
+	return unresolvedReferenceError{message: fmt.Sprintf(format, args...)}
+}
+
+func policyFromTerraform(references policyReferences, tf terraformObject) (Policy, error) {
 	policy := Policy{}
 	// Allow users to specify a consumer or provider filter via
-	// absolute id OR scope name OR filter name
-	// returning an error if either more than one scope/filter exists
-	// with the same name or if both an absolute id and name was provided
-	consumingPolicyFilterQuery := policyFilterQuery{
-		AbsoluteId: tf["consumer_filter_id"].(string),
-		FilterName: tf["consumer_filter_name"].(string),
-	}
-	filterId, err := policyFilterIdForQuery(apiClient, consumingPolicyFilterQuery)
+	// absolute id OR scope name OR filter name OR cluster name
+	// returning an error if either more than one filter matches
+	// the name or if more than one of them was provided
+	filterId, err := policyFilterIdForQuery(references, policyFilterQueryFromTerraform(tf, "consumer"))
 	if err != nil {
 		return policy, err
 	}
 	policy.ConsumerFilterId = filterId
-	providingPolicyFilterQuery := policyFilterQuery{
-		AbsoluteId: tf["provider_filter_id"].(string),
-		FilterName: tf["provider_filter_name"].(string),
-	}
-	filterId, err = policyFilterIdForQuery(apiClient, providingPolicyFilterQuery)
+	filterId, err = policyFilterIdForQuery(references, policyFilterQueryFromTerraform(tf, "provider"))
 	if err != nil {
 		return policy, err
 	}
@@ -571,42 +832,862 @@ func policyFromTerraform(apiClient Client, tf terraformObject) (Policy, error) {
 			if tfLayer4NetworkPolicy == nil {
 				continue
 			}
-			layer4NetworkPolicies = append(layer4NetworkPolicies, layer4NetworkPolicyFromTerraform(tfLayer4NetworkPolicy.(terraformObject)))
+			expanded, err := layer4NetworkPoliciesFromTerraform(tfLayer4NetworkPolicy.(terraformObject), references.client.services())
+			if err != nil {
+				return policy, err
+			}
+			layer4NetworkPolicies = append(layer4NetworkPolicies, expanded...)
 		}
 		policy.Layer4NetworkPolicies = layer4NetworkPolicies
 	}
 	return policy, nil
 }
 
-func layer4NetworkPolicyFromTerraform(tf terraformObject) Layer4NetworkPolicy {
+// customizeApplicationPortRangesDiff checks at plan time that the port
+// ranges of the policies do not start after they end and that their ports
+// and services can be expanded, once known.
+func customizeApplicationPortRangesDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	client, _ := meta.(Client)
+	for _, rank := range []string{"absolute_policy", "default_policy"} {
+		for i, tfPolicy := range d.Get(rank).([]interface{}) {
+			if tfPolicy == nil {
+				continue
+			}
+			for j, tfLayer4NetworkPolicy := range tfPolicy.(terraformObject)["layer_4_network_policy"].([]interface{}) {
+				path := fmt.Sprintf("%s.%d.layer_4_network_policy.%d", rank, i, j)
+				if tfLayer4NetworkPolicy == nil || !d.NewValueKnown(path+".port_range") || !d.NewValueKnown(path+".ports") || !d.NewValueKnown(path+".service") {
+					continue
+				}
+				tf := tfLayer4NetworkPolicy.(terraformObject)
+				tfPortRange := tf["port_range"].([]interface{})
+				if len(tfPortRange) == 2 {
+					if err := checkPortRange(path+".port_range", tfPortRange[0].(int), tfPortRange[1].(int)); err != nil {
+						return err
+					}
+				}
+				if _, err := layer4NetworkPoliciesFromTerraform(tf, client.services()); err != nil {
+					return fmt.Errorf("%s: %s", path, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// layer4NetworkPoliciesFromTerraform returns the layer 4 parameters of a
+// layer_4_network_policy block, one for its port range or one per port
+// range of its ports or service.
+func layer4NetworkPoliciesFromTerraform(tf terraformObject, services serviceCatalog) ([]Layer4NetworkPolicy, error) {
 	tfPortRange := tf["port_range"].([]interface{})
-	return Layer4NetworkPolicy{
-		Protocol:  tf["protocol"].(int),
+	ports, service := tf["ports"].(string), tf["service"].(string)
+	approved := tf["approved"].(bool)
+	switch {
+	case service != "":
+		if ports != "" || len(tfPortRange) > 0 {
+			return nil, fmt.Errorf("service can not be combined with ports or port_range")
+		}
+		if err := checkServiceProtocol(tf["protocol"].(string)); err != nil {
+			return nil, err
+		}
+		return services.layer4NetworkPolicies(service, approved)
+	case ports != "":
+		if len(tfPortRange) > 0 {
+			return nil, fmt.Errorf("ports can not be combined with port_range")
+		}
+		return layer4NetworkPoliciesForPorts(tf["protocol"].(string), ports, approved)
+	case len(tfPortRange) != 2:
+		return nil, fmt.Errorf("one of port_range, ports or service must be set")
+	}
+	protocol, err := protocolNumber(tf["protocol"].(string))
+	if err != nil {
+		return nil, err
+	}
+	return []Layer4NetworkPolicy{{
+		Protocol:  protocol,
 		PortRange: [2]int{tfPortRange[0].(int), tfPortRange[1].(int)},
-		Approved:  tf["approved"].(bool),
+		Approved:  approved,
+	}}, nil
+}
+
+func clusterToTerraform(cluster Cluster) terraformObject {
+	nodes := []interface{}{}
+	for _, node := range cluster.Nodes {
+		nodes = append(nodes, terraformObject{
+			"ip_address": node.IPAddress,
+			"name":       node.Name,
+		})
+	}
+	return terraformObject{
+		"id":              cluster.Id,
+		"name":            cluster.Name,
+		"description":     cluster.Description,
+		"node":            nodes,
+		"consistent_uuid": cluster.ConsistentUUID,
+	}
+}
+
+func filterToTerraform(filter PolicyFilter) terraformObject {
+	return terraformObject{
+		"id":    filter.Id,
+		"name":  filter.Name,
+		"query": string(filter.Query),
+	}
+}
+
+func policiesToTerraform(policies []Policies) []interface{} {
+	tfPolicies := []interface{}{}
+	for _, policy := range policies {
+		layer4NetworkPolicies := []interface{}{}
+		for _, l4Param := range policy.L4Params {
+			layer4NetworkPolicies = append(layer4NetworkPolicies, layer4NetworkPolicyToTerraform("", l4Param.Proto, l4Param.Port, l4Param.Approved))
+		}
+		tfPolicies = append(tfPolicies, terraformObject{
+			"consumer_filter_id":         policy.ConsumerId,
+			"consumer_scope_name":        "",
+			"consumer_filter_name":       "",
+			"consumer_filter_scope_name": "",
+			"consumer_cluster_name":      "",
+			"provider_filter_id":         policy.ProviderId,
+			"provider_scope_name":        "",
+			"provider_filter_name":       "",
+			"provider_filter_scope_name": "",
+			"provider_cluster_name":      "",
+			"action":                     policy.Action,
+			"layer_4_network_policy":     layer4NetworkPolicies,
+		})
+	}
+	return tfPolicies
+}
+
+// layer4NetworkPolicyToTerraform returns the layer_4_network_policy block of
+// the layer 4 parameters returned by the API, keeping the configured spelling
+// of the protocol, if any, see protocolToTerraform.
+func layer4NetworkPolicyToTerraform(configured string, protocol int, portRange [2]int, approved bool) terraformObject {
+	return terraformObject{
+		"protocol":   protocolToTerraform(configured, protocol),
+		"port_range": []interface{}{portRange[0], portRange[1]},
+		"ports":      "",
+		"service":    "",
+		"approved":   approved,
 	}
 }
 
-func resourceSecureWorkloadApplicationRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadApplicationRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	describeApplicatioParams := DescribeApplicationRequest{
 		ApplicationId: d.Id(),
+		Version:       d.Get("version").(string),
 	}
 	application, err := client.DescribeApplication(describeApplicatioParams)
 	if err != nil {
-		return err
+		return diag.FromErr(removeIfNotFound(d, err))
 	}
+	d.Set("app_scope_id", application.AppScopeId)
 	d.Set("name", application.Name)
 	d.Set("description", application.Description)
 	d.Set("primary", application.Primary)
 	d.Set("alternate_query_mode", application.AlternateQueryMode)
+	d.Set("author", application.Author)
+	d.Set("created_at", application.CreatedAt)
 	d.Set("latest_adm_version", application.LatestADMVersion)
 	d.Set("enforcement_enabled", application.EnforcementEnabled)
 	d.Set("enforced_version", application.EnforcedVersion)
+	details, err := client.DescribeApplicationDetails(describeApplicatioParams)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.Set("current_version", details.Version)
+	if details.CatchAllAction != "" {
+		d.Set("catch_all_action", details.CatchAllAction)
+	}
+	if err := d.Set("cluster", refreshClustersFromDetails(d.Get("cluster").([]interface{}), details.Clusters)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("filter", refreshFiltersFromDetails(d.Get("filter").([]interface{}), details.Filters)); err != nil {
+		return diag.FromErr(err)
+	}
+	policies, err := client.ListPolicies(d.Id(), describeApplicatioParams.Version)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	references := policyReferences{
+		client:     client,
+		clusterIds: workspaceClusterIds(d, details.Clusters),
+		filterIds:  workspaceFilterIds(d, details),
+	}
+	absolutePolicies, err := refreshPolicies(references, d.Get("absolute_policy").([]interface{}), policies.AbsolutePolicies)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("absolute_policy", absolutePolicies); err != nil {
+		return diag.FromErr(err)
+	}
+	defaultPolicies, err := refreshPolicies(references, d.Get("default_policy").([]interface{}), policies.DefaultPolicies)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(d.Set("default_policy", defaultPolicies))
+}
+
+func resourceSecureWorkloadApplicationUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	version := d.Get("version").(string)
+	if d.HasChanges("name", "description", "primary") {
+		updateApplicationParams := UpdateApplicationRequest{
+			Name:        d.Get("name").(string),
+			Description: d.Get("description").(string),
+			Primary:     d.Get("primary").(bool),
+		}
+		_, err := client.UpdateApplication(d.Id(), updateApplicationParams)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if d.HasChange("catch_all_action") {
+		err := client.UpdateCatchAll(d.Id(), UpdateCatchAllRequest{
+			Version: version,
+			Action:  d.Get("catch_all_action").(string),
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	var removedClusterIds, removedFilterIds []string
+	if d.HasChanges("cluster", "filter") {
+		details, err := client.DescribeApplicationDetails(DescribeApplicationRequest{
+			ApplicationId: d.Id(),
+			Version:       version,
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		tfOld, tfNew := d.GetChange("cluster")
+		removedClusterIds, err = reconcileClusters(client, d.Id(), version, clustersFromTerraform(tfOld.([]interface{})), clustersFromTerraform(tfNew.([]interface{})), details.Clusters)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		tfOld, tfNew = d.GetChange("filter")
+		removedFilterIds, err = reconcileFilters(client, d.Get("app_scope_id").(string), filtersFromTerraform(tfOld.([]interface{})), filtersFromTerraform(tfNew.([]interface{})), details.Filters)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if d.HasChanges("absolute_policy", "default_policy", "version") {
+		details, err := client.DescribeApplicationDetails(DescribeApplicationRequest{
+			ApplicationId: d.Id(),
+			Version:       version,
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		current, err := client.ListPolicies(d.Id(), version)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		references := policyReferences{
+			client:     client,
+			clusterIds: workspaceClusterIds(d, details.Clusters),
+			filterIds:  workspaceFilterIds(d, details),
+		}
+		ranks := []struct {
+			key     string
+			rank    string
+			current []Policies
+		}{
+			{"absolute_policy", "ABSOLUTE", current.AbsolutePolicies},
+			{"default_policy", "DEFAULT", current.DefaultPolicies},
+		}
+		for _, rank := range ranks {
+			tfOld, tfNew := d.GetChange(rank.key)
+			oldPolicies, err := policiesFromTerraform(references, tfOld.([]interface{}))
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			newPolicies, err := policiesFromTerraform(references, tfNew.([]interface{}))
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			err = reconcilePolicies(client, d.Id(), version, rank.rank, oldPolicies, newPolicies, rank.current)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+	// Clusters and filters are removed last, once
+	// no policy of the workspace references them
+	for _, clusterId := range removedClusterIds {
+		err := client.DeleteCluster(d.Id(), clusterId)
+		if err != nil && !IsNotFound(err) {
+			return diag.FromErr(err)
+		}
+	}
+	for _, filterId := range removedFilterIds {
+		err := client.DeleteFilter(filterId)
+		if err != nil && !IsNotFound(err) {
+			return diag.FromErr(err)
+		}
+	}
+	return resourceSecureWorkloadApplicationRead(ctx, d, meta)
+}
+
+func resourceSecureWorkloadApplicationImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(Client).WithContext(ctx)
+	// Nothing is in state yet, so adopt every cluster, filter and
+	// policy of the workspace using the ids assigned by the API
+	details, err := client.DescribeApplicationDetails(DescribeApplicationRequest{
+		ApplicationId: d.Id(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var clusters []interface{}
+	for _, cluster := range details.Clusters {
+		clusters = append(clusters, clusterToTerraform(cluster))
+	}
+	d.Set("cluster", clusters)
+	var filters []interface{}
+	for _, filter := range details.Filters {
+		filters = append(filters, filterToTerraform(filter))
+	}
+	d.Set("filter", filters)
+	policies, err := client.ListPolicies(d.Id(), "")
+	if err != nil {
+		return nil, err
+	}
+	d.Set("absolute_policy", policiesToTerraform(policies.AbsolutePolicies))
+	d.Set("default_policy", policiesToTerraform(policies.DefaultPolicies))
+	// Arguments that are only sent on create can not be read back,
+	// so start from their defaults to avoid planning a replacement
+	d.Set("catch_all_action", "DENY")
+	return []*schema.ResourceData{d}, nil
+}
+
+// workspaceClusterIds maps the names of the clusters of a workspace to
+// the ids used to reference them in policies: the ids of the cluster
+// blocks, or the ids assigned by the API for the other clusters.
+func workspaceClusterIds(d *schema.ResourceData, clusters []Cluster) map[string]string {
+	clusterIds := map[string]string{}
+	for _, tfCluster := range d.Get("cluster").([]interface{}) {
+		if tfCluster == nil {
+			continue
+		}
+		tf := tfCluster.(terraformObject)
+		if tf["id"].(string) != "" && tf["name"].(string) != "" {
+			clusterIds[tf["name"].(string)] = tf["id"].(string)
+		}
+	}
+	for _, cluster := range clusters {
+		if _, ok := clusterIds[cluster.Name]; !ok {
+			clusterIds[cluster.Name] = cluster.Id
+		}
+	}
+	return clusterIds
+}
+
+// workspaceFilterIds maps the ids of the cluster and filter blocks, which
+// only identify them inside the workspace definition, to the ids the API
+// assigned to them, matching the blocks by name.
+func workspaceFilterIds(d *schema.ResourceData, details ApplicationDetails) map[string]string {
+	filterIds := map[string]string{}
+	for _, tfCluster := range d.Get("cluster").([]interface{}) {
+		if tfCluster == nil {
+			continue
+		}
+		tf := tfCluster.(terraformObject)
+		for _, cluster := range details.Clusters {
+			if tf["id"].(string) != "" && cluster.Name == tf["name"].(string) {
+				filterIds[tf["id"].(string)] = cluster.Id
+				break
+			}
+		}
+	}
+	for _, tfFilter := range d.Get("filter").([]interface{}) {
+		if tfFilter == nil {
+			continue
+		}
+		tf := tfFilter.(terraformObject)
+		for _, filter := range details.Filters {
+			if tf["id"].(string) != "" && filter.Name == tf["name"].(string) {
+				filterIds[tf["id"].(string)] = filter.Id
+				break
+			}
+		}
+	}
+	return filterIds
+}
+
+// resolvedPolicyFromTerraform converts a policy block to a policy whose
+// consumer and provider are referenced by the ids assigned by the API.
+func resolvedPolicyFromTerraform(references policyReferences, tf terraformObject) (Policy, error) {
+	policy, err := policyFromTerraform(references, tf)
+	if err != nil {
+		return policy, err
+	}
+	if filterId, ok := references.filterIds[policy.ConsumerFilterId]; ok {
+		policy.ConsumerFilterId = filterId
+	}
+	if filterId, ok := references.filterIds[policy.ProviderFilterId]; ok {
+		policy.ProviderFilterId = filterId
+	}
+	return policy, nil
+}
+
+func policiesFromTerraform(references policyReferences, tfPolicies []interface{}) ([]Policy, error) {
+	var policies []Policy
+	for _, tfPolicy := range tfPolicies {
+		if tfPolicy == nil {
+			continue
+		}
+		policy, err := resolvedPolicyFromTerraform(references, tfPolicy.(terraformObject))
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// policyKey identifies policies with the same consumer, provider and action.
+func policyKey(consumerFilterId string, providerFilterId string, action string) string {
+	return fmt.Sprintf("%s|%s|%s", consumerFilterId, providerFilterId, strings.ToUpper(action))
+}
+
+// layer4Key identifies layer 4 parameters with the same protocol, ports and approval.
+func layer4Key(protocol int, portRange [2]int, approved bool) string {
+	return fmt.Sprintf("%d|%d-%d|%t", protocol, portRange[0], portRange[1], approved)
+}
+
+// layer4Pool counts the layer 4 parameters returned by the API
+// by key, so that each of them is matched at most once.
+type layer4Pool map[string]int
+
+func newLayer4Pool(l4Params []PolicyL4Param) layer4Pool {
+	pool := layer4Pool{}
+	for _, l4Param := range l4Params {
+		pool[layer4Key(l4Param.Proto, l4Param.Port, l4Param.Approved)]++
+	}
+	return pool
+}
+
+// claim claims all the given layer 4 parameters, or none of them
+// when one is missing, reporting whether they were claimed.
+func (pool layer4Pool) claim(layer4NetworkPolicies []Layer4NetworkPolicy) bool {
+	wanted := map[string]int{}
+	for _, layer4NetworkPolicy := range layer4NetworkPolicies {
+		wanted[layer4Key(layer4NetworkPolicy.Protocol, layer4NetworkPolicy.PortRange, layer4NetworkPolicy.Approved)]++
+	}
+	for key, count := range wanted {
+		if pool[key] < count {
+			return false
+		}
+	}
+	for key, count := range wanted {
+		pool[key] -= count
+	}
+	return true
+}
+
+// unclaimed returns the layer 4 parameters that were not claimed, in order.
+func (pool layer4Pool) unclaimed(l4Params []PolicyL4Param) []PolicyL4Param {
+	var unclaimed []PolicyL4Param
+	for _, l4Param := range l4Params {
+		key := layer4Key(l4Param.Proto, l4Param.Port, l4Param.Approved)
+		if pool[key] == 0 {
+			continue
+		}
+		pool[key]--
+		unclaimed = append(unclaimed, l4Param)
+	}
+	return unclaimed
+}
+
+// policyPool hands out the policies returned by the API
+// by key, so that each policy is matched at most once.
+type policyPool map[string][]Policies
+
+func newPolicyPool(policies []Policies) policyPool {
+	pool := policyPool{}
+	for _, policy := range policies {
+		key := policyKey(policy.ConsumerId, policy.ProviderId, policy.Action)
+		pool[key] = append(pool[key], policy)
+	}
+	return pool
+}
+
+func (pool policyPool) claim(key string) (Policies, bool) {
+	policies := pool[key]
+	if len(policies) == 0 {
+		return Policies{}, false
+	}
+	pool[key] = policies[1:]
+	return policies[0], true
+}
+
+// reconcilePolicies applies the difference between the old and new policies
+// of one rank to a workspace, adding and removing only the policies and
+// layer 4 parameters that changed. Policies of the workspace that were never
+// part of the configuration, e.g. managed by secureworkload_policies, are left as is.
+func reconcilePolicies(apiClient Client, workspaceId string, version string, rank string, oldPolicies []Policy, newPolicies []Policy, current []Policies) error {
+	pool := newPolicyPool(current)
+	desired := map[string]int{}
+	for _, policy := range newPolicies {
+		key := policyKey(policy.ConsumerFilterId, policy.ProviderFilterId, policy.Action)
+		desired[key]++
+		existing, ok := pool.claim(key)
+		if !ok {
+			created, err := apiClient.CreatePolicy(CreatePolicyRequest{
+				ConsumerId: policy.ConsumerFilterId,
+				ProviderId: policy.ProviderFilterId,
+				Version:    version,
+				Rank:       rank,
+				Action:     policy.Action,
+			}, workspaceId)
+			if err != nil {
+				return err
+			}
+			existing = created
+		}
+		err := reconcileLayer4NetworkPolicies(apiClient, version, existing, policy.Layer4NetworkPolicies)
+		if err != nil {
+			return err
+		}
+	}
+	for _, policy := range oldPolicies {
+		key := policyKey(policy.ConsumerFilterId, policy.ProviderFilterId, policy.Action)
+		if desired[key] > 0 {
+			desired[key]--
+			continue
+		}
+		existing, ok := pool.claim(key)
+		if !ok {
+			continue
+		}
+		err := apiClient.DeletePolicy(workspaceId, existing.Id)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+	}
 	return nil
 }
 
-func resourceSecureWorkloadApplicationDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	return client.DeleteApplication(d.Id())
+// reconcileLayer4NetworkPolicies adds the desired layer 4 parameters missing
+// from a policy and removes the ones that are no longer desired.
+func reconcileLayer4NetworkPolicies(apiClient Client, version string, policy Policies, desired []Layer4NetworkPolicy) error {
+	existing := map[string][]PolicyL4Param{}
+	for _, l4Param := range policy.L4Params {
+		key := layer4Key(l4Param.Proto, l4Param.Port, l4Param.Approved)
+		existing[key] = append(existing[key], l4Param)
+	}
+	for _, layer4NetworkPolicy := range desired {
+		key := layer4Key(layer4NetworkPolicy.Protocol, layer4NetworkPolicy.PortRange, layer4NetworkPolicy.Approved)
+		if len(existing[key]) > 0 {
+			existing[key] = existing[key][1:]
+			continue
+		}
+		_, err := apiClient.CreatePort(CreatePortRequest{
+			StartPort: layer4NetworkPolicy.PortRange[0],
+			EndPort:   layer4NetworkPolicy.PortRange[1],
+			Proto:     layer4NetworkPolicy.Protocol,
+			Approved:  layer4NetworkPolicy.Approved,
+			Version:   version,
+		}, policy.Id)
+		if err != nil {
+			return err
+		}
+	}
+	for _, l4Params := range existing {
+		for _, l4Param := range l4Params {
+			err := apiClient.DeletePort(policy.Id, l4Param.Id)
+			if err != nil && !IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func clustersFromTerraform(tfClusters []interface{}) []Cluster {
+	var clusters []Cluster
+	for _, tfCluster := range tfClusters {
+		if tfCluster == nil {
+			continue
+		}
+		cluster, _ := clusterFromTerraform(tfCluster.(terraformObject))
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+func filtersFromTerraform(tfFilters []interface{}) []PolicyFilter {
+	var filters []PolicyFilter
+	for _, tfFilter := range tfFilters {
+		if tfFilter == nil {
+			continue
+		}
+		filter, _ := filterFromTerraform(tfFilter.(terraformObject))
+		filters = append(filters, filter)
+	}
+	return filters
+}
+
+// matchingBlock returns the index of the block of old with the given
+// workspace local id, or else with the given name, or -1 if there is none.
+func matchingBlock(count int, block func(int) (string, string), id string, name string) int {
+	for i := 0; i < count; i++ {
+		if oldId, _ := block(i); id != "" && oldId == id {
+			return i
+		}
+	}
+	for i := 0; i < count; i++ {
+		if _, oldName := block(i); name != "" && oldName == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// clusterQuery returns the query matching the nodes of a cluster, as
+// clusters are defined by a query when created or updated on their own.
+func clusterQuery(nodes []Node) (json.RawMessage, error) {
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	query := ScopeQuery{Type: "or"}
+	for _, node := range nodes {
+		filterType := "eq"
+		if strings.Contains(node.IPAddress, "/") {
+			filterType = "subnet"
+		}
+		query.Filters = append(query.Filters, ScopeQuery{Type: filterType, Field: "ip", Value: node.IPAddress})
+	}
+	return json.Marshal(query)
+}
+
+// reconcileClusters applies the difference between the old and new cluster
+// blocks to a workspace, creating the new clusters and updating the changed
+// ones in place. Old and new blocks are matched by their workspace local id,
+// or else their name, and old blocks by name to the current clusters.
+// The ids of the clusters of removed blocks are returned, to be deleted
+// once no policy references them.
+func reconcileClusters(apiClient Client, workspaceId string, version string, oldClusters []Cluster, newClusters []Cluster, current []Cluster) ([]string, error) {
+	currentIds := map[string]string{}
+	for _, cluster := range current {
+		currentIds[cluster.Name] = cluster.Id
+	}
+	matched := make([]bool, len(oldClusters))
+	for _, cluster := range newClusters {
+		query, err := clusterQuery(cluster.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		i := matchingBlock(len(oldClusters), func(i int) (string, string) {
+			return oldClusters[i].Id, oldClusters[i].Name
+		}, cluster.Id, cluster.Name)
+		if i >= 0 && !matched[i] {
+			matched[i] = true
+			if clusterId, ok := currentIds[oldClusters[i].Name]; ok {
+				if reflect.DeepEqual(oldClusters[i], cluster) {
+					continue
+				}
+				_, err := apiClient.UpdateCluster(clusterId, UpdateClusterRequest{
+					Name:        cluster.Name,
+					Description: cluster.Description,
+					Query:       query,
+				})
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		_, err = apiClient.CreateCluster(CreateClusterRequest{
+			Name:        cluster.Name,
+			Version:     version,
+			Description: cluster.Description,
+			Query:       query,
+		}, workspaceId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var removed []string
+	for i, cluster := range oldClusters {
+		if clusterId, ok := currentIds[cluster.Name]; ok && !matched[i] {
+			removed = append(removed, clusterId)
+		}
+	}
+	return removed, nil
+}
+
+// reconcileFilters applies the difference between the old and new filter
+// blocks of a workspace, creating the new inventory filters in the scope of
+// the workspace and updating the changed ones in place, matching blocks like
+// reconcileClusters. The ids of the filters of removed blocks are returned,
+// to be deleted once no policy references them.
+func reconcileFilters(apiClient Client, appScopeId string, oldFilters []PolicyFilter, newFilters []PolicyFilter, current []PolicyFilter) ([]string, error) {
+	currentIds := map[string]string{}
+	for _, filter := range current {
+		currentIds[filter.Name] = filter.Id
+	}
+	matched := make([]bool, len(oldFilters))
+	for _, filter := range newFilters {
+		i := matchingBlock(len(oldFilters), func(i int) (string, string) {
+			return oldFilters[i].Id, oldFilters[i].Name
+		}, filter.Id, filter.Name)
+		if i >= 0 && !matched[i] {
+			matched[i] = true
+			if filterId, ok := currentIds[oldFilters[i].Name]; ok {
+				if oldFilters[i].Name == filter.Name && queryJSONEqual(string(oldFilters[i].Query), string(filter.Query)) {
+					continue
+				}
+				_, err := apiClient.UpdateFilter(filterId, UpdateFilterRequest{
+					Name:  filter.Name,
+					Query: filter.Query,
+				})
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		_, err := apiClient.CreateFilter(CreateFilterRequest{
+			Name:       filter.Name,
+			AppScopeId: appScopeId,
+			Query:      filter.Query,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	var removed []string
+	for i, filter := range oldFilters {
+		if filterId, ok := currentIds[filter.Name]; ok && !matched[i] {
+			removed = append(removed, filterId)
+		}
+	}
+	return removed, nil
+}
+
+// refreshPolicies refreshes the policy blocks in state from the policies
+// returned by the API, dropping the ones that no longer exist so that they
+// get planned for re-creation and refreshing their layer 4 parameters.
+// Policies whose scope, filter or cluster references no longer resolve,
+// e.g. after a rename outside of terraform, are dropped too, so that the
+// workspace can still be read and destroyed.
+func refreshPolicies(references policyReferences, tfPolicies []interface{}, current []Policies) ([]interface{}, error) {
+	pool := newPolicyPool(current)
+	refreshed := []interface{}{}
+	for _, tfPolicy := range tfPolicies {
+		if tfPolicy == nil {
+			continue
+		}
+		tf := tfPolicy.(terraformObject)
+		policy, err := resolvedPolicyFromTerraform(references, tf)
+		var unresolved unresolvedReferenceError
+		if errors.As(err, &unresolved) {
+			log.Printf("[WARN] Policy reference no longer resolves, removing the policy from state: %s", err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		existing, ok := pool.claim(policyKey(policy.ConsumerFilterId, policy.ProviderFilterId, policy.Action))
+		if !ok {
+			log.Printf("[WARN] Policy from %s to %s no longer exists, removing it from state", policy.ConsumerFilterId, policy.ProviderFilterId)
+			continue
+		}
+		tf["layer_4_network_policy"] = refreshLayer4NetworkPolicies(tf["layer_4_network_policy"].([]interface{}), existing.L4Params, references.client.services())
+		refreshed = append(refreshed, tf)
+	}
+	return refreshed, nil
+}
+
+// refreshLayer4NetworkPolicies returns the layer 4 parameters of a policy
+// keeping the order and protocol spelling of the ones already in state.
+func refreshLayer4NetworkPolicies(tfLayer4NetworkPolicies []interface{}, current []PolicyL4Param, services serviceCatalog) []interface{} {
+	remaining := newLayer4Pool(current)
+	refreshed := []interface{}{}
+	for _, tfLayer4NetworkPolicy := range tfLayer4NetworkPolicies {
+		if tfLayer4NetworkPolicy == nil {
+			continue
+		}
+		tf := tfLayer4NetworkPolicy.(terraformObject)
+		layer4NetworkPolicies, err := layer4NetworkPoliciesFromTerraform(tf, services)
+		if err != nil || !remaining.claim(layer4NetworkPolicies) {
+			continue
+		}
+		refreshed = append(refreshed, tf)
+	}
+	for _, l4Param := range remaining.unclaimed(current) {
+		refreshed = append(refreshed, layer4NetworkPolicyToTerraform(configuredProtocol(tfLayer4NetworkPolicies, l4Param.Proto), l4Param.Proto, l4Param.Port, l4Param.Approved))
+	}
+	return refreshed
+}
+
+// refreshClustersFromDetails refreshes the cluster blocks in state from the
+// clusters of the workspace, keeping their workspace local ids and dropping
+// the ones that no longer exist.
+func refreshClustersFromDetails(tfClusters []interface{}, clusters []Cluster) []interface{} {
+	refreshed := []interface{}{}
+	for _, tfCluster := range tfClusters {
+		if tfCluster == nil {
+			continue
+		}
+		tf := tfCluster.(terraformObject)
+		found := false
+		for _, cluster := range clusters {
+			if cluster.Name != tf["name"].(string) {
+				continue
+			}
+			found = true
+			tf["description"] = cluster.Description
+			// Clusters discovered by a policy run are defined by a query
+			// rather than nodes, so only refresh nodes the API reports
+			if len(cluster.Nodes) > 0 {
+				tf["node"] = clusterToTerraform(cluster)["node"]
+			}
+			break
+		}
+		if !found {
+			log.Printf("[WARN] Cluster %s no longer exists in the workspace, removing it from state", tf["name"])
+			continue
+		}
+		refreshed = append(refreshed, tf)
+	}
+	return refreshed
+}
+
+// refreshFiltersFromDetails refreshes the filter blocks in state from the
+// inventory filters of the workspace, keeping their workspace local ids and
+// dropping the ones that no longer exist.
+func refreshFiltersFromDetails(tfFilters []interface{}, filters []PolicyFilter) []interface{} {
+	refreshed := []interface{}{}
+	for _, tfFilter := range tfFilters {
+		if tfFilter == nil {
+			continue
+		}
+		tf := tfFilter.(terraformObject)
+		found := false
+		for _, filter := range filters {
+			if filter.Name != tf["name"].(string) {
+				continue
+			}
+			found = true
+			if len(filter.Query) > 0 && !queryJSONEqual(tf["query"].(string), string(filter.Query)) {
+				tf["query"] = normalizeQueryJSONState(string(filter.Query))
+			}
+			break
+		}
+		if !found {
+			log.Printf("[WARN] Filter %s no longer exists in the workspace, removing it from state", tf["name"])
+			continue
+		}
+		refreshed = append(refreshed, tf)
+	}
+	return refreshed
+}
+
+func resourceSecureWorkloadApplicationDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	return diag.FromErr(client.DeleteApplication(d.Id()))
 }
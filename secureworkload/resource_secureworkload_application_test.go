@@ -0,0 +1,282 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestReconcilePoliciesOnlyChangesWhatDiffers(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"id": "new"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	kept := Policy{ConsumerFilterId: "c1", ProviderFilterId: "p1", Action: "ALLOW", Layer4NetworkPolicies: []Layer4NetworkPolicy{
+		{Protocol: 6, PortRange: [2]int{443, 443}},
+		{Protocol: 6, PortRange: [2]int{8080, 8080}},
+	}}
+	removed := Policy{ConsumerFilterId: "c2", ProviderFilterId: "p2", Action: "DENY"}
+	added := Policy{ConsumerFilterId: "c3", ProviderFilterId: "p3", Action: "ALLOW"}
+	current := []Policies{
+		{Id: "kept", ConsumerId: "c1", ProviderId: "p1", Action: "allow", L4Params: []PolicyL4Param{
+			{Id: "https", Proto: 6, Port: [2]int{443, 443}},
+			{Id: "ssh", Proto: 6, Port: [2]int{22, 22}},
+		}},
+		{Id: "removed", ConsumerId: "c2", ProviderId: "p2", Action: "DENY"},
+		{Id: "unmanaged", ConsumerId: "c4", ProviderId: "p4", Action: "ALLOW"},
+	}
+	err := reconcilePolicies(client, "workspace", "p1", "ABSOLUTE", []Policy{kept, removed}, []Policy{kept, added}, current)
+	if err != nil {
+		t.Fatalf("Error %s reconciling policies", err)
+	}
+	expected := []string{
+		"POST " + PortsAPIV1BasePath + "kept/l4_params",
+		"DELETE " + PortsAPIV1BasePath + "kept/l4_params/ssh",
+		"POST " + PolicyAPIV1BasePath + "workspace/policies",
+		"DELETE " + SecureWorkloadAPIV1BasePath + "/policies/removed",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests %v, got %v", expected, requests)
+	}
+}
+
+func TestReconcileClustersAndFiltersUpdatesInPlace(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "new", "query": {"type": "eq", "field": "ip", "value": "10.0.0.3"}}`))
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = nil
+	web := Cluster{Id: "web", Name: "web", Nodes: []Node{{IPAddress: "10.0.0.1"}}}
+	db := Cluster{Id: "db", Name: "db", Nodes: []Node{{IPAddress: "10.0.1.0/24"}}}
+	changedWeb := web
+	changedWeb.Nodes = []Node{{IPAddress: "10.0.0.2"}}
+	cache := Cluster{Id: "cache", Name: "cache"}
+	current := []Cluster{{Id: "api-web", Name: "web"}, {Id: "api-db", Name: "db"}}
+	removed, err := reconcileClusters(client, "workspace", "", []Cluster{web, db}, []Cluster{changedWeb, cache}, current)
+	if err != nil {
+		t.Fatalf("Error %s reconciling clusters", err)
+	}
+	if !reflect.DeepEqual(removed, []string{"api-db"}) {
+		t.Errorf("Expected cluster api-db to be removed, got %v", removed)
+	}
+	ldap := PolicyFilter{Id: "ldap", Name: "ldap", Query: []byte(`{"type":"eq","field":"ip","value":"10.0.2.1"}`)}
+	renamedLdap := ldap
+	renamedLdap.Name = "directory"
+	dns := PolicyFilter{Id: "dns", Name: "dns", Query: []byte(`{"type":"eq","field":"ip","value":"10.0.2.2"}`)}
+	removed, err = reconcileFilters(client, "scope", []PolicyFilter{ldap, dns}, []PolicyFilter{renamedLdap, dns}, []PolicyFilter{{Id: "api-ldap", Name: "ldap"}, {Id: "api-dns", Name: "dns"}})
+	if err != nil {
+		t.Fatalf("Error %s reconciling filters", err)
+	}
+	if len(removed) != 0 {
+		t.Errorf("Expected no filter to be removed, got %v", removed)
+	}
+	expected := []string{
+		"PUT " + SecureWorkloadAPIV1BasePath + "/clusters/api-web",
+		"POST " + ClustersAPIV1BasePath + "workspace/clusters",
+		"PUT " + FiltersAPIV1BasePath + "/api-ldap",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests %v, got %v", expected, requests)
+	}
+}
+
+func TestClusterQueryMatchesNodes(t *testing.T) {
+	query, err := clusterQuery([]Node{{IPAddress: "10.0.0.1"}, {IPAddress: "10.0.1.0/24"}})
+	if err != nil {
+		t.Fatalf("Error %s building the query of a cluster", err)
+	}
+	expected := `{"type":"or","filters":[{"type":"eq","field":"ip","value":"10.0.0.1"},{"type":"subnet","field":"ip","value":"10.0.1.0/24"}]}`
+	if string(query) != expected {
+		t.Errorf("Expected query %s, got %s", expected, query)
+	}
+}
+
+func TestRefreshLayer4NetworkPoliciesKeepsStateOrder(t *testing.T) {
+	inState := []interface{}{
+		layer4NetworkPolicyToTerraform("6", 6, [2]int{8080, 8080}, false),
+		layer4NetworkPolicyToTerraform("", 17, [2]int{53, 53}, false),
+		terraformObject{"protocol": "6", "port_range": []interface{}{443, 443}, "ports": "", "service": "", "approved": false},
+		terraformObject{"protocol": "tcp", "port_range": []interface{}{}, "ports": "9000-9001,9100", "service": "", "approved": false},
+		terraformObject{"protocol": "", "port_range": []interface{}{}, "ports": "", "service": "dns", "approved": false},
+	}
+	current := []PolicyL4Param{
+		{Proto: 6, Port: [2]int{443, 443}},
+		{Proto: 6, Port: [2]int{22, 22}},
+		{Proto: 6, Port: [2]int{8080, 8080}},
+		{Proto: 6, Port: [2]int{9100, 9100}},
+		{Proto: 6, Port: [2]int{9000, 9001}},
+		{Proto: 6, Port: [2]int{53, 53}},
+	}
+	refreshed := refreshLayer4NetworkPolicies(inState, current, Client{}.services())
+	expected := []interface{}{
+		inState[0],
+		inState[2],
+		inState[3],
+		terraformObject{"protocol": "6", "port_range": []interface{}{22, 22}, "ports": "", "service": "", "approved": false},
+		terraformObject{"protocol": "6", "port_range": []interface{}{53, 53}, "ports": "", "service": "", "approved": false},
+	}
+	if !reflect.DeepEqual(refreshed, expected) {
+		t.Errorf("Expected %v, got %v", expected, refreshed)
+	}
+}
+
+func TestProtocolToTerraformKeepsConfiguredSpelling(t *testing.T) {
+	for _, test := range []struct {
+		configured string
+		protocol   int
+		expected   string
+	}{
+		{configured: "TCP", protocol: 6, expected: "TCP"},
+		{configured: "6", protocol: 6, expected: "6"},
+		{configured: "", protocol: 0, expected: ""},
+		{configured: "tcp", protocol: 17, expected: "udp"},
+		{configured: "", protocol: 132, expected: "132"},
+	} {
+		if protocol := protocolToTerraform(test.configured, test.protocol); protocol != test.expected {
+			t.Errorf("Expected protocol %d configured as %q to be %q, got %q", test.protocol, test.configured, test.expected, protocol)
+		}
+	}
+}
+
+func newPolicyReferencesTestServer(t *testing.T) (*httptest.Server, policyReferences) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case FiltersAPIV1BasePath:
+			w.Write([]byte(`[
+				{"id": "f1", "name": "web", "app_scope_id": "s1"},
+				{"id": "f2", "name": "web", "app_scope_id": "s2"},
+				{"id": "f3", "name": "database", "app_scope_id": "s1"}
+			]`))
+		case ScopesAPIV1BasePath:
+			w.Write([]byte(`[
+				{"id": "s1", "name": "Root:App", "short_name": "App"},
+				{"id": "s2", "name": "Root:Other:App", "short_name": "App"}
+			]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = newLookupCache()
+	return server, policyReferences{
+		client:     client,
+		clusterIds: map[string]string{"frontend": "c1"},
+	}
+}
+
+func TestPolicyFilterIdForQueryResolvesReferences(t *testing.T) {
+	server, references := newPolicyReferencesTestServer(t)
+	defer server.Close()
+	queries := map[string]policyFilterQuery{
+		"f3": {Side: "consumer", FilterName: "database"},
+		"f2": {Side: "consumer", FilterName: "web", FilterScopeName: "Root:Other:App"},
+		"s1": {Side: "provider", ScopeName: "Root:App"},
+		"c1": {Side: "provider", ClusterName: "frontend"},
+		"id": {Side: "provider", AbsoluteId: "id"},
+	}
+	for expected, query := range queries {
+		filterId, err := policyFilterIdForQuery(references, query)
+		if err != nil || filterId != expected {
+			t.Errorf("Expected %+v to resolve to %s, got %q, %v", query, expected, filterId, err)
+		}
+	}
+}
+
+func TestPolicyFilterIdForQueryListsCandidates(t *testing.T) {
+	server, references := newPolicyReferencesTestServer(t)
+	defer server.Close()
+	cases := []struct {
+		query    policyFilterQuery
+		expected string
+	}{
+		// Used to panic when no filter matched
+		{
+			policyFilterQuery{Side: "consumer", FilterName: "databse"},
+			`consumer_filter_name: no inventory filter named "databse" exists`,
+		},
+		{
+			policyFilterQuery{Side: "consumer", FilterName: "we"},
+			`consumer_filter_name: no inventory filter named "we" exists, similar filters are "web", "web"`,
+		},
+		{
+			policyFilterQuery{Side: "consumer", FilterName: "web"},
+			"consumer_filter_name: 2 inventory filters are named \"web\", set consumer_filter_scope_name or consumer_filter_id to choose one of:\n" +
+				"  - f1 owned by scope \"Root:App\"\n" +
+				"  - f2 owned by scope \"Root:Other:App\"",
+		},
+		{
+			policyFilterQuery{Side: "provider", ScopeName: "App"},
+			`provider_scope_name: no scope named "App" exists, similar scopes are "Root:App", "Root:Other:App"`,
+		},
+		{
+			policyFilterQuery{Side: "provider", ClusterName: "backend"},
+			`provider_cluster_name: no cluster named "backend" exists in the workspace, it has the clusters "frontend"`,
+		},
+		{
+			policyFilterQuery{Side: "provider", AbsoluteId: "id", FilterName: "web"},
+			"only one of provider_filter_id, provider_scope_name, provider_filter_name or provider_cluster_name can be specified, got provider_filter_id and provider_filter_name",
+		},
+		{
+			policyFilterQuery{Side: "consumer", ScopeName: "Root:App", FilterScopeName: "Root:App"},
+			"consumer_filter_scope_name can only be specified along with consumer_filter_name",
+		},
+	}
+	for _, c := range cases {
+		_, err := policyFilterIdForQuery(references, c.query)
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("Expected %+v to fail with\n%s\ngot\n%v", c.query, c.expected, err)
+		}
+	}
+}
+
+func TestRefreshPoliciesDropsUnresolvedReferences(t *testing.T) {
+	server, references := newPolicyReferencesTestServer(t)
+	defer server.Close()
+	resolved := terraformObject{
+		"consumer_filter_name":   "database",
+		"provider_scope_name":    "Root:App",
+		"action":                 "ALLOW",
+		"layer_4_network_policy": []interface{}{},
+	}
+	renamed := terraformObject{
+		"consumer_filter_name":   "databse",
+		"provider_scope_name":    "Root:App",
+		"action":                 "ALLOW",
+		"layer_4_network_policy": []interface{}{},
+	}
+	current := []Policies{
+		{Id: "p1", ConsumerId: "f3", ProviderId: "s1", Action: "ALLOW"},
+	}
+	refreshed, err := refreshPolicies(references, []interface{}{renamed, resolved}, current)
+	if err != nil {
+		t.Fatalf("Expected policies with unresolved references to be dropped, got %s", err)
+	}
+	if !reflect.DeepEqual(refreshed, []interface{}{resolved}) {
+		t.Errorf("Expected only the resolved policy to be kept, got %v", refreshed)
+	}
+	// Errors other than unresolved references still fail the refresh
+	ambiguous := terraformObject{
+		"consumer_filter_id":   "f1",
+		"consumer_filter_name": "web",
+		"action":               "ALLOW",
+	}
+	if _, err := refreshPolicies(references, []interface{}{ambiguous}, current); err == nil {
+		t.Errorf("Expected conflicting references to fail the refresh")
+	}
+}
@@ -1,8 +1,9 @@
 package secureworkload
 
 import (
-	"fmt"
+	"context"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	// client "github.com/secureworkload-exchange/terraform-go-sdk"
 )
@@ -42,11 +43,19 @@ func resourceSecureWorkloadCluster() *schema.Resource {
 			"    approved = false \n" +
 			"}\n" +
 			"```\n" +
-			"**Note:** If creating multiple clusters during a single `terraform apply`, remember to use `depends_on` to chain the filters so that terraform creates them in a specific order to avoid *429:too_many_request* error.\n",
-		Create: resourceSecureWorkloadClusterCreate,
-		Update: nil,
-		Read:   resourceSecureWorkloadClusterRead,
-		Delete: resourceSecureWorkloadClusterDelete,
+			"**Note:** If creating multiple clusters during a single `terraform apply`, remember to use `depends_on` to chain the filters so that terraform creates them in a specific order to avoid *429:too_many_request* error.\n" +
+			"\n" +
+			"## Import\n" +
+			"Clusters can be imported using the workspace ID and the cluster ID separated by a slash, `<workspace_id>/<cluster_id>`:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_cluster.cluster 5f3d4c2e497d4f7c3f8e9a11/5f3d61aa497d4f1e8b2c3d4e\n" +
+			"```\n",
+		CreateContext: resourceSecureWorkloadClusterCreate,
+		ReadContext:   resourceSecureWorkloadClusterRead,
+		DeleteContext: resourceSecureWorkloadClusterDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateWithParent("workspace_id"),
+		},
 
 		SchemaVersion: 1,
 
@@ -70,10 +79,13 @@ func resourceSecureWorkloadCluster() *schema.Resource {
 				Description: "(optional) The description of the cluster.",
 			},
 			"query": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				ForceNew:    true,
-				Description: "JSON object representation of an inventory filter query. *type* is operator, *field* is label key & *value* is label value. Operator can any of the following: [and, or, eq, subnet, contains, regex, gt, gte, lt, lte, in, range, ranges, not, all, none]",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				ValidateFunc:     validateJSON,
+				StateFunc:        normalizeQueryJSONState,
+				DiffSuppressFunc: suppressEquivalentQueryJSON,
+				Description:      "JSON object representation of an inventory filter query. *type* is operator, *field* is label key & *value* is label value. Operator can any of the following: [and, or, eq, subnet, contains, regex, gt, gte, lt, lte, in, range, ranges, not, all, none]",
 			},
 			"workspace_id": {
 				Type:        schema.TypeString,
@@ -94,11 +106,11 @@ func resourceSecureWorkloadCluster() *schema.Resource {
 
 var requiredCreateClusterParams = []string{"name", "workspace_id", "query"}
 
-func resourceSecureWorkloadClusterCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadClusterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	for _, param := range requiredCreateClusterParams {
 		if d.Get(param) == "" {
-			return fmt.Errorf("%s is required but was not provided", param)
+			return diag.Errorf("%s is required but was not provided", param)
 		}
 	}
 	createClusterParams := CreateClusterRequest{
@@ -110,26 +122,31 @@ func resourceSecureWorkloadClusterCreate(d *schema.ResourceData, meta interface{
 	}
 	cluster, err := client.CreateCluster(createClusterParams, d.Get("workspace_id").(string))
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.SetId(cluster.Id)
 	return nil
 }
 
-func resourceSecureWorkloadClusterRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	cluster, err := client.DescribeCluster(d.Id())
 	if err != nil {
-		return err
+		return diag.FromErr(removeIfNotFound(d, err))
 	}
 	d.Set("name", cluster.Name)
 	d.Set("version", cluster.Version)
 	d.Set("description", cluster.Description)
 	d.Set("approved", cluster.Approved)
+	if cluster.Query != nil {
+		if err := setJSON(d, "query", cluster.Query); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	return nil
 }
 
-func resourceSecureWorkloadClusterDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	return client.DeleteCluster(d.Get("workspace_id").(string), d.Id())
+func resourceSecureWorkloadClusterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	return diag.FromErr(client.DeleteCluster(d.Get("workspace_id").(string), d.Id()))
 }
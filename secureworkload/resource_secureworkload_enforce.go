@@ -1,8 +1,12 @@
 package secureworkload
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	// client "github.com/secureworkload-exchange/terraform-go-sdk"
 )
@@ -17,13 +21,33 @@ func resourceSecureWorkloadEnforce() *schema.Resource {
 			"resource \"secureworkload_enforce\" \"enforced\" {\n" +
 			"	 workspace_id = secureworkload_workspace.workspace.id\n" +
 			"    version = \"p10\" \n" +
+			"    timeouts {\n" +
+			"        create = \"20m\"\n" +
+			"    }\n" +
 			"}\n" +
 			"```\n" +
-			"**Note:** If creating multiple rules during a single `terraform apply`, remember to use `depends_on` to chain the rules so that terraform creates it in the same order that you intended.\n",
-		Create: resourceSecureWorkloadEnforceCreate,
-		Update: nil,
-		Read:   resourceSecureWorkloadEnforceRead,
-		Delete: resourceSecureWorkloadEnforceDelete,
+			"**Note:** If creating multiple rules during a single `terraform apply`, remember to use `depends_on` to chain the rules so that terraform creates it in the same order that you intended.\n" +
+			"Applying waits until the workspace reports the version as enforced, use `timeouts` to wait longer for large workspaces. " +
+			"Changing `version` enforces the new version without disabling enforcement.\n" +
+			"\n" +
+			"## Import\n" +
+			"Enforcement can be imported using the ID of the enforced workspace:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_enforce.enforced 5f3d4c2e497d4f7c3f8e9a11\n" +
+			"```\n",
+		CreateContext: resourceSecureWorkloadEnforceCreate,
+		UpdateContext: resourceSecureWorkloadEnforceUpdate,
+		ReadContext:   resourceSecureWorkloadEnforceRead,
+		DeleteContext: resourceSecureWorkloadEnforceDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceSecureWorkloadEnforceImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		SchemaVersion: 1,
 
@@ -32,58 +56,149 @@ func resourceSecureWorkloadEnforce() *schema.Resource {
 				Type:        schema.TypeString,
 				Required:    true,
 				ForceNew:    true,
-				Description: "ID of the needed policy.",
+				Description: "ID of the workspace to enforce.",
 			},
 			"version": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				ForceNew:    true,
-				Description: "Indicates the version of the workspace the cluster will be added to.",
+				Computed:    true,
+				Description: "The p* version of the workspace to enforce, for example p10. Defaults to the latest version. Changing it enforces the new version in place.",
+			},
+			"enforcement_enabled": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Indicates if enforcement is enabled on the workspace.",
+			},
+			"enforced_version": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The enforced p* version of the workspace.",
 			},
 		},
 	}
 }
 
+const (
+	// Longest wait between two checks of the enforced version of a workspace.
+	enforcePollInterval = 15 * time.Second
+)
+
 var requiredCreateEnforceParams = []string{"workspace_id"}
 
-func resourceSecureWorkloadEnforceCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadEnforceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	for _, param := range requiredCreateEnforceParams {
 		if d.Get(param) == "" {
-			return fmt.Errorf("%s is required but was not provided", param)
+			return diag.Errorf("%s is required but was not provided", param)
+		}
+	}
+	err := enforceWorkspace(ctx, d, meta, d.Timeout(schema.TimeoutCreate))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(d.Get("workspace_id").(string))
+	return resourceSecureWorkloadEnforceRead(ctx, d, meta)
+}
+
+func resourceSecureWorkloadEnforceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.HasChange("version") {
+		err := enforceWorkspace(ctx, d, meta, d.Timeout(schema.TimeoutUpdate))
+		if err != nil {
+			return diag.FromErr(err)
 		}
 	}
+	return resourceSecureWorkloadEnforceRead(ctx, d, meta)
+}
+
+// enforceWorkspace enforces the configured version of a workspace and waits
+// until the workspace reports that version as enforced on its agents, or
+// ctx is done once the timeout of the operation elapses.
+func enforceWorkspace(ctx context.Context, d *schema.ResourceData, meta interface{}, timeout time.Duration) error {
+	client := meta.(Client).WithContext(ctx)
+	workspaceId := d.Get("workspace_id").(string)
+	version := d.Get("version").(string)
 	createEnforceParams := CreateEnforceRequest{
-		Version: d.Get("version").(string),
+		Version: version,
 	}
-	port, err := client.CreateEnforce(createEnforceParams, d.Get("workspace_id").(string))
+	_, err := client.CreateEnforce(createEnforceParams, workspaceId)
 	if err != nil {
 		return err
 	}
-	d.SetId(port.Epoch)
+	var application Application
+	var describeErr error
+	enforced := AwaitContext(ctx, func() bool {
+		application, describeErr = client.DescribeApplication(DescribeApplicationRequest{
+			ApplicationId: workspaceId,
+		})
+		return describeErr != nil || application.EnforcementEnabled &&
+			(version == "" || fmt.Sprintf("p%d", application.EnforcedVersion) == version)
+	}, enforcePollInterval)
+	if describeErr != nil && ctx.Err() == nil {
+		return describeErr
+	}
+	if !enforced || describeErr != nil {
+		if version == "" {
+			return fmt.Errorf("timed out after %s waiting for enforcement to be enabled on workspace %s", timeout, workspaceId)
+		}
+		return fmt.Errorf("timed out after %s waiting for workspace %s to enforce version %s, it enforces p%d", timeout, workspaceId, version, application.EnforcedVersion)
+	}
 	return nil
 }
 
-func resourceSecureWorkloadEnforceRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadEnforceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	describeApplicatioParams := DescribeApplicationRequest{
 		ApplicationId: d.Get("workspace_id").(string),
 	}
 	application, err := client.DescribeApplication(describeApplicatioParams)
 	if err != nil {
-		return err
+		return diag.FromErr(removeIfNotFound(d, err))
+	}
+	// Enforcement disabled out of band means this resource is gone
+	if !application.EnforcementEnabled {
+		log.Printf("[WARN] Enforcement is no longer enabled on workspace %s, removing it from state", application.Id)
+		d.SetId("")
+		return nil
 	}
-	d.Set("name", application.Name)
-	d.Set("description", application.Description)
-	d.Set("primary", application.Primary)
-	d.Set("alternate_query_mode", application.AlternateQueryMode)
-	d.Set("latest_adm_version", application.LatestADMVersion)
+	d.Set("version", fmt.Sprintf("p%d", application.EnforcedVersion))
 	d.Set("enforcement_enabled", application.EnforcementEnabled)
 	d.Set("enforced_version", application.EnforcedVersion)
 	return nil
 }
 
-func resourceSecureWorkloadEnforceDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	return client.DeleteEnforce(d.Get("workspace_id").(string))
+func resourceSecureWorkloadEnforceImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	d.Set("workspace_id", d.Id())
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceSecureWorkloadEnforceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	workspaceId := d.Get("workspace_id").(string)
+	err := client.DeleteEnforce(workspaceId)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	// Wait for enforcement to be disabled, as the
+	// workspace can't be deleted while it is enforced
+	var describeErr error
+	disabled := AwaitContext(ctx, func() bool {
+		var application Application
+		application, describeErr = client.DescribeApplication(DescribeApplicationRequest{
+			ApplicationId: workspaceId,
+		})
+		if IsNotFound(describeErr) {
+			describeErr = nil
+			return true
+		}
+		return describeErr != nil || !application.EnforcementEnabled
+	}, enforcePollInterval)
+	if describeErr != nil && ctx.Err() == nil {
+		return diag.FromErr(describeErr)
+	}
+	if !disabled || describeErr != nil {
+		return diag.Errorf("timed out after %s waiting for enforcement to be disabled on workspace %s", d.Timeout(schema.TimeoutDelete), workspaceId)
+	}
+	return nil
 }
@@ -1,14 +1,58 @@
 package secureworkload
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
+	"log"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	// client "github.com/secureworkload-exchange/terraform-go-sdk"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
-func resourceSecureWorkloadFilter() *schema.Resource {
-	return &schema.Resource{
+var (
+	_ resource.ResourceWithConfigure      = &filterResource{}
+	_ resource.ResourceWithImportState    = &filterResource{}
+	_ resource.ResourceWithModifyPlan     = &filterResource{}
+	_ resource.ResourceWithUpgradeState   = &filterResource{}
+	_ resource.ResourceWithValidateConfig = &filterResource{}
+)
+
+// filterResource manages inventory filters. It was ported from
+// terraform-plugin-sdk/v2 and reads the state written there.
+type filterResource struct {
+	client     Client
+	configured bool
+}
+
+// filterResourceModel maps the secureworkload_filter schema.
+type filterResourceModel struct {
+	Id         types.String   `tfsdk:"id"`
+	Name       types.String   `tfsdk:"name"`
+	Query      types.List     `tfsdk:"query"`
+	QueryJSON  queryJSONValue `tfsdk:"query_json"`
+	AppScopeId types.String   `tfsdk:"app_scope_id"`
+	Primary    types.Bool     `tfsdk:"primary"`
+	Public     types.Bool     `tfsdk:"public"`
+}
+
+// NewFilterResource returns the secureworkload_filter resource.
+func NewFilterResource() resource.Resource {
+	return &filterResource{}
+}
+
+func (r *filterResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_filter"
+}
+
+func (r *filterResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
 		Description: "Resource for creating a new filter in Secure Workload\n" +
 			"\n" +
 			"## Example\n" +
@@ -17,13 +61,19 @@ func resourceSecureWorkloadFilter() *schema.Resource {
 			"resource \"secureworkload_filter\" \"filter1\" {\n" +
 			"	 app_scope_id = data.secureworkload_scope.scope.id\n" +
 			"    name = \"New-Filter\"\n" +
-			"    query = <<EOF\n" +
-			"                {" +
-			"        		 \"type\":\"eq\",\n" +
-			"        		 \"field\": \"ip\",\n" +
-			"        		 \"value\": \"10.0.0.1\"\n" +
-			"        		 }\n" +
-			"        	EOF\n" +
+			"    query {\n" +
+			"        type = \"or\"\n" +
+			"        filter {\n" +
+			"            type  = \"eq\"\n" +
+			"            field = \"ip\"\n" +
+			"            value = \"10.0.0.1\"\n" +
+			"        }\n" +
+			"        filter {\n" +
+			"            type       = \"in\"\n" +
+			"            annotation = \"environment\"\n" +
+			"            values     = [\"production\", \"staging\"]\n" +
+			"        }\n" +
+			"    }\n" +
 			"    primary = true \n" +
 			"    public = false \n" +
 			"}\n" +
@@ -31,7 +81,7 @@ func resourceSecureWorkloadFilter() *schema.Resource {
 			"	 depends_on = [secureworkload_filter.filter1]\n" +
 			"	 app_scope_id = data.secureworkload_scope.scope.id\n" +
 			"    name = \"New-Filter2\"\n" +
-			"    query = <<EOF\n" +
+			"    query_json = <<EOF\n" +
 			"                {" +
 			"        		 \"type\":\"subnet\",\n" +
 			"        		 \"field\": \"ip\",\n" +
@@ -42,91 +92,271 @@ func resourceSecureWorkloadFilter() *schema.Resource {
 			"    public = false \n" +
 			"}\n" +
 			"```\n" +
-			"**Note:** If creating multiple filters during a single `terraform apply`, remember to use `depends_on` to chain the filters so that terraform creates them in a specific order to avoid *429:too_many_request* error.\n",
-		Create: resourceSecureWorkloadFilterCreate,
-		Update: nil,
-		Read:   resourceSecureWorkloadFilterRead,
-		Delete: resourceSecureWorkloadFilterDelete,
-
-		SchemaVersion: 1,
-
-		Schema: map[string]*schema.Schema{
-			"name": {
-				Type:        schema.TypeString,
+			"**Note:** If creating multiple filters during a single `terraform apply`, remember to use `depends_on` to chain the filters so that terraform creates them in a specific order to avoid *429:too_many_request* error.\n" +
+			"\n" +
+			"The `query` block is checked when planning, use `query_json` for queries nested more than 4 levels deep. " +
+			"Filters created with a version of the provider where `query` was a JSON string are upgraded to `query_json`, rename the argument in their configuration.\n" +
+			"\n" +
+			"When `membership_warning_threshold` is set in the provider configuration, plans changing the query of a filter search the inventory " +
+			"with the current and the new query, within its scope when it is primary and its root scope otherwise, and warn about the inventory items that would join or leave the filter.\n" +
+			"\n" +
+			"## Import\n" +
+			"Filters can be imported using their ID:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_filter.filter1 5f3d3e7a497d4f3ad4e4b1a2\n" +
+			"```\n",
+		Version: 2,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The ID of this resource.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
 				Required:    true,
-				Optional:    false,
-				ForceNew:    true,
 				Description: "User-specified name for the inventory filter.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
-			"query": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
+			"query_json": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				CustomType:  queryJSONType{},
+				Validators:  []validator.String{jsonValidator{}},
 				Description: "JSON object representation of an inventory filter query. *type* is operator, *field* is label key & *value* is label value. Operator can any of the following: [and, or, eq, subnet, contains, regex, gt, gte, lt, lte, in, range, ranges, not, all, none]",
 			},
-			"app_scope_id": {
-				Type:        schema.TypeString,
-				Optional:    false,
+			"app_scope_id": schema.StringAttribute{
 				Required:    true,
-				ForceNew:    true,
 				Description: "ID of the scope associated with the filter.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
-			"primary": {
-				Type:        schema.TypeBool,
+			"primary": schema.BoolAttribute{
 				Optional:    true,
-				ForceNew:    true,
-				Default:     false,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
 				Description: "(Optional) When true, the filter is restricted to the ownership scope.",
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
 			},
-			"public": {
-				Type:        schema.TypeBool,
+			"public": schema.BoolAttribute{
 				Optional:    true,
-				ForceNew:    true,
-				Default:     false,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
 				Description: "(Optional) When true the filter provides a service for its scope. Must also be primary/scope restricted.",
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"query": frameworkQueryBlock("Inventory filter query, built out of nested filter blocks. Exactly one of query or query_json must be specified."),
+		},
 	}
 }
 
-var requiredCreateFilterParams = []string{"name", "app_scope_id", "query"}
+func (r *filterResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client, r.configured = clientFromProviderData(req.ProviderData, &resp.Diagnostics)
+}
 
-func resourceSecureWorkloadFilterCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	for _, param := range requiredCreateFilterParams {
-		if d.Get(param) == "" {
-			return fmt.Errorf("%s is required but was not provided", param)
+func (r *filterResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config filterResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	hasQuery := config.Query.IsUnknown() || len(config.Query.Elements()) > 0
+	if hasQuery == !config.QueryJSON.IsNull() {
+		resp.Diagnostics.AddError("Invalid query", "exactly one of query or query_json must be specified")
+	}
+}
+
+// ModifyPlan plans the query built by the query block as the value of
+// query_json, replaces filters whose query changes and previews the
+// inventory items that join or leave them. Filters restricted to their
+// ownership scope are previewed within it, and the others within its
+// root scope.
+func (r *filterResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan, config filterResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	state := filterResourceModel{QueryJSON: queryJSONNull()}
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	desired, known := config.QueryJSON.ValueString(), !config.QueryJSON.IsUnknown()
+	if config.QueryJSON.IsNull() {
+		queryJSON, ok, err := queryJSONFromFramework(config.Query, "query")
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("query"), "Invalid query", err.Error())
+			return
 		}
+		desired, known = queryJSON, ok
+		switch {
+		case !known:
+			plan.QueryJSON = queryJSONUnknown()
+		default:
+			plan.QueryJSON = queryJSONKeepingPrior(state.QueryJSON, desired)
+		}
+	}
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+	if req.State.Raw.IsNull() || (known && queryJSONEqual(state.QueryJSON.ValueString(), desired)) {
+		return
+	}
+	resp.RequiresReplace = append(resp.RequiresReplace, path.Root("query_json"))
+	if !known || !r.configured {
+		return
+	}
+	client := r.client.WithContext(ctx)
+	scope, err := scopeById(client, plan.AppScopeId.ValueString())
+	if err == nil && !plan.Primary.ValueBool() && scope.RootAppScopeId != "" && scope.RootAppScopeId != scope.Id {
+		scope, err = scopeById(client, scope.RootAppScopeId)
+	}
+	if err != nil {
+		log.Printf("[WARN] Error %s previewing the members of filter %s", err, state.Name.ValueString())
+		return
+	}
+	summary, detail, ok := previewMembershipChange(ctx, r.client, "filter", state.Name.ValueString(), scope.Name, state.QueryJSON.ValueString(), desired)
+	if ok {
+		resp.Diagnostics.AddAttributeWarning(path.Root("query_json"), summary, detail)
+	}
+}
+
+func (r *filterResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan filterResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 	createFilterParams := CreateFilterRequest{
-		Name:       d.Get("name").(string),
-		AppScopeId: d.Get("app_scope_id").(string),
-		Query:      []byte(d.Get("query").(string)),
-		Primary:    d.Get("primary").(bool),
-		Public:     d.Get("public").(bool),
+		Name:       plan.Name.ValueString(),
+		AppScopeId: plan.AppScopeId.ValueString(),
+		Query:      []byte(plan.QueryJSON.ValueString()),
+		Primary:    plan.Primary.ValueBool(),
+		Public:     plan.Public.ValueBool(),
 	}
-	filter, err := client.CreateFilter(createFilterParams)
+	filter, err := r.client.WithContext(ctx).CreateFilter(createFilterParams)
 	if err != nil {
-		return err
+		resp.Diagnostics.AddError("Unable to create filter", err.Error())
+		return
 	}
-	d.SetId(filter.Id)
-	return nil
+	plan.Id = types.StringValue(filter.Id)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
 
-func resourceSecureWorkloadFilterRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	filter, err := client.DescribeFilter(d.Id())
+func (r *filterResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state filterResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	filter, err := r.client.WithContext(ctx).DescribeFilter(state.Id.ValueString())
+	if IsNotFound(err) {
+		log.Printf("[WARN] Resource %s no longer exists, removing it from state", state.Id.ValueString())
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
-		return err
+		resp.Diagnostics.AddError("Unable to read filter", err.Error())
+		return
 	}
-	d.Set("name", filter.Name)
-	d.Set("app_scope_id", filter.AppScopeId)
-	d.Set("primary", filter.Primary)
-	d.Set("public", filter.Public)
-	return nil
+	state.Name = types.StringValue(filter.Name)
+	state.AppScopeId = types.StringValue(filter.AppScopeId)
+	if filter.ShortQuery.Type != "" {
+		encoded, err := json.Marshal(filter.ShortQuery)
+		if err != nil {
+			resp.Diagnostics.AddError("Unable to encode the query of the filter", err.Error())
+			return
+		}
+		state.QueryJSON = queryJSONKeepingPrior(state.QueryJSON, string(encoded))
+		state.Query, err = scopeQueryToFramework(ctx, state.Query, filter.ShortQuery, "query", "query_json")
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("query"), "Unable to refresh the query block", err.Error())
+			return
+		}
+	}
+	state.Primary = types.BoolValue(filter.Primary)
+	state.Public = types.BoolValue(filter.Public)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
 }
 
-func resourceSecureWorkloadFilterDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	return client.DeleteFilter(d.Id())
+// Update only happens when the query block changes but builds
+// the same query, all the other changes replace the filter.
+func (r *filterResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan filterResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *filterResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state filterResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.WithContext(ctx).DeleteFilter(state.Id.ValueString()); err != nil {
+		resp.Diagnostics.AddError("Unable to delete filter", err.Error())
+	}
+}
+
+func (r *filterResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// filterResourceModelV1 maps the schema of filters before
+// query became a block, with the JSON query under query.
+type filterResourceModelV1 struct {
+	Id         types.String `tfsdk:"id"`
+	Name       types.String `tfsdk:"name"`
+	Query      types.String `tfsdk:"query"`
+	AppScopeId types.String `tfsdk:"app_scope_id"`
+	Primary    types.Bool   `tfsdk:"primary"`
+	Public     types.Bool   `tfsdk:"public"`
+}
+
+// UpgradeState moves the JSON query of filters from query to query_json.
+func (r *filterResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		1: {
+			PriorSchema: &schema.Schema{
+				Attributes: map[string]schema.Attribute{
+					"id":           schema.StringAttribute{Computed: true},
+					"name":         schema.StringAttribute{Required: true},
+					"query":        schema.StringAttribute{Required: true},
+					"app_scope_id": schema.StringAttribute{Required: true},
+					"primary":      schema.BoolAttribute{Optional: true},
+					"public":       schema.BoolAttribute{Optional: true},
+				},
+			},
+			StateUpgrader: upgradeFilterStateV1,
+		},
+	}
+}
+
+func upgradeFilterStateV1(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior filterResourceModelV1
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, filterResourceModel{
+		Id:         prior.Id,
+		Name:       prior.Name,
+		Query:      types.ListValueMust(frameworkQueryBlock("").NestedObject.Type(), nil),
+		QueryJSON:  queryJSONValue{StringValue: prior.Query},
+		AppScopeId: prior.AppScopeId,
+		Primary:    types.BoolValue(prior.Primary.ValueBool()),
+		Public:     types.BoolValue(prior.Public.ValueBool()),
+	})...)
 }
@@ -0,0 +1,150 @@
+package secureworkload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	// ImportIdDelimiter separates the components of composite import ids.
+	ImportIdDelimiter = "/"
+)
+
+// removeIfNotFound removes a resource from the state when err reports
+// that it no longer exists, so that terraform plans to re-create it,
+// returning nil in that case and err otherwise.
+func removeIfNotFound(d *schema.ResourceData, err error) error {
+	if IsNotFound(err) {
+		log.Printf("[WARN] Resource %s no longer exists, removing it from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	return err
+}
+
+// setJSON stores the JSON encoding of the query value under key,
+// normalized as by its StateFunc, keeping the value already in state
+// when both hold the same query so that formatting differences and
+// the values normalized by the API are not reported as drift.
+func setJSON(d *schema.ResourceData, key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if current, ok := d.Get(key).(string); ok && queryJSONEqual(current, string(encoded)) {
+		return nil
+	}
+	return d.Set(key, normalizeQueryJSONState(string(encoded)))
+}
+
+// importStateWithParent returns an importer for resources that can only
+// be described together with the id of the object they belong to,
+// accepting ids in the form <parent_id>/<id> and storing the parent
+// id under parentKey.
+func importStateWithParent(parentKey string) schema.StateContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+		parentId, id, err := splitImportId(d.Id(), parentKey)
+		if err != nil {
+			return nil, err
+		}
+		d.Set(parentKey, parentId)
+		d.SetId(id)
+		return []*schema.ResourceData{d}, nil
+	}
+}
+
+// splitImportId splits an import id in the form <parent_id>/<id>.
+func splitImportId(importId string, parentKey string) (string, string, error) {
+	parts := strings.SplitN(importId, ImportIdDelimiter, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected import id %q, expected <%s>%s<id>", importId, parentKey, ImportIdDelimiter)
+	}
+	return parts[0], parts[1], nil
+}
+
+// protocolNumbers maps the protocol names accepted in
+// configurations to their IANA protocol numbers.
+var protocolNumbers = map[string]int{
+	"any":    0,
+	"icmp":   1,
+	"tcp":    6,
+	"udp":    17,
+	"icmpv6": 58,
+}
+
+// protocolNumber returns the protocol number of a protocol given by name
+// or number, where 0 (or "any") stands for all protocols.
+func protocolNumber(protocol string) (int, error) {
+	if protocol == "" {
+		return 0, nil
+	}
+	if number, ok := protocolNumbers[strings.ToLower(protocol)]; ok {
+		return number, nil
+	}
+	number, err := strconv.Atoi(protocol)
+	if err != nil || number < 0 || number > maxProtocolNumber {
+		return 0, fmt.Errorf("unknown protocol %q, expected a protocol number between 0 and %d or one of any, icmp, tcp, udp, icmpv6", protocol, maxProtocolNumber)
+	}
+	return number, nil
+}
+
+// protocolName returns the name of a protocol number, or the number if it has none.
+func protocolName(protocol int) string {
+	for name, number := range protocolNumbers {
+		if number == protocol {
+			return name
+		}
+	}
+	return strconv.Itoa(protocol)
+}
+
+// protocolToTerraform returns the protocol returned by the API as it is
+// configured, keeping the configured spelling, name or number, as long as
+// it stands for the same protocol.
+func protocolToTerraform(configured string, protocol int) string {
+	if number, err := protocolNumber(configured); err == nil && number == protocol {
+		return configured
+	}
+	return protocolName(protocol)
+}
+
+// configuredProtocol returns the spelling of the protocol used by the first
+// of the given blocks in state for the same protocol, if any, so that blocks
+// added outside of terraform are read back like the configured ones.
+func configuredProtocol(tfBlocks []interface{}, protocol int) string {
+	for _, tfBlock := range tfBlocks {
+		if tfBlock == nil {
+			continue
+		}
+		configured, _ := tfBlock.(terraformObject)["protocol"].(string)
+		if number, err := protocolNumber(configured); err == nil && number == protocol {
+			return configured
+		}
+	}
+	return ""
+}
+
+// suppressEquivalentProtocol suppresses the diff between
+// spellings of the same protocol, such as tcp and 6.
+func suppressEquivalentProtocol(k, old, new string, d *schema.ResourceData) bool {
+	oldNumber, err := protocolNumber(old)
+	if err != nil {
+		return false
+	}
+	newNumber, err := protocolNumber(new)
+	return err == nil && oldNumber == newNumber
+}
+
+// validateProtocol is a ValidateFunc for protocols given by name or number.
+func validateProtocol(val interface{}, key string) (warns []string, errs []error) {
+	if _, err := protocolNumber(val.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%q: %s", key, err))
+	}
+	return
+}
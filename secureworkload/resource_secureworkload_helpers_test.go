@@ -0,0 +1,112 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRemoveIfNotFoundClearsIdOnlyForNotFound(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceSecureWorkloadPort().Schema, map[string]interface{}{})
+	d.SetId("1234")
+	otherErr := &APIError{StatusCode: http.StatusInternalServerError}
+	if err := removeIfNotFound(d, otherErr); err != otherErr || d.Id() != "1234" {
+		t.Errorf("Expected other errors to be returned as is, got %v with id %q", err, d.Id())
+	}
+	if err := removeIfNotFound(d, errors.New("connection refused")); err == nil || d.Id() != "1234" {
+		t.Errorf("Expected plain errors to be returned as is, got %v with id %q", err, d.Id())
+	}
+	if err := removeIfNotFound(d, &APIError{StatusCode: http.StatusNotFound}); err != nil || d.Id() != "" {
+		t.Errorf("Expected not found to clear the id, got %v with id %q", err, d.Id())
+	}
+}
+
+func TestSetJSONKeepsSemanticallyEqualState(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceSecureWorkloadCluster().Schema, map[string]interface{}{
+		"query": "{\n  \"field\": \"ip\",\n  \"type\": \"eq\",\n  \"value\": \"10.0.0.1\"\n}",
+	})
+	original := d.Get("query").(string)
+	err := setJSON(d, "query", ScopeQuery{Type: "eq", Field: "ip", Value: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Get("query").(string) != original {
+		t.Errorf("Expected formatting of %q to be kept, got %q", original, d.Get("query"))
+	}
+	err = setJSON(d, "query", ScopeQuery{Type: "eq", Field: "ip", Value: "10.0.0.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queryJSONEqual(d.Get("query").(string), original) {
+		t.Errorf("Expected changed query to be stored, got %q", d.Get("query"))
+	}
+}
+
+func TestImportStateWithParentSplitsCompositeId(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceSecureWorkloadPort().Schema, map[string]interface{}{})
+	d.SetId("policy123/port456")
+	imported, err := importStateWithParent("policy_id")(nil, d, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 1 || d.Id() != "port456" || d.Get("policy_id").(string) != "policy123" {
+		t.Errorf("Unexpected import result id %q policy_id %q", d.Id(), d.Get("policy_id"))
+	}
+	for _, importId := range []string{"port456", "/port456", "policy123/"} {
+		d.SetId(importId)
+		if _, err := importStateWithParent("policy_id")(nil, d, nil); err == nil {
+			t.Errorf("Expected error importing %q", importId)
+		}
+	}
+}
+
+func TestParseTagIdKeepsIPv6Addresses(t *testing.T) {
+	var tagIdTests = []struct {
+		tagId         string
+		rootScopeName string
+		ip            string
+	}{
+		{"acme:1.2.3.4", "acme", "1.2.3.4"},
+		{"acme:10.0.0.0/8", "acme", "10.0.0.0/8"},
+		{"acme:2001:db8::1", "acme", "2001:db8::1"},
+		{"acme|2001:db8::1", "acme", "2001:db8::1"},
+		{"acme|2001:db8::/32", "acme", "2001:db8::/32"},
+	}
+	for _, test := range tagIdTests {
+		rootScopeName, ip, err := parseTagId(test.tagId)
+		if err != nil || rootScopeName != test.rootScopeName || ip != test.ip {
+			t.Errorf("parseTagId(%q) = %q, %q, %v", test.tagId, rootScopeName, ip, err)
+		}
+	}
+	if _, _, err := parseTagId("acme"); err == nil {
+		t.Error("Expected error for id without ip")
+	}
+}
+
+func TestUpdatedTagAttributesClearRemovedKeys(t *testing.T) {
+	old := map[string]interface{}{"Environment": "test", "Owner": "payments"}
+	new := map[string]interface{}{"Environment": "production", "Location": "PAR"}
+	expected := map[string]interface{}{"Environment": "production", "Location": "PAR", "Owner": ""}
+	if attributes := updatedTagAttributes(old, new); !reflect.DeepEqual(attributes, expected) {
+		t.Errorf("Expected %v, got %v", expected, attributes)
+	}
+}
+
+func TestProtocolNumberAcceptsNamesAndNumbers(t *testing.T) {
+	valid := map[string]int{"": 0, "any": 0, "TCP": 6, "udp": 17, "icmp": 1, "icmpv6": 58, "47": 47}
+	for protocol, expected := range valid {
+		if number, err := protocolNumber(protocol); err != nil || number != expected {
+			t.Errorf("Expected protocol %q to be %d, got %d (%v)", protocol, expected, number, err)
+		}
+	}
+	for _, protocol := range []string{"sctp-ish", "256", "-1"} {
+		if _, err := protocolNumber(protocol); err == nil {
+			t.Errorf("Expected protocol %q to be rejected", protocol)
+		}
+	}
+}
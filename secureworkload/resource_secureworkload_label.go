@@ -1,20 +1,65 @@
 package secureworkload
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"strings"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	// client "github.com/secureworkload-exchange/terraform-go-sdk"
 	// secureworkload "github.com/secureworkload-exchange/terraform-go-sdk"
 )
 
 const (
-	TagIdDelimter = ":"
+	// TagIdDelimter separates the root scope name from the ip in label
+	// ids. It can't be part of an IPv4 or IPv6 address or subnet.
+	TagIdDelimter = "|"
+	// LegacyTagIdDelimiter separated the root scope name
+	// from the ip in label ids before TagIdDelimter.
+	LegacyTagIdDelimiter = ":"
 )
 
-func resourceSecureWorkloadLabel() *schema.Resource {
-	return &schema.Resource{
+var (
+	_ resource.ResourceWithConfigure   = &labelResource{}
+	_ resource.ResourceWithImportState = &labelResource{}
+	_ resource.ResourceWithModifyPlan  = &labelResource{}
+)
+
+// labelResource manages the label of an address or subnet. It was
+// ported from terraform-plugin-sdk/v2 and reads the state written there.
+type labelResource struct {
+	client     Client
+	configured bool
+}
+
+// labelResourceModel maps the secureworkload_label schema.
+type labelResourceModel struct {
+	Id            types.String `tfsdk:"id"`
+	RootScopeName types.String `tfsdk:"root_scope_name"`
+	Ip            types.String `tfsdk:"ip"`
+	Attributes    types.Map    `tfsdk:"attributes"`
+}
+
+// NewLabelResource returns the secureworkload_label resource.
+func NewLabelResource() resource.Resource {
+	return &labelResource{}
+}
+
+func (r *labelResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_label"
+}
+
+func (r *labelResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
 		Description: "Resource for creating a new label in Secure Workload\n" +
 			"\n" +
 			"## Example\n" +
@@ -30,96 +75,285 @@ func resourceSecureWorkloadLabel() *schema.Resource {
 			"    }\n" +
 			"}\n" +
 			"```\n" +
-			"**Note:** If creating multiple resources for label during a single `terraform apply`, you may have to use `depends_on` to chain the resources so that terraform creates it in the same order that you intended.\n",
-		Create: resourceSecureWorkloadTagCreate,
-		Update: resourceSecureWorkloadTagCreate,
-		Read:   resourceSecureWorkloadTagRead,
-		Delete: resourceSecureWorkloadTagDelete,
-
-		SchemaVersion: 1,
-
-		Schema: map[string]*schema.Schema{
-			"root_scope_name": {
-				Type:        schema.TypeString,
+			"**Note:** If creating multiple resources for label during a single `terraform apply`, you may have to use `depends_on` to chain the resources so that terraform creates it in the same order that you intended.\n" +
+			"To label many addresses at once, use `secureworkload_labels_bulk`, which uploads all the labels of a root scope in a single request.\n" +
+			"Inventory and flows can only be searched by the keys of `attributes` that are enabled label keys of the root scope, see `secureworkload_label_schema`, plans warn about the other keys.\n" +
+			"\n" +
+			"## Import\n" +
+			"Labels can be imported using the root scope name and the IP address or subnet separated by a pipe, `<root_scope_name>|<ip>`:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_label.label-1 'acme|2001:db8::1'\n" +
+			"```\n",
+		Version: 1,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The ID of this resource.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"root_scope_name": schema.StringAttribute{
 				Optional:    true,
-				Default:     "",
-				ForceNew:    true,
-				Description: "SecureWorkload root app scope name.",
+				Computed:    true,
+				Description: "SecureWorkload root app scope name. Defaults to the root scope readable with the API credentials, when there is only one.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
-			"ip": {
-				Type:        schema.TypeString,
+			"ip": schema.StringAttribute{
 				Required:    true,
-				ForceNew:    true,
+				Validators:  []validator.String{ipOrCIDRValidator{}},
 				Description: "IPv4/IPv6 address or subnet.",
-			},
-			"attributes": {
-				Type:     schema.TypeMap,
-				Optional: true,
-				ForceNew: false,
-				Computed: true,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
 				},
+			},
+			"attributes": schema.MapAttribute{
+				Optional:    true,
+				Computed:    true,
+				ElementType: types.StringType,
 				Description: "Key/value map for tagging matching flows and inventory items.",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.UseStateForUnknown(),
+				},
 			},
 		},
 	}
 }
 
-var requiredCreateTagParams = []string{"ip", "attributes"}
+func (r *labelResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client, r.configured = clientFromProviderData(req.ProviderData, &resp.Diagnostics)
+}
 
-func resourceSecureWorkloadTagCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	for _, param := range requiredCreateTagParams {
-		if d.Get(param) == "" {
-			return fmt.Errorf("%s is required but was not provided", param)
+// parseTagId splits a label id in the form <root_scope_name>|<ip>, or in
+// the legacy form <root_scope_name>:<ip>. Root scope names never contain
+// the delimiter, so everything after the first one is the ip, which
+// keeps IPv6 addresses intact in legacy ids.
+func parseTagId(tagId string) (string, string, error) {
+	delimiter := TagIdDelimter
+	if !strings.Contains(tagId, TagIdDelimter) {
+		delimiter = LegacyTagIdDelimiter
+	}
+	tagIdComponents := strings.SplitN(tagId, delimiter, 2)
+	if len(tagIdComponents) != 2 || tagIdComponents[0] == "" || tagIdComponents[1] == "" {
+		return "", "", fmt.Errorf("unexpected label id %q, expected <root_scope_name>%s<ip>", tagId, TagIdDelimter)
+	}
+	return tagIdComponents[0], tagIdComponents[1], nil
+}
+
+func tagId(rootScopeName string, ip string) string {
+	return fmt.Sprintf("%s%s%s", rootScopeName, TagIdDelimter, ip)
+}
+
+// ModifyPlan resolves the root scope at plan time when it is not
+// configured, and warns about the changed attributes whose keys can't be
+// searched by in the label schema of the root scope.
+func (r *labelResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || !r.configured {
+		return
+	}
+	var plan labelResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	state := labelResourceModel{Attributes: types.MapNull(types.StringType)}
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if plan.RootScopeName.IsUnknown() && req.State.Raw.IsNull() {
+		// Leave it unknown until apply if it can't be resolved yet
+		resolved, err := r.client.WithContext(ctx).LookupRootScopeName()
+		if err != nil {
+			log.Printf("[WARN] Error %s resolving the root scope of label %s", err, plan.Ip.ValueString())
+		} else {
+			plan.RootScopeName = types.StringValue(resolved)
+			resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
 		}
 	}
-	tenantName := d.Get("root_scope_name").(string)
-	if tenantName == "" {
-		tenantURL := client.Config.APIURL
-		// strip protocol and extract the tenant name/subdomain from the url
-		// e.g. https://acme.secureworkloadpreview.com => acme
-		tenantName = strings.Split(strings.Split(tenantURL, "://")[1], ".")[0]
+	if plan.RootScopeName.IsUnknown() || !frameworkValueKnown(plan.Attributes) || plan.Attributes.Equal(state.Attributes) {
+		return
+	}
+	var names []string
+	for name := range plan.Attributes.Elements() {
+		names = append(names, name)
+	}
+	resp.Diagnostics.Append(unsearchableLabelKeysWarnings(ctx, r.client, plan.RootScopeName.ValueString(), names, path.Root("attributes"))...)
+}
+
+func (r *labelResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan labelResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if len(plan.Attributes.Elements()) == 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("attributes"), "Missing attributes", "attributes is required but was not provided")
+		return
+	}
+	client := r.client.WithContext(ctx)
+	rootScopeName := plan.RootScopeName.ValueString()
+	if plan.RootScopeName.IsUnknown() {
+		var err error
+		rootScopeName, err = client.LookupRootScopeName()
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("root_scope_name"), "Unable to resolve the root scope",
+				fmt.Sprintf("root_scope_name is not set and can't be resolved: %s", err))
+			return
+		}
+	}
+	attributes, diags := tagAttributesFromFramework(ctx, plan.Attributes)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
-	attributes := d.Get("attributes").(map[string]interface{})
 	createTagParams := CreateTagRequest{
-		RootScopeName: tenantName,
-		Ip:            d.Get("ip").(string),
+		RootScopeName: rootScopeName,
+		Ip:            plan.Ip.ValueString(),
 		Attributes:    attributes,
 	}
 	tag, err := client.CreateTag(createTagParams)
 	if err != nil {
-		return err
+		resp.Diagnostics.AddError("Unable to create label", err.Error())
+		return
 	}
-	d.SetId(fmt.Sprintf("%s%s%s", createTagParams.RootScopeName, TagIdDelimter, tag.Ip))
-	return nil
+	plan.Id = types.StringValue(tagId(createTagParams.RootScopeName, tag.Ip))
+	plan.RootScopeName = types.StringValue(rootScopeName)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
 
-func resourceSecureWorkloadTagRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	tagIdComponents := strings.Split(d.Id(), TagIdDelimter)
+// Update posts the changed attributes of a label, along
+// with the removed keys set to empty values, which clears them.
+func (r *labelResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state labelResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	rootScopeName, ip, err := parseTagId(state.Id.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid label id", err.Error())
+		return
+	}
+	old, diags := tagAttributesFromFramework(ctx, state.Attributes)
+	resp.Diagnostics.Append(diags...)
+	new, diags := tagAttributesFromFramework(ctx, plan.Attributes)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	_, err = r.client.WithContext(ctx).CreateTag(CreateTagRequest{
+		RootScopeName: rootScopeName,
+		Ip:            ip,
+		Attributes:    updatedTagAttributes(old, new),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to update label", err.Error())
+		return
+	}
+	plan.Id = state.Id
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// updatedTagAttributes returns the attributes to post to turn the old
+// attributes of a label into the new ones, where removed keys are empty.
+func updatedTagAttributes(old map[string]interface{}, new map[string]interface{}) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for key := range old {
+		attributes[key] = ""
+	}
+	for key, value := range new {
+		attributes[key] = value
+	}
+	return attributes
+}
+
+func (r *labelResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state labelResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	rootScopeName, ip, err := parseTagId(state.Id.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid label id", err.Error())
+		return
+	}
 	describeTagRequest := DescribeTagRequest{
-		RootAppScopeName: tagIdComponents[0],
-		Ip:               tagIdComponents[1],
+		RootAppScopeName: rootScopeName,
+		Ip:               ip,
 	}
 	attributes := make(map[string]string)
-	err := client.DescribeTag(describeTagRequest, &attributes)
+	err = r.client.WithContext(ctx).DescribeTag(describeTagRequest, &attributes)
+	if IsNotFound(err) {
+		log.Printf("[WARN] Resource %s no longer exists, removing it from state", state.Id.ValueString())
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
-		return err
+		resp.Diagnostics.AddError("Unable to read label", err.Error())
+		return
+	}
+	// Cleared keys may be described with empty values
+	for key, value := range attributes {
+		if value == "" {
+			delete(attributes, key)
+		}
+	}
+	// Deleting a label removes all its attributes,
+	// so an empty answer means the label is gone
+	if len(attributes) == 0 {
+		log.Printf("[WARN] Label %s no longer exists, removing it from state", state.Id.ValueString())
+		resp.State.RemoveResource(ctx)
+		return
 	}
-	d.Set("root_scope_name", describeTagRequest.RootAppScopeName)
-	d.Set("ip", describeTagRequest.Ip)
-	d.Set("attributes", attributes)
-	return nil
+	// Move ids in the legacy form to the current one
+	state.Id = types.StringValue(tagId(rootScopeName, ip))
+	state.RootScopeName = types.StringValue(describeTagRequest.RootAppScopeName)
+	state.Ip = types.StringValue(describeTagRequest.Ip)
+	var diags diag.Diagnostics
+	state.Attributes, diags = types.MapValueFrom(ctx, types.StringType, attributes)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
 }
 
-func resourceSecureWorkloadTagDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	tagIdComponents := strings.Split(d.Id(), TagIdDelimter)
+func (r *labelResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state labelResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	rootScopeName, ip, err := parseTagId(state.Id.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid label id", err.Error())
+		return
+	}
 	deleteTagRequest := DeleteTagRequest{
-		RootAppScopeName: tagIdComponents[0],
-		Ip:               tagIdComponents[1],
+		RootAppScopeName: rootScopeName,
+		Ip:               ip,
+	}
+	if err := r.client.WithContext(ctx).DeleteTag(deleteTagRequest); err != nil {
+		resp.Diagnostics.AddError("Unable to delete label", err.Error())
+	}
+}
+
+func (r *labelResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// tagAttributesFromFramework returns the attributes of a label as posted to the API.
+func tagAttributesFromFramework(ctx context.Context, tfAttributes types.Map) (map[string]interface{}, diag.Diagnostics) {
+	attributes := map[string]interface{}{}
+	if tfAttributes.IsNull() || tfAttributes.IsUnknown() {
+		return attributes, nil
+	}
+	var values map[string]string
+	diags := tfAttributes.ElementsAs(ctx, &values, false)
+	for key, value := range values {
+		attributes[key] = value
 	}
-	return client.DeleteTag(deleteTagRequest)
+	return attributes, diags
 }
@@ -0,0 +1,551 @@
+package secureworkload
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.ResourceWithConfigure      = &labelSchemaResource{}
+	_ resource.ResourceWithImportState    = &labelSchemaResource{}
+	_ resource.ResourceWithModifyPlan     = &labelSchemaResource{}
+	_ resource.ResourceWithValidateConfig = &labelSchemaResource{}
+)
+
+// labelSchemaResource manages the label keys of a root scope.
+type labelSchemaResource struct {
+	client     Client
+	configured bool
+}
+
+// labelSchemaResourceModel maps the secureworkload_label_schema schema.
+type labelSchemaResourceModel struct {
+	Id                types.String `tfsdk:"id"`
+	RootScopeName     types.String `tfsdk:"root_scope_name"`
+	DeleteAdoptedKeys types.Bool   `tfsdk:"delete_adopted_keys"`
+	Keys              types.Set    `tfsdk:"key"`
+	CreatedKeys       types.Set    `tfsdk:"created_keys"`
+}
+
+// labelKeyModel maps the key blocks of the secureworkload_label_schema schema.
+type labelKeyModel struct {
+	Name    types.String `tfsdk:"name"`
+	Enabled types.Bool   `tfsdk:"enabled"`
+}
+
+// labelKeyType is the type of the key blocks of the secureworkload_label_schema schema.
+var labelKeyType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"name":    types.StringType,
+		"enabled": types.BoolType,
+	},
+}
+
+// NewLabelSchemaResource returns the secureworkload_label_schema resource.
+func NewLabelSchemaResource() resource.Resource {
+	return &labelSchemaResource{}
+}
+
+func (r *labelSchemaResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_label_schema"
+}
+
+func (r *labelSchemaResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Resource for managing the label keys, also known as user annotation keys, of a root scope in Secure Workload\n" +
+			"\n" +
+			"Inventory and flows can only be searched by the labels whose key exists and is enabled. " +
+			"The resource manages the keys listed in its configuration and leaves the other keys of the root scope alone. " +
+			"A root scope has at most 32 keys, including the ones not managed by the resource. Plans adding keys fail when the root scope would have more.\n" +
+			"\n" +
+			"Keys created by the resource are deleted, along with the labels using them, when they are removed from the configuration " +
+			"and when the resource is destroyed. Keys that already existed when they were added to the configuration are adopted: " +
+			"they are enabled or disabled as configured, but are only deleted when `delete_adopted_keys` is true.\n" +
+			"\n" +
+			"## Example\n" +
+			"An example is shown below: \n" +
+			"```hcl\n" +
+			"resource \"secureworkload_label_schema\" \"acme\" {\n" +
+			"    root_scope_name = \"acme\"\n" +
+			"    key {\n" +
+			"        name = \"Environment\"\n" +
+			"    }\n" +
+			"    key {\n" +
+			"        name    = \"Owner\"\n" +
+			"        enabled = false\n" +
+			"    }\n" +
+			"}\n" +
+			"```\n" +
+			"\n" +
+			"## Import\n" +
+			"Label schemas can be imported using the root scope name, all the existing keys are then adopted by the resource:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_label_schema.acme acme\n" +
+			"```\n",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The ID of this resource.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"root_scope_name": schema.StringAttribute{
+				Required:    true,
+				Description: "SecureWorkload root app scope name.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"delete_adopted_keys": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+				Description: "(Optional) Whether the keys that already existed when they were added to the configuration are deleted, along with the labels using them, when they are removed from it or when the resource is destroyed. Default is false.",
+			},
+			"created_keys": schema.SetAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Names of the keys created by the resource, which are deleted when they are removed from the configuration.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"key": schema.SetNestedBlock{
+				Description: "Label key of the root scope, at most 32 of them.",
+				Validators:  []validator.Set{setSizeAtMostValidator{max: MaxAnnotationKeys}},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Required:    true,
+							Description: "Name of the key, as used in the attributes of labels.",
+						},
+						"enabled": schema.BoolAttribute{
+							Optional:    true,
+							Computed:    true,
+							Default:     booldefault.StaticBool(true),
+							Description: "(Optional) Whether inventory and flows can be searched by the key. Default is true.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *labelSchemaResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client, r.configured = clientFromProviderData(req.ProviderData, &resp.Diagnostics)
+}
+
+// ValidateConfig checks that the keys have valid names and are listed once.
+func (r *labelSchemaResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config labelSchemaResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() || config.Keys.IsNull() || config.Keys.IsUnknown() {
+		return
+	}
+	var keys []labelKeyModel
+	resp.Diagnostics.Append(config.Keys.ElementsAs(ctx, &keys, false)...)
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if key.Name.IsUnknown() {
+			continue
+		}
+		name := key.Name.ValueString()
+		if strings.TrimSpace(name) == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("key"), "Invalid label key", "key names must not be empty")
+		} else if strings.EqualFold(name, annotationsIPColumn) || strings.EqualFold(name, annotationsVRFColumn) {
+			resp.Diagnostics.AddAttributeError(path.Root("key"), "Invalid label key", fmt.Sprintf("key %s is reserved", name))
+		} else if seen[name] {
+			resp.Diagnostics.AddAttributeError(path.Root("key"), "Invalid label key", fmt.Sprintf("key %s is listed more than once", name))
+		}
+		seen[name] = true
+	}
+}
+
+// ModifyPlan keeps the created keys known when no key is added, and warns
+// about the adopted keys that are removed from the configuration, as
+// they are left in place rather than deleted. When keys are added, it
+// checks that the root scope can have them along with its other keys.
+func (r *labelSchemaResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan labelSchemaResourceModel
+	state := labelSchemaResourceModel{Keys: types.SetNull(labelKeyType), CreatedKeys: types.SetNull(types.StringType)}
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	}
+	if resp.Diagnostics.HasError() || !frameworkValueKnown(plan.Keys) {
+		return
+	}
+	planned, diags := annotationKeysFromFramework(ctx, plan.Keys)
+	resp.Diagnostics.Append(diags...)
+	inState, diags := annotationKeysFromFramework(ctx, state.Keys)
+	resp.Diagnostics.Append(diags...)
+	created, diags := stringSetFromFramework(ctx, state.CreatedKeys)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// Keys of a replaced root scope are deleted from the old one
+	if !plan.RootScopeName.Equal(state.RootScopeName) {
+		inState, created = nil, map[string]bool{}
+	}
+	deleted, _ := diffAnnotationKeys(inState, planned)
+	var leftInPlace []string
+	deleting := map[string]bool{}
+	for _, name := range deleted {
+		if !created[name] && !plan.DeleteAdoptedKeys.ValueBool() {
+			leftInPlace = append(leftInPlace, name)
+		} else {
+			deleting[name] = true
+		}
+	}
+	if len(leftInPlace) > 0 {
+		resp.Diagnostics.AddAttributeWarning(path.Root("key"), "Adopted label keys are not deleted",
+			fmt.Sprintf("The keys %s of root scope %s were not created by the resource and are left in place, set delete_adopted_keys to delete them.",
+				strings.Join(leftInPlace, ", "), plan.RootScopeName.ValueString()))
+	}
+	added := addedAnnotationKeys(inState, planned)
+	if len(added) > 0 && r.configured && frameworkValueKnown(plan.RootScopeName) {
+		r.checkAnnotationKeyCount(ctx, plan.RootScopeName.ValueString(), planned, deleting, &resp.Diagnostics)
+	}
+	if req.State.Raw.IsNull() || len(added) > 0 {
+		return
+	}
+	plan.CreatedKeys, diags = createdKeysToFramework(ctx, created, planned)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+}
+
+// checkAnnotationKeyCount reports an error when the existing keys of the
+// root scope that are not planned nor deleted, together with the planned
+// keys, are more than the root scope can have. Keys that can't be listed
+// are logged, so as not to fail plans.
+func (r *labelSchemaResource) checkAnnotationKeyCount(ctx context.Context, rootScopeName string, planned []AnnotationKey, deleting map[string]bool, diags *diag.Diagnostics) {
+	existing, err := r.client.WithContext(ctx).LookupAnnotationKeys(rootScopeName)
+	if err != nil {
+		log.Printf("[WARN] Error %s listing the label keys of root scope %s", err, rootScopeName)
+		return
+	}
+	names := map[string]bool{}
+	for _, key := range planned {
+		names[key.Name] = true
+	}
+	var unmanaged []string
+	for _, key := range existing {
+		if !names[key.Name] && !deleting[key.Name] {
+			unmanaged = append(unmanaged, key.Name)
+		}
+	}
+	if total := len(planned) + len(unmanaged); total > MaxAnnotationKeys {
+		sort.Strings(unmanaged)
+		diags.AddAttributeError(path.Root("key"), "Too many label keys",
+			fmt.Sprintf("Root scope %s would have %d label keys, more than the %d it can have, as the keys %s exist outside of the configuration.",
+				rootScopeName, total, MaxAnnotationKeys, strings.Join(unmanaged, ", ")))
+	}
+}
+
+func (r *labelSchemaResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan labelSchemaResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	keys, diags := annotationKeysFromFramework(ctx, plan.Keys)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := r.client.WithContext(ctx)
+	rootScopeName := plan.RootScopeName.ValueString()
+	created, err := setAnnotationKeys(client, rootScopeName, map[string]bool{}, keys, keys)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to set label keys", err.Error())
+		return
+	}
+	plan.Id = types.StringValue(rootScopeName)
+	plan.CreatedKeys, diags = createdKeysToFramework(ctx, created, keys)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Read refreshes the keys in state, dropping the deleted ones.
+// The other keys of the root scope are not added to state.
+func (r *labelSchemaResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state labelSchemaResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	current, err := r.client.WithContext(ctx).ListAnnotationKeys(state.Id.ValueString())
+	if IsNotFound(err) {
+		log.Printf("[WARN] Resource %s no longer exists, removing it from state", state.Id.ValueString())
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to list label keys", err.Error())
+		return
+	}
+	inState, diags := annotationKeysFromFramework(ctx, state.Keys)
+	resp.Diagnostics.Append(diags...)
+	created, diags := stringSetFromFramework(ctx, state.CreatedKeys)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	currentByName := map[string]AnnotationKey{}
+	for _, key := range current {
+		currentByName[key.Name] = key
+	}
+	refreshed := []AnnotationKey{}
+	for _, key := range inState {
+		if key, ok := currentByName[key.Name]; ok {
+			refreshed = append(refreshed, key)
+		} else {
+			log.Printf("[WARN] Label key %s no longer exists, removing it from state", key.Name)
+		}
+	}
+	state.RootScopeName = state.Id
+	state.Keys, diags = annotationKeysToFramework(ctx, refreshed)
+	resp.Diagnostics.Append(diags...)
+	state.CreatedKeys, diags = createdKeysToFramework(ctx, created, refreshed)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *labelSchemaResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state labelSchemaResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	old, diags := annotationKeysFromFramework(ctx, state.Keys)
+	resp.Diagnostics.Append(diags...)
+	new, diags := annotationKeysFromFramework(ctx, plan.Keys)
+	resp.Diagnostics.Append(diags...)
+	created, diags := stringSetFromFramework(ctx, state.CreatedKeys)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := r.client.WithContext(ctx)
+	deleted, set := diffAnnotationKeys(old, new)
+	// Delete keys first to stay within the limit of keys
+	if err := deleteAnnotationKeys(client, state.Id.ValueString(), created, plan.DeleteAdoptedKeys.ValueBool(), deleted); err != nil {
+		resp.Diagnostics.AddError("Unable to delete label keys", err.Error())
+		return
+	}
+	created, err := setAnnotationKeys(client, state.Id.ValueString(), created, addedAnnotationKeys(old, new), set)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to set label keys", err.Error())
+		return
+	}
+	plan.Id = state.Id
+	plan.CreatedKeys, diags = createdKeysToFramework(ctx, created, new)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Delete deletes the keys created by the resource, and
+// the adopted ones when delete_adopted_keys is true.
+func (r *labelSchemaResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state labelSchemaResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	keys, diags := annotationKeysFromFramework(ctx, state.Keys)
+	resp.Diagnostics.Append(diags...)
+	created, diags := stringSetFromFramework(ctx, state.CreatedKeys)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var names []string
+	for _, key := range keys {
+		names = append(names, key.Name)
+	}
+	if err := deleteAnnotationKeys(r.client.WithContext(ctx), state.Id.ValueString(), created, state.DeleteAdoptedKeys.ValueBool(), names); err != nil {
+		resp.Diagnostics.AddError("Unable to delete label keys", err.Error())
+	}
+}
+
+// ImportState adopts all the keys of the root scope named by the id.
+func (r *labelSchemaResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	current, err := r.client.WithContext(ctx).ListAnnotationKeys(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to list label keys", err.Error())
+		return
+	}
+	keys, diags := annotationKeysToFramework(ctx, current)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, labelSchemaResourceModel{
+		Id:                types.StringValue(req.ID),
+		RootScopeName:     types.StringValue(req.ID),
+		DeleteAdoptedKeys: types.BoolValue(false),
+		Keys:              keys,
+		CreatedKeys:       types.SetValueMust(types.StringType, nil),
+	})...)
+}
+
+// setAnnotationKeys creates, enables or disables the given keys of a root
+// scope, and returns the names of the keys created by the resource: the
+// ones in created and the added keys that did not exist yet.
+func setAnnotationKeys(client Client, rootScopeName string, created map[string]bool, added []AnnotationKey, set []AnnotationKey) (map[string]bool, error) {
+	existing := map[string]bool{}
+	if len(added) > 0 {
+		keys, err := client.ListAnnotationKeys(rootScopeName)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			existing[key.Name] = true
+		}
+	}
+	result := map[string]bool{}
+	for name := range created {
+		result[name] = true
+	}
+	for _, key := range added {
+		if !existing[key.Name] {
+			result[key.Name] = true
+		}
+	}
+	for _, key := range set {
+		if err := client.SetAnnotationKey(rootScopeName, key); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// deleteAnnotationKeys deletes the named keys of a root scope that were
+// created by the resource, and the other ones when deleteAdopted is true.
+func deleteAnnotationKeys(client Client, rootScopeName string, created map[string]bool, deleteAdopted bool, names []string) error {
+	for _, name := range names {
+		if !created[name] && !deleteAdopted {
+			log.Printf("[INFO] Leaving label key %s of root scope %s in place, as it was not created by the resource", name, rootScopeName)
+			continue
+		}
+		if err := client.DeleteAnnotationKey(rootScopeName, name); err != nil && !IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffAnnotationKeys returns the names of the old keys that are not in
+// new, and the new keys that are not in old or are enabled or disabled.
+func diffAnnotationKeys(old []AnnotationKey, new []AnnotationKey) ([]string, []AnnotationKey) {
+	oldByName := map[string]AnnotationKey{}
+	for _, key := range old {
+		oldByName[key.Name] = key
+	}
+	newByName := map[string]bool{}
+	var set []AnnotationKey
+	for _, key := range new {
+		newByName[key.Name] = true
+		if previous, ok := oldByName[key.Name]; !ok || previous.Enabled != key.Enabled {
+			set = append(set, key)
+		}
+	}
+	var deleted []string
+	for _, key := range old {
+		if !newByName[key.Name] {
+			deleted = append(deleted, key.Name)
+		}
+	}
+	return deleted, set
+}
+
+// addedAnnotationKeys returns the new keys whose name is not in old.
+func addedAnnotationKeys(old []AnnotationKey, new []AnnotationKey) []AnnotationKey {
+	oldByName := map[string]bool{}
+	for _, key := range old {
+		oldByName[key.Name] = true
+	}
+	var added []AnnotationKey
+	for _, key := range new {
+		if !oldByName[key.Name] {
+			added = append(added, key)
+		}
+	}
+	return added
+}
+
+// annotationKeysFromFramework returns the keys of key blocks, sorted by name.
+func annotationKeysFromFramework(ctx context.Context, tfKeys types.Set) ([]AnnotationKey, diag.Diagnostics) {
+	keys := []AnnotationKey{}
+	if tfKeys.IsNull() || tfKeys.IsUnknown() {
+		return keys, nil
+	}
+	var models []labelKeyModel
+	diags := tfKeys.ElementsAs(ctx, &models, false)
+	for _, model := range models {
+		keys = append(keys, AnnotationKey{
+			Name:    model.Name.ValueString(),
+			Enabled: model.Enabled.ValueBool(),
+		})
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Name < keys[j].Name
+	})
+	return keys, diags
+}
+
+func annotationKeysToFramework(ctx context.Context, keys []AnnotationKey) (types.Set, diag.Diagnostics) {
+	models := []labelKeyModel{}
+	for _, key := range keys {
+		models = append(models, labelKeyModel{
+			Name:    types.StringValue(key.Name),
+			Enabled: types.BoolValue(key.Enabled),
+		})
+	}
+	return types.SetValueFrom(ctx, labelKeyType, models)
+}
+
+// stringSetFromFramework returns the strings of a set of strings.
+func stringSetFromFramework(ctx context.Context, tfSet types.Set) (map[string]bool, diag.Diagnostics) {
+	set := map[string]bool{}
+	if tfSet.IsNull() || tfSet.IsUnknown() {
+		return set, nil
+	}
+	var values []string
+	diags := tfSet.ElementsAs(ctx, &values, false)
+	for _, value := range values {
+		set[value] = true
+	}
+	return set, diags
+}
+
+// createdKeysToFramework returns the names of the given keys that are in created.
+func createdKeysToFramework(ctx context.Context, created map[string]bool, keys []AnnotationKey) (types.Set, diag.Diagnostics) {
+	names := []string{}
+	for _, key := range keys {
+		if created[key.Name] {
+			names = append(names, key.Name)
+		}
+	}
+	return types.SetValueFrom(ctx, types.StringType, names)
+}
@@ -0,0 +1,309 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDiffAnnotationKeys(t *testing.T) {
+	old := []AnnotationKey{{Name: "Environment", Enabled: true}, {Name: "Location", Enabled: true}, {Name: "Owner", Enabled: true}}
+	new := []AnnotationKey{{Name: "Environment", Enabled: true}, {Name: "Owner", Enabled: false}, {Name: "Tier", Enabled: true}}
+	deleted, set := diffAnnotationKeys(old, new)
+	if !reflect.DeepEqual(deleted, []string{"Location"}) {
+		t.Errorf("Expected Location to be deleted, got %v", deleted)
+	}
+	if expected := new[1:]; !reflect.DeepEqual(set, expected) {
+		t.Errorf("Expected %v to be set, got %v", expected, set)
+	}
+	if added := addedAnnotationKeys(old, new); !reflect.DeepEqual(added, new[2:]) {
+		t.Errorf("Expected Tier to be added, got %v", added)
+	}
+}
+
+// labelSchemaKeys returns the value of key blocks with the given enabled flags.
+func labelSchemaKeys(keys map[string]bool) tftypes.Value {
+	keyType := labelKeyType.TerraformType(context.Background())
+	var values []tftypes.Value
+	for name, enabled := range keys {
+		values = append(values, tftypes.NewValue(keyType, map[string]tftypes.Value{
+			"name":    tftypes.NewValue(tftypes.String, name),
+			"enabled": tftypes.NewValue(tftypes.Bool, enabled),
+		}))
+	}
+	return tftypes.NewValue(tftypes.Set{ElementType: keyType}, values)
+}
+
+func TestLabelSchemaRejectsDuplicateAndReservedKeys(t *testing.T) {
+	ctx := context.Background()
+	r := &labelSchemaResource{}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	keyType := labelKeyType.TerraformType(ctx)
+	for _, keys := range [][]tftypes.Value{
+		{
+			tftypes.NewValue(keyType, map[string]tftypes.Value{"name": tftypes.NewValue(tftypes.String, "Owner"), "enabled": tftypes.NewValue(tftypes.Bool, nil)}),
+			tftypes.NewValue(keyType, map[string]tftypes.Value{"name": tftypes.NewValue(tftypes.String, "Owner"), "enabled": tftypes.NewValue(tftypes.Bool, false)}),
+		},
+		{
+			tftypes.NewValue(keyType, map[string]tftypes.Value{"name": tftypes.NewValue(tftypes.String, "VRF"), "enabled": tftypes.NewValue(tftypes.Bool, nil)}),
+		},
+	} {
+		config := frameworkResourceValue(t, r, map[string]tftypes.Value{
+			"root_scope_name": tftypes.NewValue(tftypes.String, "acme"),
+			"key":             tftypes.NewValue(tftypes.Set{ElementType: keyType}, keys),
+		})
+		resp := &resource.ValidateConfigResponse{}
+		r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Errorf("Expected keys %v to be rejected", keys)
+		}
+	}
+}
+
+func TestLabelSchemaEnablesKeysByDefault(t *testing.T) {
+	ctx := context.Background()
+	server, err := ProviderServer(ctx)
+	if err != nil {
+		t.Fatalf("Error %s creating provider server", err)
+	}
+	r := NewLabelSchemaResource()
+	keyType := labelKeyType.TerraformType(ctx)
+	config := frameworkResourceValue(t, r, map[string]tftypes.Value{
+		"root_scope_name": tftypes.NewValue(tftypes.String, "acme"),
+		"key": tftypes.NewValue(tftypes.Set{ElementType: keyType}, []tftypes.Value{
+			tftypes.NewValue(keyType, map[string]tftypes.Value{"name": tftypes.NewValue(tftypes.String, "Owner"), "enabled": tftypes.NewValue(tftypes.Bool, nil)}),
+		}),
+	})
+	objectType := config.Type()
+	dynamicValue := func(value tftypes.Value) *tfprotov5.DynamicValue {
+		dv, err := tfprotov5.NewDynamicValue(objectType, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &dv
+	}
+	resp, err := server().PlanResourceChange(ctx, &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         "secureworkload_label_schema",
+		PriorState:       dynamicValue(tftypes.NewValue(objectType, nil)),
+		ProposedNewState: dynamicValue(config),
+		Config:           dynamicValue(config),
+	})
+	if err != nil {
+		t.Fatalf("Error %s planning", err)
+	}
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("Unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+	planned, err := resp.PlannedState.Unmarshal(objectType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := labelSchemaKeys(map[string]bool{"Owner": true})
+	var attributes map[string]tftypes.Value
+	if err := planned.As(&attributes); err != nil {
+		t.Fatal(err)
+	}
+	if !attributes["key"].Equal(expected) {
+		t.Errorf("Expected the key to be enabled by default, got %s", attributes["key"])
+	}
+}
+
+// annotationKeysServer serves the annotation keys API with the given keys,
+// recording the keys that are set and deleted.
+type annotationKeysServer struct {
+	mutex   sync.Mutex
+	keys    map[string]bool
+	deleted []string
+}
+
+func (s *annotationKeysServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		s.keys[name] = !strings.Contains(string(body), "false")
+		w.Write([]byte(`{}`))
+	case http.MethodDelete:
+		delete(s.keys, name)
+		s.deleted = append(s.deleted, name)
+		w.Write([]byte(`{}`))
+	default:
+		var entries []string
+		for name, enabled := range s.keys {
+			entries = append(entries, fmt.Sprintf(`{"name": %q, "enabled": %t}`, name, enabled))
+		}
+		w.Write([]byte("[" + strings.Join(entries, ",") + "]"))
+	}
+}
+
+func TestLabelSchemaOnlyDeletesCreatedKeys(t *testing.T) {
+	ctx := context.Background()
+	keys := &annotationKeysServer{keys: map[string]bool{"Owner": true, "Location": true}}
+	server := httptest.NewServer(keys)
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = nil
+	r := &labelSchemaResource{client: client, configured: true}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+	plan := frameworkResourceValue(t, r, map[string]tftypes.Value{
+		"root_scope_name":     tftypes.NewValue(tftypes.String, "acme"),
+		"delete_adopted_keys": tftypes.NewValue(tftypes.Bool, false),
+		"id":                  tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		"created_keys":        tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, tftypes.UnknownValue),
+		"key":                 labelSchemaKeys(map[string]bool{"Owner": false, "Environment": true}),
+	})
+	createResp := &resource.CreateResponse{State: tfsdk.State{Schema: s, Raw: tftypes.NewValue(plan.Type(), nil)}}
+	r.Create(ctx, resource.CreateRequest{Plan: tfsdk.Plan{Schema: s, Raw: plan}}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Unexpected errors creating the label schema: %v", createResp.Diagnostics)
+	}
+	var state labelSchemaResourceModel
+	createResp.State.Get(ctx, &state)
+	created, _ := stringSetFromFramework(ctx, state.CreatedKeys)
+	if !reflect.DeepEqual(created, map[string]bool{"Environment": true}) {
+		t.Errorf("Expected only Environment to be created, got %v", created)
+	}
+	if !reflect.DeepEqual(keys.keys, map[string]bool{"Owner": false, "Location": true, "Environment": true}) {
+		t.Errorf("Expected Owner to be disabled and Environment to be created, got %v", keys.keys)
+	}
+
+	readResp := &resource.ReadResponse{State: createResp.State}
+	r.Read(ctx, resource.ReadRequest{State: createResp.State}, readResp)
+	readResp.State.Get(ctx, &state)
+	inState, _ := annotationKeysFromFramework(ctx, state.Keys)
+	var names []string
+	for _, key := range inState {
+		names = append(names, key.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Environment", "Owner"}) {
+		t.Errorf("Expected only the configured keys in state, got %v", names)
+	}
+
+	r.Delete(ctx, resource.DeleteRequest{State: readResp.State}, &resource.DeleteResponse{})
+	sort.Strings(keys.deleted)
+	if !reflect.DeepEqual(keys.deleted, []string{"Environment"}) {
+		t.Errorf("Expected only Environment to be deleted, got %v", keys.deleted)
+	}
+}
+
+func TestLabelSchemaWarnsAboutAdoptedKeysLeftInPlace(t *testing.T) {
+	r := &labelSchemaResource{}
+	created := tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "Environment")})
+	state := frameworkResourceValue(t, r, map[string]tftypes.Value{
+		"id":                  tftypes.NewValue(tftypes.String, "acme"),
+		"root_scope_name":     tftypes.NewValue(tftypes.String, "acme"),
+		"delete_adopted_keys": tftypes.NewValue(tftypes.Bool, false),
+		"created_keys":        created,
+		"key":                 labelSchemaKeys(map[string]bool{"Owner": true, "Environment": true}),
+	})
+	plan := frameworkResourceValue(t, r, map[string]tftypes.Value{
+		"id":                  tftypes.NewValue(tftypes.String, "acme"),
+		"root_scope_name":     tftypes.NewValue(tftypes.String, "acme"),
+		"delete_adopted_keys": tftypes.NewValue(tftypes.Bool, false),
+		"created_keys":        tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, tftypes.UnknownValue),
+		"key":                 labelSchemaKeys(map[string]bool{}),
+	})
+	resp := modifyFrameworkPlan(t, r, state, plan, plan)
+	warnings := resp.Diagnostics.Warnings()
+	if resp.Diagnostics.HasError() || len(warnings) != 1 || !strings.Contains(warnings[0].Detail(), "keys Owner of root scope acme") {
+		t.Errorf("Expected a warning about the Owner key, got %v", resp.Diagnostics)
+	}
+	var planned labelSchemaResourceModel
+	resp.Plan.Get(context.Background(), &planned)
+	if plannedCreated, _ := stringSetFromFramework(context.Background(), planned.CreatedKeys); len(plannedCreated) != 0 || planned.CreatedKeys.IsUnknown() {
+		t.Errorf("Expected no created keys to be planned, got %s", planned.CreatedKeys)
+	}
+}
+
+func TestLabelSchemaRejectsMoreKeysThanTheRootScopeCanHave(t *testing.T) {
+	existing := map[string]bool{"Owner": true}
+	for i := 0; i < MaxAnnotationKeys-2; i++ {
+		existing[fmt.Sprintf("Key%d", i)] = true
+	}
+	server := httptest.NewServer(&annotationKeysServer{keys: existing})
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = nil
+	r := &labelSchemaResource{client: client, configured: true}
+	schemaValue := func(keys map[string]bool, created tftypes.Value) tftypes.Value {
+		return frameworkResourceValue(t, r, map[string]tftypes.Value{
+			"id":                  tftypes.NewValue(tftypes.String, "acme"),
+			"root_scope_name":     tftypes.NewValue(tftypes.String, "acme"),
+			"delete_adopted_keys": tftypes.NewValue(tftypes.Bool, false),
+			"created_keys":        created,
+			"key":                 labelSchemaKeys(keys),
+		})
+	}
+	createdOwner := tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "Owner")})
+	unknown := tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, tftypes.UnknownValue)
+	state := schemaValue(map[string]bool{"Owner": true}, createdOwner)
+	for _, test := range []struct {
+		state    tftypes.Value
+		keys     map[string]bool
+		expected bool
+	}{
+		// Owner is deleted, as it was created by the resource
+		{state, map[string]bool{"Environment": true, "Tier": true}, false},
+		{state, map[string]bool{"Environment": true, "Tier": true, "Location": true}, true},
+		{tftypes.NewValue(state.Type(), nil), map[string]bool{"Environment": true}, false},
+		{tftypes.NewValue(state.Type(), nil), map[string]bool{"Environment": true, "Tier": true}, true},
+	} {
+		plan := schemaValue(test.keys, unknown)
+		resp := modifyFrameworkPlan(t, r, test.state, plan, plan)
+		if resp.Diagnostics.HasError() != test.expected {
+			t.Errorf("Expected an error %v planning the keys %v, got %v", test.expected, test.keys, resp.Diagnostics)
+		}
+		if test.expected && !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), fmt.Sprintf("more than the %d it can have", MaxAnnotationKeys)) {
+			t.Errorf("Unexpected error %s", resp.Diagnostics.Errors()[0].Detail())
+		}
+	}
+}
+
+func TestLabelsWarnAboutUnsearchableKeysWhenPlanning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name": "Environment", "enabled": true}, {"name": "Owner", "enabled": false}]`))
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = nil
+	r := &labelResource{client: client, configured: true}
+	config := frameworkResourceValue(t, r, map[string]tftypes.Value{
+		"root_scope_name": tftypes.NewValue(tftypes.String, "acme"),
+		"ip":              tftypes.NewValue(tftypes.String, "10.0.0.1"),
+		"attributes": tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+			"Environment": tftypes.NewValue(tftypes.String, "test"),
+			"Owner":       tftypes.NewValue(tftypes.String, "payments"),
+			"Datacenter":  tftypes.NewValue(tftypes.String, "aws"),
+			"Tier":        tftypes.NewValue(tftypes.String, "web"),
+		}),
+	})
+	resp := modifyFrameworkPlan(t, r, tftypes.NewValue(config.Type(), nil), config, config)
+	warnings := resp.Diagnostics.Warnings()
+	if resp.Diagnostics.HasError() || len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %v", resp.Diagnostics)
+	}
+	for i, expected := range []string{"keys Datacenter, Tier are not label keys", "label keys Owner of root scope acme are disabled"} {
+		if !strings.Contains(warnings[i].Detail(), expected) {
+			t.Errorf("Expected a warning that the %s, got %s: %s", expected, warnings[i].Summary(), warnings[i].Detail())
+		}
+	}
+}
@@ -0,0 +1,649 @@
+package secureworkload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.ResourceWithConfigure      = &labelsBulkResource{}
+	_ resource.ResourceWithImportState    = &labelsBulkResource{}
+	_ resource.ResourceWithModifyPlan     = &labelsBulkResource{}
+	_ resource.ResourceWithValidateConfig = &labelsBulkResource{}
+)
+
+// labelsBulkResource manages many labels of a root scope at once.
+type labelsBulkResource struct {
+	client     Client
+	configured bool
+}
+
+// labelsBulkResourceModel maps the secureworkload_labels_bulk schema.
+type labelsBulkResourceModel struct {
+	Id            types.String `tfsdk:"id"`
+	RootScopeName types.String `tfsdk:"root_scope_name"`
+	Operation     types.String `tfsdk:"operation"`
+	Labels        types.Set    `tfsdk:"labels"`
+	SourceFile    types.String `tfsdk:"source_file"`
+}
+
+// labelModel maps the labels of the secureworkload_labels_bulk schema.
+type labelModel struct {
+	Ip         string            `tfsdk:"ip"`
+	Attributes map[string]string `tfsdk:"attributes"`
+}
+
+// labelType is the type of the labels of the secureworkload_labels_bulk schema.
+var labelType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"ip":         types.StringType,
+		"attributes": types.MapType{ElemType: types.StringType},
+	},
+}
+
+// NewLabelsBulkResource returns the secureworkload_labels_bulk resource.
+func NewLabelsBulkResource() resource.Resource {
+	return &labelsBulkResource{}
+}
+
+func (r *labelsBulkResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_labels_bulk"
+}
+
+func (r *labelsBulkResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Resource for managing many labels of a root scope in Secure Workload at once\n" +
+			"\n" +
+			"The labels are uploaded as a single CSV file through the user annotations API, rather than with one request per address " +
+			"as with `secureworkload_label`, and are read back by downloading the annotations of the root scope. " +
+			"They are either listed in `labels` or loaded from a CSV or JSON file with `source_file`, " +
+			"in which case the plan still shows the labels that are added, changed and removed.\n" +
+			"\n" +
+			"With the default `operation`, `add`, the resource owns the addresses it labels: their annotations are replaced " +
+			"by the ones of the resource and deleted along with it. With `merge`, only the keys of the resource are managed " +
+			"and the other annotations of its addresses are kept.\n" +
+			"\n" +
+			"## Example\n" +
+			"An example is shown below: \n" +
+			"```hcl\n" +
+			"resource \"secureworkload_labels_bulk\" \"cmdb\" {\n" +
+			"    root_scope_name = \"acme\"\n" +
+			"    labels = [\n" +
+			"        {\n" +
+			"            ip = \"10.0.0.1\"\n" +
+			"            attributes = {\n" +
+			"                Environment = \"production\"\n" +
+			"                Owner       = \"payments\"\n" +
+			"            }\n" +
+			"        },\n" +
+			"        {\n" +
+			"            ip = \"10.0.1.0/24\"\n" +
+			"            attributes = {\n" +
+			"                Environment = \"test\"\n" +
+			"            }\n" +
+			"        },\n" +
+			"    ]\n" +
+			"}\n" +
+			"\n" +
+			"resource \"secureworkload_labels_bulk\" \"from_file\" {\n" +
+			"    root_scope_name = \"acme\"\n" +
+			"    operation       = \"merge\"\n" +
+			"    source_file     = \"${path.module}/cmdb.csv\"\n" +
+			"}\n" +
+			"```\n" +
+			"CSV files start with a header naming the `IP` column and one column per key, empty values are left out. " +
+			"JSON files hold a list of objects with an `ip` and an `attributes` map.\n" +
+			"\n" +
+			"**Note:** Do not label the same addresses with several `secureworkload_labels_bulk` resources or along with `secureworkload_label`, " +
+			"as they would overwrite each other.\n" +
+			"\n" +
+			"## Import\n" +
+			"Bulk labels can be imported using the root scope name, all the labels of the root scope are then managed by the resource:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_labels_bulk.cmdb acme\n" +
+			"```\n",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The ID of this resource.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"root_scope_name": schema.StringAttribute{
+				Required:    true,
+				Description: "SecureWorkload root app scope name.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"operation": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(AnnotationsOperationAdd),
+				Validators:  []validator.String{stringOneOfValidator{values: []string{AnnotationsOperationAdd, AnnotationsOperationMerge}}},
+				Description: "How the labels are uploaded: add replaces all the annotations of the labelled addresses, merge only manages the keys of the labels. Default is add.",
+			},
+			"labels": schema.SetAttribute{
+				Optional:    true,
+				Computed:    true,
+				ElementType: labelType,
+				Description: "Labels of addresses or subnets, each with the IPv4/IPv6 address or subnet in ip and the key/value map for tagging matching flows and inventory items in attributes. Computed from the file when source_file is set.",
+			},
+			"source_file": schema.StringAttribute{
+				Optional:    true,
+				Description: "Path of a CSV file, or of a JSON file when it ends with .json, to load the labels from instead of labels.",
+			},
+		},
+	}
+}
+
+func (r *labelsBulkResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client, r.configured = clientFromProviderData(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *labelsBulkResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config labelsBulkResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if config.Labels.IsNull() == config.SourceFile.IsNull() {
+		resp.Diagnostics.AddError("Invalid labels", "exactly one of labels or source_file must be specified")
+	}
+}
+
+// ModifyPlan loads the labels of the source file at plan time, so that
+// the plan shows the labels that change, checks that every address is
+// labelled once and warns about the keys that can't be searched by in
+// the label schema of the root scope.
+func (r *labelsBulkResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan, config labelsBulkResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var annotations []Annotation
+	switch {
+	case config.SourceFile.IsUnknown():
+		plan.Labels = types.SetUnknown(labelType)
+	case !config.SourceFile.IsNull():
+		sourceFile := config.SourceFile.ValueString()
+		var err error
+		annotations, err = readLabelsFile(sourceFile)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("source_file"), "Unable to read the labels file", err.Error())
+			return
+		}
+		if err := validateLabels(annotations); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("source_file"), "Invalid labels", fmt.Sprintf("%s: %s", sourceFile, err))
+			return
+		}
+		var diags diag.Diagnostics
+		plan.Labels, diags = labelsToFramework(ctx, annotations)
+		resp.Diagnostics.Append(diags...)
+	case frameworkValueKnown(config.Labels):
+		var diags diag.Diagnostics
+		annotations, diags = labelsFromFramework(ctx, config.Labels)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if err := validateLabels(annotations); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("labels"), "Invalid labels", err.Error())
+			return
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+	if annotations == nil || !r.configured || plan.RootScopeName.IsUnknown() {
+		return
+	}
+	if !req.State.Raw.IsNull() {
+		var state labelsBulkResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if state.Labels.Equal(plan.Labels) {
+			return
+		}
+	}
+	keys := map[string]bool{}
+	for _, annotation := range annotations {
+		for key := range annotation.Attributes {
+			keys[key] = true
+		}
+	}
+	var names []string
+	for name := range keys {
+		names = append(names, name)
+	}
+	resp.Diagnostics.Append(unsearchableLabelKeysWarnings(ctx, r.client, plan.RootScopeName.ValueString(), names, path.Root("labels"))...)
+}
+
+func (r *labelsBulkResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan labelsBulkResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	annotations, diags := labelsFromFramework(ctx, plan.Labels)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	rootScopeName := plan.RootScopeName.ValueString()
+	if len(annotations) > 0 {
+		if err := r.client.WithContext(ctx).UploadAnnotations(rootScopeName, plan.Operation.ValueString(), annotations); err != nil {
+			resp.Diagnostics.AddError("Unable to upload labels", err.Error())
+			return
+		}
+	}
+	plan.Id = types.StringValue(rootScopeName)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *labelsBulkResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state labelsBulkResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	current, err := r.client.WithContext(ctx).DownloadAnnotations(state.Id.ValueString())
+	if IsNotFound(err) {
+		log.Printf("[WARN] Resource %s no longer exists, removing it from state", state.Id.ValueString())
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to download labels", err.Error())
+		return
+	}
+	inState, diags := labelsFromFramework(ctx, state.Labels)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state.RootScopeName = state.Id
+	state.Labels, diags = labelsToFramework(ctx, refreshLabels(state.Operation.ValueString(), inState, current))
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *labelsBulkResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state labelsBulkResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !plan.Labels.Equal(state.Labels) {
+		old, diags := labelsFromFramework(ctx, state.Labels)
+		resp.Diagnostics.Append(diags...)
+		new, diags := labelsFromFramework(ctx, plan.Labels)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		err := applyLabelsChanges(r.client.WithContext(ctx), state.Id.ValueString(), plan.Operation.ValueString(), old, new)
+		if err != nil {
+			resp.Diagnostics.AddError("Unable to upload labels", err.Error())
+			return
+		}
+	}
+	plan.Id = state.Id
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *labelsBulkResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state labelsBulkResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	inState, diags := labelsFromFramework(ctx, state.Labels)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := applyLabelsChanges(r.client.WithContext(ctx), state.Id.ValueString(), state.Operation.ValueString(), inState, nil)
+	if err != nil && !IsNotFound(err) {
+		resp.Diagnostics.AddError("Unable to delete labels", err.Error())
+	}
+}
+
+// ImportState imports all the labels of the root scope named by the id.
+func (r *labelsBulkResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	current, err := r.client.WithContext(ctx).DownloadAnnotations(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to download labels", err.Error())
+		return
+	}
+	labels, diags := labelsToFramework(ctx, current)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, labelsBulkResourceModel{
+		Id:            types.StringValue(req.ID),
+		RootScopeName: types.StringValue(req.ID),
+		Operation:     types.StringValue(AnnotationsOperationAdd),
+		Labels:        labels,
+		SourceFile:    types.StringNull(),
+	})...)
+}
+
+// unsearchableLabelKeysWarnings returns plan warnings about the label keys
+// that do not exist or are disabled in the label schema of a root scope,
+// as inventory and flows can not be searched by them. Keys that can't be
+// listed are not reported, so as not to fail plans over a warning.
+func unsearchableLabelKeysWarnings(ctx context.Context, client Client, rootScopeName string, names []string, attribute path.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if len(names) == 0 {
+		return diags
+	}
+	keys, err := client.WithContext(ctx).LookupAnnotationKeys(rootScopeName)
+	if err != nil {
+		log.Printf("[WARN] Error %s listing the label keys of root scope %s", err, rootScopeName)
+		return diags
+	}
+	enabled := map[string]bool{}
+	for _, key := range keys {
+		enabled[key.Name] = key.Enabled
+	}
+	var missing, disabled []string
+	for _, name := range names {
+		if isEnabled, ok := enabled[name]; !ok {
+			missing = append(missing, name)
+		} else if !isEnabled {
+			disabled = append(disabled, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(disabled)
+	if len(missing) > 0 {
+		diags.AddAttributeWarning(attribute, "Label keys not in the label schema",
+			fmt.Sprintf("The keys %s are not label keys of root scope %s, inventory and flows can not be searched by them "+
+				"until they are added and enabled, e.g. with secureworkload_label_schema. A root scope has at most %d label keys.",
+				strings.Join(missing, ", "), rootScopeName, MaxAnnotationKeys))
+	}
+	if len(disabled) > 0 {
+		diags.AddAttributeWarning(attribute, "Label keys disabled in the label schema",
+			fmt.Sprintf("The label keys %s of root scope %s are disabled, inventory and flows can not be searched by them until they are enabled.",
+				strings.Join(disabled, ", "), rootScopeName))
+	}
+	return diags
+}
+
+// applyLabelsChanges uploads the changes turning the old labels of a root
+// scope into the new ones. The current annotations are only downloaded
+// when merged labels lose keys, which merge uploads can not clear.
+func applyLabelsChanges(client Client, rootScopeName string, operation string, old []Annotation, new []Annotation) error {
+	var current []Annotation
+	if operation == AnnotationsOperationMerge && labelsLoseKeys(old, new) {
+		var err error
+		current, err = client.DownloadAnnotations(rootScopeName)
+		if err != nil {
+			return err
+		}
+	}
+	changes := diffLabels(operation, old, new, current)
+	if len(changes.remove) > 0 {
+		if err := client.UploadAnnotations(rootScopeName, AnnotationsOperationDelete, changes.remove); err != nil {
+			return err
+		}
+	}
+	if len(changes.replace) > 0 {
+		if err := client.UploadAnnotations(rootScopeName, AnnotationsOperationAdd, changes.replace); err != nil {
+			return err
+		}
+	}
+	if len(changes.upload) > 0 {
+		if err := client.UploadAnnotations(rootScopeName, operation, changes.upload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labelsChanges are the uploads turning the labels in state into the planned ones.
+type labelsChanges struct {
+	// Labels uploaded with the operation of the resource.
+	upload []Annotation
+	// Labels whose annotations are replaced as a whole.
+	replace []Annotation
+	// Labels whose annotations are deleted.
+	remove []Annotation
+}
+
+// diffLabels returns the uploads turning the old labels into the new ones.
+// With merge, the keys dropped from a label are cleared by replacing the
+// current annotations of its address without them, so that the keys
+// not managed by the resource are kept.
+func diffLabels(operation string, old []Annotation, new []Annotation, current []Annotation) labelsChanges {
+	var changes labelsChanges
+	oldByIp, currentByIp := labelsByIp(old), labelsByIp(current)
+	newByIp := labelsByIp(new)
+	for _, annotation := range new {
+		previous, existed := oldByIp[annotation.Ip]
+		if existed && attributesEqual(previous, annotation.Attributes) {
+			continue
+		}
+		dropped := droppedKeys(previous, annotation.Attributes)
+		if operation != AnnotationsOperationMerge || len(dropped) == 0 {
+			changes.upload = append(changes.upload, annotation)
+			continue
+		}
+		attributes := withoutKeys(currentByIp[annotation.Ip], dropped)
+		for key, value := range annotation.Attributes {
+			attributes[key] = value
+		}
+		changes.replace = append(changes.replace, Annotation{Ip: annotation.Ip, Attributes: attributes})
+	}
+	for _, annotation := range old {
+		if _, kept := newByIp[annotation.Ip]; kept {
+			continue
+		}
+		if operation == AnnotationsOperationMerge {
+			if attributes := withoutKeys(currentByIp[annotation.Ip], annotation.Attributes); len(attributes) > 0 {
+				changes.replace = append(changes.replace, Annotation{Ip: annotation.Ip, Attributes: attributes})
+				continue
+			}
+		}
+		changes.remove = append(changes.remove, Annotation{Ip: annotation.Ip})
+	}
+	return changes
+}
+
+// labelsLoseKeys reports whether any key of the old labels is not in the new ones.
+func labelsLoseKeys(old []Annotation, new []Annotation) bool {
+	newByIp := labelsByIp(new)
+	for _, annotation := range old {
+		if len(droppedKeys(annotation.Attributes, newByIp[annotation.Ip])) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshLabels returns the labels in state as currently annotated,
+// dropping the ones whose address is no longer annotated. With merge,
+// only the keys already in state are refreshed.
+func refreshLabels(operation string, inState []Annotation, current []Annotation) []Annotation {
+	currentByIp := labelsByIp(current)
+	refreshed := []Annotation{}
+	for _, annotation := range inState {
+		attributes, ok := currentByIp[annotation.Ip]
+		if !ok {
+			log.Printf("[WARN] Label %s no longer exists, removing it from state", annotation.Ip)
+			continue
+		}
+		if operation == AnnotationsOperationMerge {
+			managed := map[string]string{}
+			for key := range annotation.Attributes {
+				if value, ok := attributes[key]; ok {
+					managed[key] = value
+				}
+			}
+			if len(managed) == 0 {
+				log.Printf("[WARN] Label %s no longer exists, removing it from state", annotation.Ip)
+				continue
+			}
+			attributes = managed
+		}
+		refreshed = append(refreshed, Annotation{Ip: annotation.Ip, Attributes: attributes})
+	}
+	return refreshed
+}
+
+// validateLabels checks that every address is labelled once,
+// with valid addresses and keys.
+func validateLabels(annotations []Annotation) error {
+	seen := map[string]bool{}
+	for _, annotation := range annotations {
+		if _, errs := validateIPOrCIDR(annotation.Ip, "ip"); len(errs) > 0 {
+			return errs[0]
+		}
+		if seen[annotation.Ip] {
+			return fmt.Errorf("%s is labelled more than once", annotation.Ip)
+		}
+		seen[annotation.Ip] = true
+		for key := range annotation.Attributes {
+			if strings.TrimSpace(key) == "" {
+				return fmt.Errorf("label of %s has an empty key", annotation.Ip)
+			}
+			if strings.EqualFold(key, annotationsIPColumn) || strings.EqualFold(key, annotationsVRFColumn) {
+				return fmt.Errorf("label of %s uses the reserved key %s", annotation.Ip, key)
+			}
+		}
+	}
+	return nil
+}
+
+// readLabelsFile reads labels from a CSV file, or from
+// a JSON file when its name ends with .json.
+func readLabelsFile(path string) ([]Annotation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if !strings.HasSuffix(strings.ToLower(path), ".json") {
+		return readAnnotationsCSV(file)
+	}
+	var rows []struct {
+		Ip         string            `json:"ip"`
+		Attributes map[string]string `json:"attributes"`
+	}
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("%s is not a list of labels: %s", path, err)
+	}
+	annotations := []Annotation{}
+	for _, row := range rows {
+		annotation := Annotation{Ip: row.Ip, Attributes: map[string]string{}}
+		for key, value := range row.Attributes {
+			if value != "" {
+				annotation.Attributes[key] = value
+			}
+		}
+		annotations = append(annotations, annotation)
+	}
+	return annotations, nil
+}
+
+func labelsByIp(annotations []Annotation) map[string]map[string]string {
+	byIp := map[string]map[string]string{}
+	for _, annotation := range annotations {
+		byIp[annotation.Ip] = annotation.Attributes
+	}
+	return byIp
+}
+
+func attributesEqual(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// droppedKeys returns the keys of old that are not in new.
+func droppedKeys(old map[string]string, new map[string]string) map[string]string {
+	dropped := map[string]string{}
+	for key, value := range old {
+		if _, ok := new[key]; !ok {
+			dropped[key] = value
+		}
+	}
+	return dropped
+}
+
+// withoutKeys returns a copy of attributes without the given keys.
+func withoutKeys(attributes map[string]string, keys map[string]string) map[string]string {
+	remaining := map[string]string{}
+	for key, value := range attributes {
+		if _, ok := keys[key]; !ok {
+			remaining[key] = value
+		}
+	}
+	return remaining
+}
+
+// labelsFromFramework returns the labels of a set of labels, sorted by address.
+func labelsFromFramework(ctx context.Context, tfLabels types.Set) ([]Annotation, diag.Diagnostics) {
+	annotations := []Annotation{}
+	if tfLabels.IsNull() || tfLabels.IsUnknown() {
+		return annotations, nil
+	}
+	var labels []labelModel
+	diags := tfLabels.ElementsAs(ctx, &labels, false)
+	for _, label := range labels {
+		annotation := Annotation{Ip: label.Ip, Attributes: map[string]string{}}
+		for key, value := range label.Attributes {
+			annotation.Attributes[key] = value
+		}
+		annotations = append(annotations, annotation)
+	}
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return annotations[i].Ip < annotations[j].Ip
+	})
+	return annotations, diags
+}
+
+func labelsToFramework(ctx context.Context, annotations []Annotation) (types.Set, diag.Diagnostics) {
+	labels := []labelModel{}
+	for _, annotation := range annotations {
+		label := labelModel{Ip: annotation.Ip, Attributes: map[string]string{}}
+		for key, value := range annotation.Attributes {
+			label.Attributes[key] = value
+		}
+		labels = append(labels, label)
+	}
+	return types.SetValueFrom(ctx, labelType, labels)
+}
@@ -0,0 +1,165 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDiffLabelsAddReplacesChangedLabels(t *testing.T) {
+	old := []Annotation{
+		{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "test", "Owner": "payments"}},
+		{Ip: "10.0.0.2", Attributes: map[string]string{"Environment": "test"}},
+		{Ip: "10.0.0.3", Attributes: map[string]string{"Environment": "test"}},
+	}
+	new := []Annotation{
+		{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "production"}},
+		{Ip: "10.0.0.2", Attributes: map[string]string{"Environment": "test"}},
+		{Ip: "10.0.0.4", Attributes: map[string]string{"Environment": "test"}},
+	}
+	expected := labelsChanges{
+		upload: []Annotation{new[0], new[2]},
+		remove: []Annotation{{Ip: "10.0.0.3"}},
+	}
+	if changes := diffLabels(AnnotationsOperationAdd, old, new, nil); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, changes)
+	}
+	if labelsLoseKeys(old, new) != true || labelsLoseKeys(new[:2], old) != false {
+		t.Errorf("Expected only dropped keys to be reported as lost")
+	}
+}
+
+func TestDiffLabelsMergeKeepsUnmanagedKeys(t *testing.T) {
+	old := []Annotation{
+		{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "test", "Owner": "payments"}},
+		{Ip: "10.0.0.2", Attributes: map[string]string{"Environment": "test"}},
+		{Ip: "10.0.0.3", Attributes: map[string]string{"Environment": "test"}},
+	}
+	new := []Annotation{
+		{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "production"}},
+		{Ip: "10.0.0.4", Attributes: map[string]string{"Environment": "test"}},
+	}
+	current := []Annotation{
+		{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "test", "Owner": "payments", "Location": "PAR"}},
+		{Ip: "10.0.0.2", Attributes: map[string]string{"Environment": "test", "Location": "LON"}},
+		{Ip: "10.0.0.3", Attributes: map[string]string{"Environment": "test"}},
+	}
+	expected := labelsChanges{
+		upload: []Annotation{new[1]},
+		replace: []Annotation{
+			{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "production", "Location": "PAR"}},
+			{Ip: "10.0.0.2", Attributes: map[string]string{"Location": "LON"}},
+		},
+		remove: []Annotation{{Ip: "10.0.0.3"}},
+	}
+	if changes := diffLabels(AnnotationsOperationMerge, old, new, current); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, changes)
+	}
+}
+
+func TestRefreshLabelsDetectsDrift(t *testing.T) {
+	inState := []Annotation{
+		{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "test"}},
+		{Ip: "10.0.0.2", Attributes: map[string]string{"Environment": "test"}},
+	}
+	current := []Annotation{
+		{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "production", "Location": "PAR"}},
+		{Ip: "10.0.0.9", Attributes: map[string]string{"Environment": "test"}},
+	}
+	expected := []Annotation{current[0]}
+	if refreshed := refreshLabels(AnnotationsOperationAdd, inState, current); !reflect.DeepEqual(refreshed, expected) {
+		t.Errorf("Expected %v, got %v", expected, refreshed)
+	}
+	expected = []Annotation{{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "production"}}}
+	if refreshed := refreshLabels(AnnotationsOperationMerge, inState, current); !reflect.DeepEqual(refreshed, expected) {
+		t.Errorf("Expected merged labels to only refresh their keys, got %v", refreshed)
+	}
+}
+
+func TestSourceFileLabelsArePlanned(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"cmdb.csv":  "IP,Environment,Owner\n10.0.0.1,production,payments\n10.0.1.0/24,test,\n",
+		"cmdb.json": `[{"ip": "10.0.0.1", "attributes": {"Environment": "production", "Owner": "payments"}}, {"ip": "10.0.1.0/24", "attributes": {"Environment": "test", "Owner": ""}}]`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		r := &labelsBulkResource{}
+		config := frameworkResourceValue(t, r, map[string]tftypes.Value{
+			"root_scope_name": tftypes.NewValue(tftypes.String, "acme"),
+			"source_file":     tftypes.NewValue(tftypes.String, path),
+		})
+		resp := modifyFrameworkPlan(t, r, tftypes.NewValue(config.Type(), nil), config, config)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("Error planning labels of %s: %v", name, resp.Diagnostics)
+		}
+		var plan labelsBulkResourceModel
+		resp.Diagnostics.Append(resp.Plan.Get(context.Background(), &plan)...)
+		planned, diags := labelsFromFramework(context.Background(), plan.Labels)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("Error reading the planned labels of %s: %v", name, resp.Diagnostics)
+		}
+		expected := []Annotation{
+			{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "production", "Owner": "payments"}},
+			{Ip: "10.0.1.0/24", Attributes: map[string]string{"Environment": "test"}},
+		}
+		if !reflect.DeepEqual(planned, expected) {
+			t.Errorf("Expected %v to be planned from %s, got %v", expected, name, planned)
+		}
+	}
+}
+
+func TestLabelsBulkWarnsAboutUnsearchableKeysWhenPlanning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name": "Environment", "enabled": true}]`))
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = nil
+	r := &labelsBulkResource{client: client, configured: true}
+	labelTFType := labelType.TerraformType(context.Background())
+	attributesType := tftypes.Map{ElementType: tftypes.String}
+	config := frameworkResourceValue(t, r, map[string]tftypes.Value{
+		"root_scope_name": tftypes.NewValue(tftypes.String, "acme"),
+		"labels": tftypes.NewValue(tftypes.Set{ElementType: labelTFType}, []tftypes.Value{
+			tftypes.NewValue(labelTFType, map[string]tftypes.Value{
+				"ip": tftypes.NewValue(tftypes.String, "10.0.0.1"),
+				"attributes": tftypes.NewValue(attributesType, map[string]tftypes.Value{
+					"Environment": tftypes.NewValue(tftypes.String, "test"),
+					"Owner":       tftypes.NewValue(tftypes.String, "payments"),
+				}),
+			}),
+		}),
+	})
+	resp := modifyFrameworkPlan(t, r, tftypes.NewValue(config.Type(), nil), config, config)
+	warnings := resp.Diagnostics.Warnings()
+	if resp.Diagnostics.HasError() || len(warnings) != 1 || !strings.Contains(warnings[0].Detail(), "keys Owner are not label keys") {
+		t.Errorf("Expected a warning about the Owner key, got %v", resp.Diagnostics)
+	}
+}
+
+func TestValidateLabelsRejectsDuplicatesAndReservedKeys(t *testing.T) {
+	for _, annotations := range [][]Annotation{
+		{{Ip: "10.0.0.1"}, {Ip: "10.0.0.1"}},
+		{{Ip: "10.0.0.300"}},
+		{{Ip: "10.0.0.1", Attributes: map[string]string{"vrf": "acme"}}},
+		{{Ip: "10.0.0.1", Attributes: map[string]string{" ": "acme"}}},
+	} {
+		if err := validateLabels(annotations); err == nil {
+			t.Errorf("Expected %v to be rejected", annotations)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package secureworkload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+const (
+	// Largest number of inventory items listed for each
+	// of the queries compared by a membership preview.
+	membershipPreviewMaxResults = 10000
+	// Number of addresses given as examples of the
+	// inventory items joining or leaving in previews.
+	membershipPreviewSamples = 5
+)
+
+// membershipChange is the difference between the inventory
+// items matched by the current and the planned query.
+type membershipChange struct {
+	// Number of items matched by the current and the planned query.
+	before int
+	after  int
+	// Addresses of the items only matched by the planned or the current query, sorted.
+	joining []string
+	leaving []string
+	// Whether the current or the planned query matched more items than
+	// membershipPreviewMaxResults, so that the change is unknown.
+	beforeTruncated bool
+	afterTruncated  bool
+}
+
+// diffMembers compares the inventory items matched by two queries,
+// either of which may have matched more items than were returned.
+func diffMembers(before []InventoryItem, beforeTruncated bool, after []InventoryItem, afterTruncated bool) membershipChange {
+	change := membershipChange{
+		before:          len(before),
+		after:           len(after),
+		beforeTruncated: beforeTruncated,
+		afterTruncated:  afterTruncated,
+	}
+	if change.truncated() {
+		return change
+	}
+	matchedBefore := map[string]bool{}
+	for _, item := range before {
+		matchedBefore[item.String("ip")] = true
+	}
+	matchedAfter := map[string]bool{}
+	for _, item := range after {
+		ip := item.String("ip")
+		matchedAfter[ip] = true
+		if !matchedBefore[ip] {
+			change.joining = append(change.joining, ip)
+		}
+	}
+	for _, item := range before {
+		if ip := item.String("ip"); !matchedAfter[ip] {
+			change.leaving = append(change.leaving, ip)
+		}
+	}
+	sort.Strings(change.joining)
+	sort.Strings(change.leaving)
+	return change
+}
+
+// truncated reports whether either query matched more items than were
+// compared, in which case the items joining and leaving are unknown.
+func (change membershipChange) truncated() bool {
+	return change.beforeTruncated || change.afterTruncated
+}
+
+// describe describes the change for the given scope or filter. Changes
+// compared on truncated results only report the number of items matched.
+func (change membershipChange) describe(kind string, name string) string {
+	if change.truncated() {
+		return fmt.Sprintf("The new query of %s %s matches %s inventory items instead of %s. "+
+			"The preview is inconclusive, as only the first %d items matched by each query are compared.",
+			kind, name, describeCount(change.after, change.afterTruncated), describeCount(change.before, change.beforeTruncated), membershipPreviewMaxResults)
+	}
+	return fmt.Sprintf("The new query of %s %s matches %d inventory items instead of %d: %s join and %s leave.",
+		kind, name, change.after, change.before, describeMembers(change.joining), describeMembers(change.leaving))
+}
+
+// describeCount describes a number of items, which
+// is a lower bound when the items were truncated.
+func describeCount(count int, truncated bool) string {
+	if truncated {
+		return fmt.Sprintf("more than %d", count)
+	}
+	return fmt.Sprintf("%d", count)
+}
+
+// describeMembers describes a number of addresses with a few examples.
+func describeMembers(ips []string) string {
+	if len(ips) == 0 {
+		return "none"
+	}
+	if len(ips) > membershipPreviewSamples {
+		return fmt.Sprintf("%d, e.g. %s", len(ips), strings.Join(ips[:membershipPreviewSamples], ", "))
+	}
+	return fmt.Sprintf("%d (%s)", len(ips), strings.Join(ips, ", "))
+}
+
+// previewMembershipChange searches the inventory of the scope named
+// scopeName with the current and the planned query of a scope or filter,
+// described by kind and name, and returns the summary and detail of a
+// warning about the plan when more items would join or leave than the
+// membership warning threshold of the client, or when either query
+// matches too many items to tell. Previews that fail are logged, so
+// as not to fail plans.
+func previewMembershipChange(ctx context.Context, client Client, kind string, name string, scopeName string, oldQuery string, newQuery string) (string, string, bool) {
+	threshold := client.Config.MembershipWarningThreshold
+	if threshold < 0 || oldQuery == "" || newQuery == "" || queryJSONEqual(oldQuery, newQuery) {
+		return "", "", false
+	}
+	client = client.WithContext(ctx)
+	var members [2][]InventoryItem
+	var truncated [2]bool
+	for i, query := range []string{oldQuery, newQuery} {
+		if !json.Valid([]byte(query)) {
+			return "", "", false
+		}
+		items, itemsTruncated, err := client.SearchInventoryTruncated(InventorySearchRequest{
+			Filter:     json.RawMessage(query),
+			ScopeName:  scopeName,
+			Dimensions: []string{"ip"},
+		}, membershipPreviewMaxResults)
+		if err != nil {
+			log.Printf("[WARN] Error %s previewing the members of %s %s", err, kind, name)
+			return "", "", false
+		}
+		members[i], truncated[i] = items, itemsTruncated
+	}
+	change := diffMembers(members[0], truncated[0], members[1], truncated[1])
+	if !change.truncated() && len(change.joining)+len(change.leaving) <= threshold {
+		log.Printf("[INFO] %s", change.describe(kind, name))
+		return "", "", false
+	}
+	if change.truncated() {
+		return fmt.Sprintf("Query may change the members of %s %s", kind, name), change.describe(kind, name), true
+	}
+	return fmt.Sprintf("Query changes the members of %s %s", kind, name), change.describe(kind, name), true
+}
+
+// scopeById looks up a scope by its id.
+func scopeById(apiClient Client, id string) (Scope, error) {
+	scopes, err := apiClient.LookupScopes()
+	if err != nil {
+		return Scope{}, err
+	}
+	for _, scope := range scopes {
+		if scope.Id == id {
+			return scope, nil
+		}
+	}
+	return Scope{}, fmt.Errorf("no scope with id %q exists", id)
+}
@@ -0,0 +1,164 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDiffMembersListsJoiningAndLeavingItems(t *testing.T) {
+	before := []InventoryItem{{"ip": "10.0.0.1"}, {"ip": "10.0.0.2"}, {"ip": "10.0.0.3"}}
+	after := []InventoryItem{{"ip": "10.0.0.3"}, {"ip": "10.0.1.1"}}
+	change := diffMembers(before, false, after, false)
+	if change.before != 3 || change.after != 2 || change.truncated() ||
+		!reflect.DeepEqual(change.joining, []string{"10.0.1.1"}) || !reflect.DeepEqual(change.leaving, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("Unexpected change %+v", change)
+	}
+	expected := "The new query of scope acme:web matches 2 inventory items instead of 3: 1 (10.0.1.1) join and 2 (10.0.0.1, 10.0.0.2) leave."
+	if detail := change.describe("scope", "acme:web"); detail != expected {
+		t.Errorf("Expected %q, got %q", expected, detail)
+	}
+}
+
+func TestTruncatedPreviewsOnlyReportCounts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var search InventorySearchRequest
+		json.NewDecoder(r.Body).Decode(&search)
+		if !strings.Contains(string(search.Filter), "10.0.0.0/8") {
+			fmt.Fprint(w, `{"results": [{"ip": "10.0.0.1"}]}`)
+			return
+		}
+		// More items than are compared
+		results := []string{}
+		for i := 0; i < search.Limit; i++ {
+			results = append(results, fmt.Sprintf(`{"ip": "10.%d.%d.%d"}`, i/65536, i/256%256, i%256))
+		}
+		fmt.Fprintf(w, `{"offset": "next", "results": [%s]}`, strings.Join(results, ","))
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.Config.MembershipWarningThreshold = 100000
+	summary, detail, ok := previewMembershipChange(context.Background(), client, "scope", "acme:web", "acme",
+		`{"type": "eq", "field": "ip", "value": "10.0.0.1"}`, `{"type": "subnet", "field": "ip", "value": "10.0.0.0/8"}`)
+	expected := fmt.Sprintf("The new query of scope acme:web matches more than %d inventory items instead of 1. The preview is inconclusive", membershipPreviewMaxResults)
+	if !ok || !strings.HasPrefix(summary, "Query may change") || !strings.HasPrefix(detail, expected) || strings.Contains(detail, "10.0.0.") {
+		t.Errorf("Expected an inconclusive warning with only counts, got %v %q: %q", ok, summary, detail)
+	}
+}
+
+func TestScopeQueryChangesPreviewMembership(t *testing.T) {
+	var searches []InventorySearchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case ScopesAPIV1BasePath:
+			fmt.Fprint(w, `[{"id": "1", "name": "acme"}, {"id": "2", "name": "acme:web", "parent_app_scope_id": "1"}]`)
+		case InventoryAPIV1BasePath + "/search":
+			var search InventorySearchRequest
+			json.NewDecoder(r.Body).Decode(&search)
+			searches = append(searches, search)
+			if strings.Contains(string(search.Filter), "10.0.0.0/24") {
+				fmt.Fprint(w, `{"results": [{"ip": "10.0.0.1"}, {"ip": "10.0.0.2"}]}`)
+			} else {
+				fmt.Fprint(w, `{"results": [{"ip": "10.0.0.1"}]}`)
+			}
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = nil
+	r := &scopeResource{client: client, configured: true}
+	subnet := `{"field":"ip","type":"subnet","value":"10.0.0.0/24"}`
+	state := frameworkResourceValue(t, r, map[string]tftypes.Value{
+		"id":                  tftypes.NewValue(tftypes.String, "2"),
+		"name":                tftypes.NewValue(tftypes.String, "acme:web"),
+		"short_name":          tftypes.NewValue(tftypes.String, "web"),
+		"parent_app_scope_id": tftypes.NewValue(tftypes.String, "1"),
+		"query_json":          tftypes.NewValue(tftypes.String, subnet),
+		"short_query":         tftypes.NewValue(tftypes.String, subnet),
+	})
+	config := frameworkResourceValue(t, r, map[string]tftypes.Value{
+		"short_name":          tftypes.NewValue(tftypes.String, "web"),
+		"parent_app_scope_id": tftypes.NewValue(tftypes.String, "1"),
+		"query_json":          tftypes.NewValue(tftypes.String, `{"type": "eq", "field": "ip", "value": "10.0.0.1"}`),
+	})
+	for threshold, expectedWarnings := range map[int]int{0: 1, 1: 0, -1: 0} {
+		searches = nil
+		r.client.Config.MembershipWarningThreshold = threshold
+		resp := modifyFrameworkPlan(t, r, state, config, config)
+		warnings := resp.Diagnostics.Warnings()
+		if resp.Diagnostics.HasError() || len(warnings) != expectedWarnings {
+			t.Fatalf("Expected %d warnings with threshold %d, got %v", expectedWarnings, threshold, resp.Diagnostics)
+		}
+		if expectedWarnings > 0 && !strings.Contains(warnings[0].Detail(), "1 inventory items instead of 2: none join and 1 (10.0.0.2) leave") {
+			t.Errorf("Unexpected warning %s", warnings[0].Detail())
+		}
+		if threshold >= 0 && (len(searches) != 2 || searches[0].ScopeName != "acme") {
+			t.Errorf("Expected both queries to be searched within the parent scope, got %+v", searches)
+		}
+	}
+}
+
+func TestFilterQueryChangesReplaceAndPreviewMembership(t *testing.T) {
+	var searches []InventorySearchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case ScopesAPIV1BasePath:
+			fmt.Fprint(w, `[{"id": "1", "name": "acme"}, {"id": "2", "name": "acme:web", "parent_app_scope_id": "1", "root_app_scope_id": "1"}]`)
+		case InventoryAPIV1BasePath + "/search":
+			var search InventorySearchRequest
+			json.NewDecoder(r.Body).Decode(&search)
+			searches = append(searches, search)
+			if strings.Contains(string(search.Filter), "10.0.0.0/8") {
+				fmt.Fprint(w, `{"results": [{"ip": "10.0.0.1"}, {"ip": "10.0.0.2"}]}`)
+			} else {
+				fmt.Fprint(w, `{"results": [{"ip": "10.0.0.1"}]}`)
+			}
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = nil
+	client.Config.MembershipWarningThreshold = 0
+	r := &filterResource{client: client, configured: true}
+	state := frameworkResourceValue(t, r, map[string]tftypes.Value{
+		"id":           tftypes.NewValue(tftypes.String, "1234"),
+		"name":         tftypes.NewValue(tftypes.String, "web"),
+		"app_scope_id": tftypes.NewValue(tftypes.String, "2"),
+		"query_json":   tftypes.NewValue(tftypes.String, `{"field":"ip","type":"eq","value":"10.0.0.1"}`),
+		"primary":      tftypes.NewValue(tftypes.Bool, false),
+		"public":       tftypes.NewValue(tftypes.Bool, false),
+	})
+	for query, replaced := range map[string]bool{
+		`{"type": "eq", "field": "ip", "value": "10.0.0.1"}`:       false,
+		`{"type": "subnet", "field": "ip", "value": "10.0.0.0/8"}`: true,
+	} {
+		searches = nil
+		config := frameworkResourceValue(t, r, map[string]tftypes.Value{
+			"name":         tftypes.NewValue(tftypes.String, "web"),
+			"app_scope_id": tftypes.NewValue(tftypes.String, "2"),
+			"query_json":   tftypes.NewValue(tftypes.String, query),
+			"primary":      tftypes.NewValue(tftypes.Bool, false),
+			"public":       tftypes.NewValue(tftypes.Bool, false),
+		})
+		resp := modifyFrameworkPlan(t, r, state, config, config)
+		if resp.Diagnostics.HasError() || (len(resp.RequiresReplace) > 0) != replaced {
+			t.Fatalf("Expected query %s to be replaced %t, got %v %v", query, replaced, resp.RequiresReplace, resp.Diagnostics)
+		}
+		if replaced && (len(resp.Diagnostics.Warnings()) != 1 || len(searches) != 2 || searches[0].ScopeName != "acme") {
+			t.Errorf("Expected the members to be previewed within the root scope, got %v %+v", resp.Diagnostics, searches)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package secureworkload
 
 import (
-	"fmt"
+	"context"
+	"log"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	// client "github.com/secureworkload-exchange/terraform-go-sdk"
 )
@@ -18,14 +20,23 @@ func resourceSecureWorkloadPort() *schema.Resource {
 			"	 policy_id = secureworkload_policies.policy1.id\n" +
 			"    start_port = 80 \n" +
 			"    end_port = 80 \n" +
-			"    proto = 6 \n" +
+			"    proto = \"tcp\" \n" +
 			"}\n" +
 			"```\n" +
-			"**Note:** If creating multiple resources for ports during a single `terraform apply`, you may have to use `depends_on` to chain the resources so that terraform creates it in the same order that you intended.\n",
-		Create: resourceSecureWorkloadPortCreate,
-		Update: nil,
-		Read:   resourceSecureWorkloadPortRead,
-		Delete: resourceSecureWorkloadPortDelete,
+			"**Note:** If creating multiple resources for ports during a single `terraform apply`, you may have to use `depends_on` to chain the resources so that terraform creates it in the same order that you intended.\n" +
+			"\n" +
+			"## Import\n" +
+			"Ports can be imported using the policy ID and the port ID separated by a slash, `<policy_id>/<port_id>`:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_port.port1 5f3d6a0b497d4f0c9d1e2f3a/5f3d6f4c497d4f3b0a1b2c3d\n" +
+			"```\n",
+		CustomizeDiff: customizePortRangeDiff("start_port", "end_port"),
+		CreateContext: resourceSecureWorkloadPortCreate,
+		ReadContext:   resourceSecureWorkloadPortRead,
+		DeleteContext: resourceSecureWorkloadPortDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateWithParent("policy_id"),
+		},
 
 		SchemaVersion: 1,
 
@@ -49,22 +60,26 @@ func resourceSecureWorkloadPort() *schema.Resource {
 				Description: "(optional) Short string about this proto and port",
 			},
 			"start_port": {
-				Type:        schema.TypeInt,
-				Required:    true,
-				ForceNew:    true,
-				Description: "Start port of the range.",
+				Type:         schema.TypeInt,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validatePort,
+				Description:  "Start port of the range.",
 			},
 			"end_port": {
-				Type:        schema.TypeInt,
-				Required:    true,
-				ForceNew:    true,
-				Description: "End port of the range.",
+				Type:         schema.TypeInt,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validatePort,
+				Description:  "End port of the range.",
 			},
 			"proto": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				ForceNew:    true,
-				Description: "Protocol Integer value (NULL means all protocols)",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				ValidateFunc:     validateProtocol,
+				DiffSuppressFunc: suppressEquivalentProtocol,
+				Description:      "Protocol name (any, icmp, tcp, udp or icmpv6) or number. Empty or any means all protocols.",
 			},
 		},
 	}
@@ -72,23 +87,27 @@ func resourceSecureWorkloadPort() *schema.Resource {
 
 var requiredCreatePortParams = []string{"policy_id", "start_port", "end_port"}
 
-func resourceSecureWorkloadPortCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadPortCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	for _, param := range requiredCreatePortParams {
 		if d.Get(param) == "" {
-			return fmt.Errorf("%s is required but was not provided", param)
+			return diag.Errorf("%s is required but was not provided", param)
 		}
 	}
+	protocol, err := protocolNumber(d.Get("proto").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	createPortParams := CreatePortRequest{
 		StartPort:   d.Get("start_port").(int),
 		EndPort:     d.Get("end_port").(int),
 		Version:     d.Get("version").(string),
 		Description: d.Get("description").(string),
-		Proto:       d.Get("proto").(int),
+		Proto:       protocol,
 	}
 	port, err := client.CreatePort(createPortParams, d.Get("policy_id").(string))
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.SetId(port.Id)
 	return nil
@@ -108,22 +127,28 @@ func resourceSecureWorkloadPortCreate(d *schema.ResourceData, meta interface{})
 // 	return nil
 // }
 
-func resourceSecureWorkloadPortRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadPortRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	policy, err := client.DescribePolicy(d.Get("policy_id").(string))
 	if err != nil {
-		return err
+		return diag.FromErr(removeIfNotFound(d, err))
+	}
+	for _, l4Param := range policy.L4Params {
+		if l4Param.Id != d.Id() {
+			continue
+		}
+		d.Set("start_port", l4Param.Port[0])
+		d.Set("end_port", l4Param.Port[1])
+		d.Set("description", l4Param.Description)
+		d.Set("proto", protocolToTerraform(d.Get("proto").(string), l4Param.Proto))
+		return nil
 	}
-	d.Set("consumer_filter_id", policy.ConsumerId)
-	d.Set("provider_filter_id", policy.ProviderId)
-	d.Set("version", policy.Version)
-	d.Set("rank", policy.Rank)
-	d.Set("policy_action", policy.Action)
-	d.Set("priority", policy.Priority)
+	log.Printf("[WARN] Port %s no longer exists on policy %s, removing it from state", d.Id(), policy.Id)
+	d.SetId("")
 	return nil
 }
 
-func resourceSecureWorkloadPortDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	return client.DeletePort(d.Get("policy_id").(string), d.Id())
+func resourceSecureWorkloadPortDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	return diag.FromErr(client.DeletePort(d.Get("policy_id").(string), d.Id()))
 }
@@ -0,0 +1,407 @@
+package secureworkload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	datasourceschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// Number of levels of filter blocks that can be nested in a query block.
+	maxQueryDepth = 4
+	// Prefix of the fields matching user annotations.
+	annotationFieldPrefix = "user_"
+)
+
+var (
+	// Operators of query blocks, and, or and not combine the nested
+	// filter blocks, the others match the value of a field.
+	queryOperators = []string{"and", "or", "not", "eq", "contains", "regex", "subnet", "in", "gt", "gte", "lt", "lte", "range"}
+	// Types of the fields whose values are checked and converted,
+	// the values of other fields are sent as strings.
+	queryFieldTypes = map[string]string{
+		"ip":              "ip",
+		"address":         "ip",
+		"vrf_id":          "number",
+		"host_tags_cvss":  "number",
+		"host_tags_cvss3": "number",
+	}
+)
+
+// scopeQueryFromTerraform builds the query of a query block, checking
+// the arity of its operators and the values of typed fields.
+// path locates the block in errors.
+func scopeQueryFromTerraform(tf terraformObject, path string) (ScopeQuery, error) {
+	query := ScopeQuery{
+		Type:  tf["type"].(string),
+		Field: tf["field"].(string),
+	}
+	if annotation := tf["annotation"].(string); annotation != "" {
+		if query.Field != "" {
+			return query, fmt.Errorf("%s: only one of field or annotation can be specified", path)
+		}
+		query.Field = annotationFieldPrefix + annotation
+	}
+	value := tf["value"].(string)
+	from, to := tf["from"].(string), tf["to"].(string)
+	var values []string
+	for _, tfValue := range tf["values"].([]interface{}) {
+		tfValue, _ := tfValue.(string)
+		values = append(values, tfValue)
+	}
+	var tfFilters []interface{}
+	if value, ok := tf["filter"]; ok {
+		tfFilters = value.([]interface{})
+	}
+	switch query.Type {
+	case "and", "or", "not":
+		if query.Field != "" || value != "" || len(values) > 0 || from != "" || to != "" {
+			return query, fmt.Errorf("%s: %s queries combine their filter blocks and can't have a field, annotation, value, values, from or to", path, query.Type)
+		}
+		if query.Type == "not" && len(tfFilters) != 1 {
+			return query, fmt.Errorf("%s: not queries must have exactly one filter block, got %d", path, len(tfFilters))
+		}
+		if len(tfFilters) == 0 {
+			if _, ok := tf["filter"]; !ok {
+				return query, fmt.Errorf("%s: %s queries can't be nested more than %d levels deep, use query_json instead", path, query.Type, maxQueryDepth)
+			}
+			return query, fmt.Errorf("%s: %s queries must have at least one filter block", path, query.Type)
+		}
+		for i, tfFilter := range tfFilters {
+			if tfFilter == nil {
+				return query, fmt.Errorf("%s.filter.%d: filter blocks must have a type", path, i)
+			}
+			filter, err := scopeQueryFromTerraform(tfFilter.(terraformObject), fmt.Sprintf("%s.filter.%d", path, i))
+			if err != nil {
+				return query, err
+			}
+			// The API expects the query negated by not queries in filter
+			if query.Type == "not" {
+				query.Filter = &filter
+				continue
+			}
+			query.Filters = append(query.Filters, filter)
+		}
+		return query, nil
+	}
+	if len(tfFilters) > 0 {
+		return query, fmt.Errorf("%s: %s queries match a field and can't have filter blocks", path, query.Type)
+	}
+	if query.Field == "" {
+		return query, fmt.Errorf("%s: %s queries must have a field or annotation", path, query.Type)
+	}
+	fieldType := queryFieldTypes[query.Field]
+	switch query.Type {
+	case "in":
+		if value != "" || from != "" || to != "" {
+			return query, fmt.Errorf("%s: in queries match values and can't have a value, from or to", path)
+		}
+		if len(values) == 0 {
+			return query, fmt.Errorf("%s: in queries must have at least one of values", path)
+		}
+		for _, value := range values {
+			converted, err := queryValue(query.Field, fieldType, value)
+			if err != nil {
+				return query, fmt.Errorf("%s: %w", path, err)
+			}
+			query.Values = append(query.Values, converted)
+		}
+	case "range":
+		if value != "" || len(values) > 0 {
+			return query, fmt.Errorf("%s: range queries match from and to and can't have a value or values", path)
+		}
+		if from == "" || to == "" {
+			return query, fmt.Errorf("%s: range queries must have both from and to", path)
+		}
+		if fieldType == "" {
+			fieldType = "number"
+		}
+		var err error
+		if query.From, err = queryValue(query.Field, fieldType, from); err != nil {
+			return query, fmt.Errorf("%s: %w", path, err)
+		}
+		if query.To, err = queryValue(query.Field, fieldType, to); err != nil {
+			return query, fmt.Errorf("%s: %w", path, err)
+		}
+		if !rangeInOrder(fieldType, from, to) {
+			return query, fmt.Errorf("%s: from must not be greater than to, got %s and %s", path, from, to)
+		}
+	default:
+		if len(values) > 0 || from != "" || to != "" {
+			return query, fmt.Errorf("%s: %s queries match a value and can't have values, from or to", path, query.Type)
+		}
+		if value == "" {
+			return query, fmt.Errorf("%s: %s queries must have a value", path, query.Type)
+		}
+		var err error
+		switch query.Type {
+		case "subnet":
+			if fieldType != "" && fieldType != "ip" {
+				return query, fmt.Errorf("%s: subnet queries can only match addresses, %s is a %s field", path, query.Field, fieldType)
+			}
+			if _, _, cidrErr := net.ParseCIDR(value); cidrErr != nil && net.ParseIP(value) == nil {
+				return query, fmt.Errorf("%s: subnet queries must match a subnet like 10.0.0.0/8, got %q", path, value)
+			}
+			query.Value = value
+		case "gt", "gte", "lt", "lte":
+			if fieldType == "ip" {
+				return query, fmt.Errorf("%s: %s queries can only compare numbers, %s is an address field", path, query.Type, query.Field)
+			}
+			query.Value, err = queryValue(query.Field, "number", value)
+		case "regex":
+			if _, regexErr := regexp.Compile(value); regexErr != nil {
+				return query, fmt.Errorf("%s: invalid regular expression %q: %s", path, value, regexErr)
+			}
+			query.Value = value
+		case "contains":
+			query.Value = value
+		default:
+			query.Value, err = queryValue(query.Field, fieldType, value)
+		}
+		if err != nil {
+			return query, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return query, nil
+}
+
+// queryValue converts a value of a query block to
+// the JSON value expected for the type of the field.
+func queryValue(field string, fieldType string, value string) (interface{}, error) {
+	switch fieldType {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("%s must be matched against a number, got %q", field, value)
+		}
+		return json.Number(value), nil
+	case "ip":
+		if net.ParseIP(value) == nil {
+			return nil, fmt.Errorf("%s must be matched against an IP address, got %q", field, value)
+		}
+	}
+	return value, nil
+}
+
+// rangeInOrder reports whether from is not greater than to,
+// for bounds that have been checked by queryValue.
+func rangeInOrder(fieldType string, from string, to string) bool {
+	if fieldType == "ip" {
+		fromIP, toIP := net.ParseIP(from), net.ParseIP(to)
+		if (fromIP.To4() == nil) != (toIP.To4() == nil) {
+			return false
+		}
+		return string(fromIP.To16()) <= string(toIP.To16())
+	}
+	fromNumber, _ := strconv.ParseFloat(from, 64)
+	toNumber, _ := strconv.ParseFloat(to, 64)
+	return fromNumber <= toNumber
+}
+
+// scopeQueryToTerraform converts a query to a query block, returning
+// false when it is nested too deep to be represented as one.
+func scopeQueryToTerraform(query ScopeQuery, depth int) (terraformObject, bool) {
+	values := []interface{}{}
+	for _, value := range query.Values {
+		values = append(values, queryValueToTerraform(value))
+	}
+	tf := terraformObject{
+		"type":       query.Type,
+		"field":      query.Field,
+		"annotation": "",
+		"value":      queryValueToTerraform(query.Value),
+		"values":     values,
+		"from":       queryValueToTerraform(query.From),
+		"to":         queryValueToTerraform(query.To),
+	}
+	children := query.Filters
+	if query.Filter != nil {
+		children = append([]ScopeQuery{*query.Filter}, children...)
+	}
+	if len(children) > 0 && depth == 0 {
+		return nil, false
+	}
+	if depth > 0 {
+		filters := []interface{}{}
+		for _, filter := range children {
+			tfFilter, ok := scopeQueryToTerraform(filter, depth-1)
+			if !ok {
+				return nil, false
+			}
+			filters = append(filters, tfFilter)
+		}
+		tf["filter"] = filters
+	}
+	return tf, true
+}
+
+func queryValueToTerraform(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// frameworkQueryBlock returns the schema of a query block, which
+// builds a ScopeQuery out of nested filter blocks.
+func frameworkQueryBlock(description string) schema.ListNestedBlock {
+	return schema.ListNestedBlock{
+		Description:  description,
+		NestedObject: frameworkQueryObject(maxQueryDepth),
+		Validators: []validator.List{
+			listSizeAtMostValidator{max: 1},
+		},
+	}
+}
+
+// frameworkQueryObject returns the schema of a query node,
+// nesting filter blocks up to depth levels below it.
+func frameworkQueryObject(depth int) schema.NestedBlockObject {
+	object := schema.NestedBlockObject{
+		Attributes: map[string]schema.Attribute{
+			"type": schema.StringAttribute{
+				Required:    true,
+				Validators:  []validator.String{stringOneOfValidator{values: queryOperators}},
+				Description: fmt.Sprintf("Operator of the query, one of %s.", strings.Join(queryOperators, ", ")),
+			},
+			"field": schema.StringAttribute{
+				Optional:    true,
+				Description: "Field matched by the operator, for example ip.",
+			},
+			"annotation": schema.StringAttribute{
+				Optional:    true,
+				Description: "Annotation key matched by the operator, a shorthand for field = \"user_<annotation>\".",
+			},
+			"value": schema.StringAttribute{
+				Optional:    true,
+				Description: "Value matched by the eq, contains, regex, subnet, gt, gte, lt and lte operators. Values of numeric fields and of comparisons are sent as numbers.",
+			},
+			"values": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Values matched by the in operator.",
+			},
+			"from": schema.StringAttribute{
+				Optional:    true,
+				Description: "Inclusive lower bound of the range operator.",
+			},
+			"to": schema.StringAttribute{
+				Optional:    true,
+				Description: "Inclusive upper bound of the range operator.",
+			},
+		},
+	}
+	if depth > 0 {
+		object.Blocks = map[string]schema.Block{
+			"filter": schema.ListNestedBlock{
+				Description:  "Queries combined by the and, or and not operators.",
+				NestedObject: frameworkQueryObject(depth - 1),
+			},
+		}
+	}
+	return object
+}
+
+// frameworkDataSourceQueryBlock returns the query block of framework
+// data sources, see frameworkQueryBlock.
+func frameworkDataSourceQueryBlock(description string) datasourceschema.ListNestedBlock {
+	return datasourceschema.ListNestedBlock{
+		Description:  description,
+		NestedObject: frameworkDataSourceQueryObject(maxQueryDepth),
+		Validators: []validator.List{
+			listSizeAtMostValidator{max: 1},
+		},
+	}
+}
+
+// frameworkDataSourceQueryObject returns the schema of a query node of
+// data sources, see frameworkQueryObject.
+func frameworkDataSourceQueryObject(depth int) datasourceschema.NestedBlockObject {
+	resourceObject := frameworkQueryObject(0)
+	object := datasourceschema.NestedBlockObject{
+		Attributes: map[string]datasourceschema.Attribute{},
+	}
+	for name, attribute := range resourceObject.Attributes {
+		switch attribute := attribute.(type) {
+		case schema.StringAttribute:
+			object.Attributes[name] = datasourceschema.StringAttribute{
+				Required:    attribute.Required,
+				Optional:    attribute.Optional,
+				Validators:  attribute.Validators,
+				Description: attribute.Description,
+			}
+		case schema.ListAttribute:
+			object.Attributes[name] = datasourceschema.ListAttribute{
+				Optional:    attribute.Optional,
+				ElementType: attribute.ElementType,
+				Description: attribute.Description,
+			}
+		}
+	}
+	if depth > 0 {
+		object.Blocks = map[string]datasourceschema.Block{
+			"filter": datasourceschema.ListNestedBlock{
+				Description:  "Queries combined by the and, or and not operators.",
+				NestedObject: frameworkDataSourceQueryObject(depth - 1),
+			},
+		}
+	}
+	return object
+}
+
+// scopeQueryFromFramework builds the query of the query block under
+// blockKey, see scopeQueryFromTerraform. It returns false when the
+// block is not configured or not known yet.
+func scopeQueryFromFramework(tfQuery types.List, blockKey string) (ScopeQuery, bool, error) {
+	if tfQuery.IsNull() || !frameworkValueKnown(tfQuery) || len(tfQuery.Elements()) == 0 {
+		return ScopeQuery{}, false, nil
+	}
+	query, err := scopeQueryFromTerraform(frameworkToTerraform(tfQuery.Elements()[0]).(terraformObject), blockKey+".0")
+	return query, true, err
+}
+
+// queryJSONFromFramework returns the JSON of the query built by the query
+// block under blockKey, see scopeQueryFromFramework.
+func queryJSONFromFramework(tfQuery types.List, blockKey string) (string, bool, error) {
+	query, ok, err := scopeQueryFromFramework(tfQuery, blockKey)
+	if !ok || err != nil {
+		return "", ok, err
+	}
+	encoded, err := json.Marshal(query)
+	return string(encoded), true, err
+}
+
+// scopeQueryToFramework refreshes the query block under blockKey from the
+// query returned by the API. The query block is only
+// refreshed when it is used, and kept as is while it builds the same query.
+func scopeQueryToFramework(ctx context.Context, prior types.List, query ScopeQuery, blockKey string, jsonKey string) (types.List, error) {
+	if prior.IsNull() || prior.IsUnknown() || len(prior.Elements()) == 0 {
+		return prior, nil
+	}
+	if current, ok, err := scopeQueryFromFramework(prior, blockKey); ok && err == nil {
+		currentJSON, _ := json.Marshal(current)
+		queryJSON, _ := json.Marshal(query)
+		if queryJSONEqual(string(currentJSON), string(queryJSON)) {
+			return prior, nil
+		}
+	}
+	tf, ok := scopeQueryToTerraform(query, maxQueryDepth)
+	if !ok {
+		return prior, fmt.Errorf("the query is nested more than %d levels deep and can't be represented by the %s block, use %s instead", maxQueryDepth, blockKey, jsonKey)
+	}
+	return terraformToFramework([]interface{}{tf}, prior.Type(ctx)).(types.List), nil
+}
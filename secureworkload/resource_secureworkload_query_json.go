@@ -0,0 +1,186 @@
+package secureworkload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// normalizeQueryJSON returns the canonical encoding of a JSON query, the
+// one stored in state for all the attributes holding queries:
+//   - keys are sorted and insignificant whitespace is removed,
+//   - the filters combined by and and or queries are sorted, as their
+//     order does not change the query,
+//   - values are converted to the type the API stores them as, numbers
+//     for numeric fields and comparisons, strings for addresses and
+//     annotations, as the API echoes them back that way.
+func normalizeQueryJSON(raw string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var query interface{}
+	if err := decoder.Decode(&query); err != nil {
+		return "", err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return "", fmt.Errorf("unexpected data after the JSON document")
+	}
+	encoded, err := json.Marshal(normalizeQuery(query))
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// normalizeQuery normalizes a decoded JSON query in place. not queries
+// negating the single query of filters negate it in filter instead,
+// as the API expects.
+func normalizeQuery(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		queryType, _ := value["type"].(string)
+		field, _ := value["field"].(string)
+		if filters, ok := value["filters"].([]interface{}); ok && queryType == "not" && len(filters) == 1 && value["filter"] == nil {
+			value["filter"] = filters[0]
+			delete(value, "filters")
+		}
+		for key, child := range value {
+			switch key {
+			case "value", "from", "to":
+				value[key] = coerceQueryValue(queryType, field, child)
+			case "values":
+				values, ok := child.([]interface{})
+				if !ok {
+					break
+				}
+				for i := range values {
+					values[i] = coerceQueryValue(queryType, field, values[i])
+				}
+			default:
+				value[key] = normalizeQuery(child)
+			}
+		}
+		if filters, ok := value["filters"].([]interface{}); ok && (queryType == "and" || queryType == "or") {
+			sortQueries(filters)
+		}
+		return value
+	case []interface{}:
+		for i := range value {
+			value[i] = normalizeQuery(value[i])
+		}
+		return value
+	case json.Number:
+		return canonicalNumber(value)
+	}
+	return value
+}
+
+// coerceQueryValue converts a value matched by a query
+// to the type the API stores it as for the field.
+func coerceQueryValue(queryType string, field string, value interface{}) interface{} {
+	fieldType := queryFieldTypes[field]
+	numeric := fieldType == "number"
+	switch queryType {
+	case "gt", "gte", "lt", "lte":
+		numeric = true
+	case "range":
+		numeric = fieldType != "ip"
+	}
+	switch value := value.(type) {
+	case string:
+		if _, err := strconv.ParseFloat(value, 64); numeric && err == nil {
+			return canonicalNumber(json.Number(value))
+		}
+		return value
+	case json.Number:
+		number := canonicalNumber(value)
+		if !numeric && (fieldType == "ip" || strings.HasPrefix(field, annotationFieldPrefix)) {
+			return number.String()
+		}
+		return number
+	}
+	return normalizeQuery(value)
+}
+
+// canonicalNumber formats numbers which are equal the same way,
+// e.g. 2, 2.0 and 2e0 all become 2.
+func canonicalNumber(number json.Number) json.Number {
+	if integer, err := number.Int64(); err == nil {
+		return json.Number(strconv.FormatInt(integer, 10))
+	}
+	if float, err := number.Float64(); err == nil {
+		return json.Number(strconv.FormatFloat(float, 'f', -1, 64))
+	}
+	return number
+}
+
+// sortQueries sorts normalized queries by their encoding.
+func sortQueries(queries []interface{}) {
+	encoded := make([][]byte, len(queries))
+	for i, query := range queries {
+		encoded[i], _ = json.Marshal(query)
+	}
+	sort.Sort(queriesByEncoding{queries, encoded})
+}
+
+type queriesByEncoding struct {
+	queries []interface{}
+	encoded [][]byte
+}
+
+func (q queriesByEncoding) Len() int {
+	return len(q.queries)
+}
+
+func (q queriesByEncoding) Less(i, j int) bool {
+	return bytes.Compare(q.encoded[i], q.encoded[j]) < 0
+}
+
+func (q queriesByEncoding) Swap(i, j int) {
+	q.queries[i], q.queries[j] = q.queries[j], q.queries[i]
+	q.encoded[i], q.encoded[j] = q.encoded[j], q.encoded[i]
+}
+
+// queryJSONEqual reports whether two JSON queries are the same once
+// normalized, see normalizeQueryJSON.
+func queryJSONEqual(a, b string) bool {
+	aNormalized, err := normalizeQueryJSON(a)
+	if err != nil {
+		return false
+	}
+	bNormalized, err := normalizeQueryJSON(b)
+	if err != nil {
+		return false
+	}
+	return aNormalized == bNormalized
+}
+
+// suppressEquivalentQueryJSON is the DiffSuppressFunc of the attributes
+// holding JSON queries, suppressing the diff of equivalent queries.
+func suppressEquivalentQueryJSON(k, old, new string, d *schema.ResourceData) bool {
+	return queryJSONEqual(old, new)
+}
+
+// normalizeQueryJSONState is the StateFunc of the attributes holding JSON
+// queries, storing them normalized. Invalid documents are stored as is,
+// for validation to report them.
+func normalizeQueryJSONState(val interface{}) string {
+	normalized, err := normalizeQueryJSON(val.(string))
+	if err != nil {
+		return val.(string)
+	}
+	return normalized
+}
+
+func validateJSON(val interface{}, key string) (warns []string, errs []error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(val.(string)), &value); err != nil {
+		errs = append(errs, fmt.Errorf("%s must be a JSON document: %s", key, err))
+	}
+	return
+}
@@ -0,0 +1,102 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"testing"
+)
+
+func TestQueryJSONEqual(t *testing.T) {
+	for _, test := range []struct {
+		a, b  string
+		equal bool
+	}{
+		{
+			a:     `{"type": "eq", "field": "ip", "value": "10.0.0.1"}`,
+			b:     "{\n  \"value\": \"10.0.0.1\",\n  \"field\": \"ip\",\n  \"type\": \"eq\"\n}",
+			equal: true,
+		},
+		{
+			a:     `{"type": "or", "filters": [{"type": "eq", "field": "ip", "value": "10.0.0.1"}, {"type": "eq", "field": "ip", "value": "10.0.0.2"}]}`,
+			b:     `{"type": "or", "filters": [{"type": "eq", "field": "ip", "value": "10.0.0.2"}, {"type": "eq", "field": "ip", "value": "10.0.0.1"}]}`,
+			equal: true,
+		},
+		{
+			a:     `{"type": "not", "filters": [{"type": "and", "filters": [{"type": "subnet", "field": "ip", "value": "10.0.0.0/8"}, {"type": "eq", "field": "vrf_id", "value": 1}]}]}`,
+			b:     `{"type": "not", "filters": [{"type": "and", "filters": [{"type": "eq", "field": "vrf_id", "value": 1}, {"type": "subnet", "field": "ip", "value": "10.0.0.0/8"}]}]}`,
+			equal: true,
+		},
+		{
+			a:     `{"type": "eq", "field": "vrf_id", "value": "700056"}`,
+			b:     `{"type": "eq", "field": "vrf_id", "value": 700056}`,
+			equal: true,
+		},
+		{
+			a:     `{"type": "gt", "field": "user_priority", "value": "2"}`,
+			b:     `{"type": "gt", "field": "user_priority", "value": 2.0}`,
+			equal: true,
+		},
+		{
+			a:     `{"type": "eq", "field": "user_release", "value": "2"}`,
+			b:     `{"type": "eq", "field": "user_release", "value": 2}`,
+			equal: true,
+		},
+		{
+			a:     `{"type": "range", "field": "host_tags_cvss3", "from": "1", "to": "7.5"}`,
+			b:     `{"type": "range", "field": "host_tags_cvss3", "from": 1, "to": 7.50}`,
+			equal: true,
+		},
+		{
+			a:     `{"type": "in", "field": "vrf_id", "values": ["1", "2"]}`,
+			b:     `{"type": "in", "field": "vrf_id", "values": [1, 2]}`,
+			equal: true,
+		},
+		{
+			a:     `{"type": "in", "field": "vrf_id", "values": [1, 2]}`,
+			b:     `{"type": "in", "field": "vrf_id", "values": [2, 1]}`,
+			equal: false,
+		},
+		{
+			a:     `{"type": "eq", "field": "ip", "value": "10.0.0.1"}`,
+			b:     `{"type": "eq", "field": "ip", "value": "10.0.0.2"}`,
+			equal: false,
+		},
+		{
+			a:     `{"type": "eq", "field": "os", "value": "2"}`,
+			b:     `{"type": "eq", "field": "os", "value": 2}`,
+			equal: false,
+		},
+		{
+			a:     `{"type": "eq", "field": "ip", "value": "10.0.0.1"}`,
+			b:     `{"type": "eq", "field": "ip", "value": "10.0.0.1"} {}`,
+			equal: false,
+		},
+	} {
+		if equal := queryJSONEqual(test.a, test.b); equal != test.equal {
+			t.Errorf("Expected queryJSONEqual(%s, %s) to be %t", test.a, test.b, test.equal)
+		}
+	}
+}
+
+func TestNormalizeQueryJSONIsCanonical(t *testing.T) {
+	normalized, err := normalizeQueryJSON(`{
+		"type": "and",
+		"filters": [
+			{"value": 2.0, "type": "gt", "field": "host_tags_cvss"},
+			{"field": "ip", "type": "subnet", "value": "10.0.0.0/8"}
+		]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"filters":[{"field":"host_tags_cvss","type":"gt","value":2},{"field":"ip","type":"subnet","value":"10.0.0.0/8"}],"type":"and"}`
+	if normalized != expected {
+		t.Errorf("Expected %s, got %s", expected, normalized)
+	}
+	if again, _ := normalizeQueryJSON(normalized); again != normalized {
+		t.Errorf("Expected normalizing twice to be a no-op, got %s", again)
+	}
+	if state := normalizeQueryJSONState("not json"); state != "not json" {
+		t.Errorf("Expected invalid documents to be stored as is, got %q", state)
+	}
+}
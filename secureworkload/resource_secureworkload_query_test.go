@@ -0,0 +1,213 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+)
+
+// queryFromConfig builds the query of a query block configuration.
+func queryFromConfig(t *testing.T, tfQuery map[string]interface{}) (ScopeQuery, error) {
+	tfQueries := terraformToFramework([]interface{}{tfQuery}, frameworkQueryBlock("").Type()).(types.List)
+	query, _, err := scopeQueryFromFramework(tfQueries, "query")
+	return query, err
+}
+
+func TestScopeQueryFromTerraformBuildsNestedQuery(t *testing.T) {
+	query, err := queryFromConfig(t, map[string]interface{}{
+		"type": "or",
+		"filter": []interface{}{
+			map[string]interface{}{
+				"type": "and",
+				"filter": []interface{}{
+					map[string]interface{}{"type": "subnet", "field": "ip", "value": "10.0.0.0/8"},
+					map[string]interface{}{"type": "in", "annotation": "environment", "values": []interface{}{"production", "staging"}},
+				},
+			},
+			map[string]interface{}{"type": "gt", "field": "host_tags_cvss3", "value": "7.5"},
+			map[string]interface{}{"type": "range", "field": "vrf_id", "from": "1", "to": "10"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error building the query, got %s", err)
+	}
+	encoded, _ := json.Marshal(query)
+	expected := `{"type": "or", "filters": [
+		{"type": "and", "filters": [
+			{"type": "subnet", "field": "ip", "value": "10.0.0.0/8"},
+			{"type": "in", "field": "user_environment", "values": ["production", "staging"]}
+		]},
+		{"type": "gt", "field": "host_tags_cvss3", "value": 7.5},
+		{"type": "range", "field": "vrf_id", "from": 1, "to": 10}
+	]}`
+	if !queryJSONEqual(string(encoded), expected) {
+		t.Errorf("Expected query %s, got %s", expected, encoded)
+	}
+}
+
+func TestScopeQueryFromTerraformNegatesFilter(t *testing.T) {
+	query, err := queryFromConfig(t, map[string]interface{}{
+		"type": "not",
+		"filter": []interface{}{
+			map[string]interface{}{"type": "subnet", "field": "ip", "value": "10.0.0.0/8"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error building the query, got %s", err)
+	}
+	encoded, _ := json.Marshal(query)
+	expected := `{"type":"not","filter":{"type":"subnet","field":"ip","value":"10.0.0.0/8"}}`
+	if string(encoded) != expected {
+		t.Errorf("Expected query %s, got %s", expected, encoded)
+	}
+	tf, ok := scopeQueryToTerraform(query, maxQueryDepth)
+	if !ok {
+		t.Fatal("Expected the query to be represented as a block")
+	}
+	if filters := tf["filter"].([]interface{}); len(filters) != 1 || filters[0].(terraformObject)["value"] != "10.0.0.0/8" {
+		t.Errorf("Expected the negated query as the filter block, got %v", tf["filter"])
+	}
+	// Queries written with the negated query in filters are equivalent
+	if !queryJSONEqual(string(encoded), `{"type": "not", "filters": [{"type": "subnet", "field": "ip", "value": "10.0.0.0/8"}]}`) {
+		t.Errorf("Expected not queries negating filters to be equivalent to %s", encoded)
+	}
+}
+
+func TestScopeQueryFromTerraformRejectsInvalidQueries(t *testing.T) {
+	for _, test := range []struct {
+		query map[string]interface{}
+		error string
+	}{
+		{
+			query: map[string]interface{}{"type": "and"},
+			error: "query.0: and queries must have at least one filter block",
+		},
+		{
+			query: map[string]interface{}{"type": "or", "field": "ip", "filter": []interface{}{
+				map[string]interface{}{"type": "eq", "field": "ip", "value": "10.0.0.1"},
+			}},
+			error: "or queries combine their filter blocks",
+		},
+		{
+			query: map[string]interface{}{"type": "not", "filter": []interface{}{
+				map[string]interface{}{"type": "eq", "field": "ip", "value": "10.0.0.1"},
+				map[string]interface{}{"type": "eq", "field": "ip", "value": "10.0.0.2"},
+			}},
+			error: "not queries must have exactly one filter block, got 2",
+		},
+		{
+			query: map[string]interface{}{"type": "or", "filter": []interface{}{
+				map[string]interface{}{"type": "eq", "value": "10.0.0.1"},
+			}},
+			error: "query.0.filter.0: eq queries must have a field or annotation",
+		},
+		{
+			query: map[string]interface{}{"type": "eq", "field": "ip", "annotation": "owner", "value": "10.0.0.1"},
+			error: "only one of field or annotation can be specified",
+		},
+		{
+			query: map[string]interface{}{"type": "eq", "field": "ip", "value": "10.0.0.300"},
+			error: "ip must be matched against an IP address",
+		},
+		{
+			query: map[string]interface{}{"type": "subnet", "field": "ip", "value": "10.0.0.0/33"},
+			error: "subnet queries must match a subnet",
+		},
+		{
+			query: map[string]interface{}{"type": "gt", "field": "host_tags_cvss", "value": "high"},
+			error: "host_tags_cvss must be matched against a number",
+		},
+		{
+			query: map[string]interface{}{"type": "regex", "annotation": "owner", "value": "web[0-9"},
+			error: "invalid regular expression",
+		},
+		{
+			query: map[string]interface{}{"type": "in", "field": "os"},
+			error: "in queries must have at least one of values",
+		},
+		{
+			query: map[string]interface{}{"type": "range", "field": "ip", "from": "10.0.0.9", "to": "10.0.0.1"},
+			error: "from must not be greater than to",
+		},
+	} {
+		_, err := queryFromConfig(t, test.query)
+		if err == nil || !strings.Contains(err.Error(), test.error) {
+			t.Errorf("Expected error %q building %v, got %v", test.error, test.query, err)
+		}
+	}
+}
+
+func TestScopeQueryToTerraformRoundTrips(t *testing.T) {
+	var query ScopeQuery
+	json.Unmarshal([]byte(`{"type": "and", "filters": [
+		{"type": "eq", "field": "ip", "value": "10.0.0.1"},
+		{"type": "lte", "field": "host_tags_cvss", "value": 4},
+		{"type": "in", "field": "user_environment", "values": ["production"]}
+	]}`), &query)
+	tf, ok := scopeQueryToTerraform(query, maxQueryDepth)
+	if !ok {
+		t.Fatal("Expected the query to be represented as a block")
+	}
+	roundTripped, err := queryFromConfig(t, tf)
+	if err != nil {
+		t.Fatalf("Expected no error building the query back, got %s", err)
+	}
+	queryJSON, _ := json.Marshal(query)
+	roundTrippedJSON, _ := json.Marshal(roundTripped)
+	if !queryJSONEqual(string(queryJSON), string(roundTrippedJSON)) {
+		t.Errorf("Expected query %s, got %s", queryJSON, roundTrippedJSON)
+	}
+}
+
+func TestScopeQueryToTerraformRejectsDeepQueries(t *testing.T) {
+	query := ScopeQuery{Type: "eq", Field: "ip", Value: "10.0.0.1"}
+	for i := 0; i <= maxQueryDepth; i++ {
+		query = ScopeQuery{Type: "not", Filters: []ScopeQuery{query}}
+	}
+	if _, ok := scopeQueryToTerraform(query, maxQueryDepth); ok {
+		t.Errorf("Expected queries nested more than %d levels deep not to be represented as a block", maxQueryDepth)
+	}
+}
+
+func TestFilterStateUpgradeV1MovesQueryToQueryJSON(t *testing.T) {
+	ctx := context.Background()
+	server, err := ProviderServer(ctx)
+	if err != nil {
+		t.Fatalf("Error %s creating provider server", err)
+	}
+	query := `{"type": "eq", "field": "ip", "value": "10.0.0.1"}`
+	encodedQuery, _ := json.Marshal(query)
+	resp, err := server().UpgradeResourceState(ctx, &tfprotov5.UpgradeResourceStateRequest{
+		TypeName: "secureworkload_filter",
+		Version:  1,
+		RawState: &tfprotov5.RawState{JSON: []byte(`{"id": "1234", "name": "web", "app_scope_id": "1", "primary": true, "query": ` + string(encodedQuery) + `}`)},
+	})
+	if err != nil {
+		t.Fatalf("Error %s upgrading state", err)
+	}
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("Unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+	schemaResp := &resource.SchemaResponse{}
+	NewFilterResource().Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	upgraded, err := resp.UpgradedState.Unmarshal(schemaResp.Schema.Type().TerraformType(ctx))
+	if err != nil {
+		t.Fatalf("Error %s decoding upgraded state", err)
+	}
+	var state filterResourceModel
+	if diags := (tfsdk.State{Schema: schemaResp.Schema, Raw: upgraded}).Get(ctx, &state); diags.HasError() {
+		t.Fatalf("Error decoding upgraded state: %v", diags)
+	}
+	if state.QueryJSON.ValueString() != query || len(state.Query.Elements()) != 0 || state.Id.ValueString() != "1234" ||
+		!state.Primary.ValueBool() || state.Public.ValueBool() {
+		t.Errorf("Expected the query to be moved to query_json, got %+v", state)
+	}
+}
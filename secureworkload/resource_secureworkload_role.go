@@ -1,9 +1,10 @@
 package secureworkload
 
 import (
-	"fmt"
+	"context"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	// client "github.com/secureworkload-exchange/terraform-go-sdk"
 	// secureworkload "github.com/secureworkload-exchange/terraform-go-sdk"
@@ -29,11 +30,19 @@ func resourceSecureWorkloadRole() *schema.Resource {
 			"    description = \"Demo description for role\"\n" +
 			"}\n" +
 			"```\n" +
-			"**Note:** If creating multiple resources for role during a single `terraform apply`, you may have to use `depends_on` to chain the resources so that terraform creates it in the same order that you intended.\n",
-		Create: resourceSecureWorkloadRoleCreate,
-		Update: nil,
-		Read:   resourceSecureWorkloadRoleRead,
-		Delete: resourceSecureWorkloadRoleDelete,
+			"**Note:** If creating multiple resources for role during a single `terraform apply`, you may have to use `depends_on` to chain the resources so that terraform creates it in the same order that you intended.\n" +
+			"\n" +
+			"## Import\n" +
+			"Roles can be imported using their ID:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_role.role1 5f3d52a1497d4f2b1c6d7e3f\n" +
+			"```\n",
+		CreateContext: resourceSecureWorkloadRoleCreate,
+		ReadContext:   resourceSecureWorkloadRoleRead,
+		DeleteContext: resourceSecureWorkloadRoleDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
 
 		SchemaVersion: 1,
 
@@ -68,17 +77,10 @@ func resourceSecureWorkloadRole() *schema.Resource {
 				Required:    true,
 				ForceNew:    true,
 				Description: `The type of access to grant the role to the "access_app_scope_id" scope.\n Valid values are SCOPE_READ", "SCOPE_WRITE", "EXECUTE", "ENFORCE", "SCOPE_OWNER", "DEVELOPER"`,
-				ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
-					v := strings.ToUpper(val.(string))
-					allowedValues := []string{"SCOPE_READ", "SCOPE_WRITE", "EXECUTE", "ENFORCE", "SCOPE_OWNER", "DEVELOPER"}
-					for _, allowed := range allowedValues {
-						if v == allowed {
-							return
-						}
-					}
-					errs = append(errs, fmt.Errorf("%q must be in %v, got: %q", key, allowedValues, v))
-					return
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return strings.EqualFold(old, new)
 				},
+				ValidateFunc: validateStringInSlice(ValidAbilities2, true),
 			},
 			"user_ids": {
 				Type:        schema.TypeSet,
@@ -94,9 +96,9 @@ func resourceSecureWorkloadRole() *schema.Resource {
 	}
 }
 
-func resourceSecureWorkloadRoleCreate(d *schema.ResourceData, meta interface{}) error {
+func resourceSecureWorkloadRoleCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 
-	client := meta.(Client)
+	client := meta.(Client).WithContext(ctx)
 	tfUserIds := d.Get("user_ids").(*schema.Set).List()
 	userIds := []string{}
 	for _, tfUserId := range tfUserIds {
@@ -118,24 +120,48 @@ func resourceSecureWorkloadRoleCreate(d *schema.ResourceData, meta interface{})
 
 	response, err := client.CreateScopedRoleForUsers(createScopedRoleForUsersParams)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.SetId(response.RoleId)
 	return nil
 }
 
-func resourceSecureWorkloadRoleRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadRoleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	role, err := client.GetRole(d.Id())
 	if err != nil {
-		return err
+		return diag.FromErr(removeIfNotFound(d, err))
 	}
 	d.Set("app_scope_id", role.AppScopeId)
 	d.Set("name", role.Name)
 	d.Set("description", role.Description)
+	for _, capability := range role.Capabilities {
+		if capability.Inherited {
+			continue
+		}
+		d.Set("access_app_scope_id", capability.AppScopeId)
+		d.Set("access_type", capability.Ability)
+		break
+	}
+	// Role membership is tracked on the users, so collect
+	// every user in the scope that has been assigned this role
+	users, err := client.LookupUsers(ListUsersRequest{AppScopeId: role.AppScopeId})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	userIds := []string{}
+	for _, user := range users {
+		for _, roleId := range user.RoleIds {
+			if roleId == role.Id {
+				userIds = append(userIds, user.Id)
+				break
+			}
+		}
+	}
+	d.Set("user_ids", userIds)
 	return nil
 }
-func resourceSecureWorkloadRoleDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	return client.DeleteRole(d.Id())
+func resourceSecureWorkloadRoleDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	return diag.FromErr(client.DeleteRole(d.Id()))
 }
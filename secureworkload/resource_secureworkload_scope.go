@@ -1,19 +1,113 @@
 package secureworkload
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"strings"
+	"log"
 	"time"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	// client "github.com/secureworkload-exchange/terraform-go-sdk"
-	// secureworkload "github.com/secureworkload-exchange/terraform-go-sdk"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
-var timer int
+const (
+	// Wait between two attempts to delete a scope that is still in use.
+	scopeDeletePollInterval = 60 * time.Second
+	// Time deleting a scope may take, unless set in the timeouts block.
+	defaultScopeDeleteTimeout = 20 * time.Minute
+)
+
+var (
+	_ resource.ResourceWithConfigure      = &scopeResource{}
+	_ resource.ResourceWithImportState    = &scopeResource{}
+	_ resource.ResourceWithModifyPlan     = &scopeResource{}
+	_ resource.ResourceWithUpgradeState   = &scopeResource{}
+	_ resource.ResourceWithValidateConfig = &scopeResource{}
+)
+
+// scopeResource manages scopes. It was ported from terraform-plugin-sdk/v2
+// and reads the state written there, so its schema must stay compatible.
+type scopeResource struct {
+	client     Client
+	configured bool
+}
+
+// scopeResourceModel maps the secureworkload_scope schema.
+type scopeResourceModel struct {
+	Id               types.String   `tfsdk:"id"`
+	ShortName        types.String   `tfsdk:"short_name"`
+	SubType          types.String   `tfsdk:"sub_type"`
+	Description      types.String   `tfsdk:"description"`
+	ParentAppScopeId types.String   `tfsdk:"parent_app_scope_id"`
+	PolicyPriority   types.Int64    `tfsdk:"policy_priority"`
+	Query            types.List     `tfsdk:"query"`
+	QueryJSON        queryJSONValue `tfsdk:"query_json"`
+	ShortQuery       queryJSONValue `tfsdk:"short_query"`
+	Name             types.String   `tfsdk:"name"`
+	RootAppScopeId   types.String   `tfsdk:"root_app_scope_id"`
+	VRFId            types.Int64    `tfsdk:"vrf_id"`
+	Priority         types.String   `tfsdk:"priority"`
+	ShortPriority    types.Int64    `tfsdk:"short_priority"`
+	Dirty            types.Bool     `tfsdk:"dirty"`
+	ChildAppScopeIds types.List     `tfsdk:"child_app_scope_ids"`
+	CreatedAt        types.Int64    `tfsdk:"created_at"`
+	UpdatedAt        types.Int64    `tfsdk:"updated_at"`
+	Timeouts         *timeoutsModel `tfsdk:"timeouts"`
+}
 
-func resourceSecureWorkloadScope() *schema.Resource {
-	return &schema.Resource{
+// timeoutsModel maps the timeouts block of resources that can only
+// configure how long deleting them may take.
+type timeoutsModel struct {
+	Delete types.String `tfsdk:"delete"`
+}
+
+// deleteTimeoutsBlock returns the timeouts block of resources that can only
+// configure how long deleting them may take, the counterpart of the block
+// added by terraform-plugin-sdk/v2 for schema.ResourceTimeout.
+func deleteTimeoutsBlock(defaultTimeout time.Duration) schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		Attributes: map[string]schema.Attribute{
+			"delete": schema.StringAttribute{
+				Optional:    true,
+				Validators:  []validator.String{durationValidator{}},
+				Description: fmt.Sprintf("Time deleting the resource may take, e.g. 30m. Defaults to %s.", defaultTimeout),
+			},
+		},
+	}
+}
+
+// deleteTimeout returns the delete timeout of the timeouts block, if set.
+func deleteTimeout(timeouts *timeoutsModel, defaultTimeout time.Duration) time.Duration {
+	if timeouts == nil || timeouts.Delete.IsNull() || timeouts.Delete.IsUnknown() {
+		return defaultTimeout
+	}
+	timeout, err := time.ParseDuration(timeouts.Delete.ValueString())
+	if err != nil {
+		return defaultTimeout
+	}
+	return timeout
+}
+
+// NewScopeResource returns the secureworkload_scope resource.
+func NewScopeResource() resource.Resource {
+	return &scopeResource{}
+}
+
+func (r *scopeResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_scope"
+}
+
+func (r *scopeResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
 		Description: "Resource for creating a scope in Secure Workload\n" +
 			"\n" +
 			"## Example\n" +
@@ -22,19 +116,25 @@ func resourceSecureWorkloadScope() *schema.Resource {
 			"resource \"secureworkload_scope\" \"scope\" {\n" +
 			"    short_name = \"Terraform-created-scope\"\n" +
 			"    sub_type = \"DNS_SERVERS\"\n" +
-			"    short_query = file(\"${path.module}/query_file.json\") \n" +
+			"    query_json = file(\"${path.module}/query_file.json\") \n" +
 			"	 parent_app_scope_id = data.secureworkload_scope.scope.id\n" +
 			"}\n" +
 			"\n" +
 			"resource \"secureworkload_scope\" \"scope2\" {\n" +
 			"    short_name = \"Terraform-created-scope2\"\n" +
-			"    short_query = <<EOF\n" +
-			"                { \n" +
-			"        		 \"type\":\"subnet\",\n" +
-			"        		 \"field\": \"ip\",\n" +
-			"        		 \"value\": \"10.0.1.0/24\"\n" +
-			"        		 }\n" +
-			"        	EOF\n" +
+			"    query {\n" +
+			"        type = \"and\"\n" +
+			"        filter {\n" +
+			"            type  = \"subnet\"\n" +
+			"            field = \"ip\"\n" +
+			"            value = \"10.0.1.0/24\"\n" +
+			"        }\n" +
+			"        filter {\n" +
+			"            type       = \"eq\"\n" +
+			"            annotation = \"orchestrator_system/name\"\n" +
+			"            value      = \"Random\"\n" +
+			"        }\n" +
+			"    }\n" +
 			"    sub_type = \"GENERIC\"\n" +
 			"	 parent_app_scope_id = data.secureworkload_scope.scope.id\n" +
 			"}\n" +
@@ -67,188 +167,431 @@ func resourceSecureWorkloadScope() *schema.Resource {
 			"	]\n" +
 			"  }\n" +
 			"```\n" +
-			"**Note:** If creating multiple resources for scope during a single `terraform apply`, you may have to use `depends_on` to chain the resources so that terraform creates it in the same order that you intended.\n",
-		Create: resourceSecureWorkloadScopeCreate,
-		Update: resourceSecureWorkloadScopeUpdate,
-		Read:   resourceSecureWorkloadScopeRead,
-		Delete: resourceSecureWorkloadScopeDelete,
-
-		SchemaVersion: 1,
-
-		Schema: map[string]*schema.Schema{
-			"short_name": {
-				Type:        schema.TypeString,
+			"**Note:** If creating multiple resources for scope during a single `terraform apply`, you may have to use `depends_on` to chain the resources so that terraform creates it in the same order that you intended.\n" +
+			"\n" +
+			"The `query` block is checked when planning, use `query_json` for queries nested more than 4 levels deep. " +
+			"`short_query` is deprecated in favour of `query_json`, which it is equivalent to.\n" +
+			"\n" +
+			"When `membership_warning_threshold` is set in the provider configuration, plans changing the query of a scope search the inventory " +
+			"of its parent scope with the current and the new query, and warn about the inventory items that would join or leave the scope.\n" +
+			"\n" +
+			"## Import\n" +
+			"Scopes can be imported using their ID:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_scope.scope 5ed6890c497d4f55eb5c585c\n" +
+			"```\n",
+		Version: 2,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The ID of this resource.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"short_name": schema.StringAttribute{
 				Required:    true,
-				ForceNew:    true,
 				Description: "User-specified name for the scope.",
 			},
-			"sub_type": {
-				Type:        schema.TypeString,
+			"sub_type": schema.StringAttribute{
 				Optional:    true,
-				ForceNew:    true,
 				Description: "User-specified sub type for the scope.",
+				PlanModifiers: []planmodifier.String{
+					// Unset and empty sub types are the same
+					stringplanmodifier.RequiresReplaceIf(func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+						resp.RequiresReplace = req.StateValue.ValueString() != req.PlanValue.ValueString()
+					}, "Changing the sub type creates a new scope.", "Changing the sub type creates a new scope."),
+				},
 			},
-			"description": {
-				Type:        schema.TypeString,
+			"description": schema.StringAttribute{
 				Optional:    true,
-				ForceNew:    false,
 				Description: "User-specified description of the scope.",
 			},
-			"parent_app_scope_id": {
-				Type:        schema.TypeString,
+			"parent_app_scope_id": schema.StringAttribute{
 				Required:    true,
-				ForceNew:    true,
-				Description: "What resource field to use when evaluating the scope query.",
+				Description: "ID of the parent scope. Changing the parent creates a new scope.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
-			"policy_priority": {
-				Type:        schema.TypeInt,
+			"policy_priority": schema.Int64Attribute{
 				Optional:    true,
-				ForceNew:    false,
-				Default:     nil,
 				Computed:    true,
 				Description: "Used to sort application priorities; default is last.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
-			"short_query": {
-				Type:        schema.TypeString,
+			"query_json": schema.StringAttribute{
 				Optional:    true,
-				ForceNew:    true,
+				Computed:    true,
+				CustomType:  queryJSONType{},
+				Validators:  []validator.String{jsonValidator{}},
 				Description: "JSON object representation of an inventory filter query. The query shown in the above example is 'orchestrator_system/name containes Random and Address = 10.0.1.1 or CVE Score v3 >2'.Operator can any of the following: [and, or, eq, subnet, contains, regex, gt, gte, lt, lte, in, range, ranges, not, all, none] ",
 			},
-			"name": {
-				Type:        schema.TypeString,
+			"short_query": schema.StringAttribute{
+				Optional:           true,
+				Computed:           true,
+				CustomType:         queryJSONType{},
+				Validators:         []validator.String{jsonValidator{}},
+				DeprecationMessage: "Use query_json or the query block instead.",
+				Description:        "JSON object representation of an inventory filter query, same as query_json.",
+			},
+			"name": schema.StringAttribute{
 				Computed:    true,
 				Description: "Fully qualified name of the scope. This is a fully qualified name; that is, it includes the names of parent scopes (if applicable) all the way to the root scope.",
 			},
-			"root_app_scope_id": {
-				Type:        schema.TypeString,
+			"root_app_scope_id": schema.StringAttribute{
 				Computed:    true,
 				Description: "Root scope for the secureworkload installation",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
-			"vrf_id": {
-				Type:        schema.TypeInt,
+			"vrf_id": schema.Int64Attribute{
 				Computed:    true,
 				Description: "ID of the VRF to which scope belongs.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
-			"priority": {
-				Type:     schema.TypeString,
+			"priority": schema.StringAttribute{
 				Computed: true,
 			},
-			"short_priority": {
-				Type:        schema.TypeInt,
+			"short_priority": schema.Int64Attribute{
 				Computed:    true,
 				Description: "Used to sort application priorities; default is last.",
 			},
-			"dirty": {
-				Type:        schema.TypeBool,
+			"dirty": schema.BoolAttribute{
 				Computed:    true,
 				Description: "Indicates a child or parent query has been updated and that the changes need to be committed..",
 			},
-			"child_app_scope_ids": {
-				Type:        schema.TypeList,
+			"child_app_scope_ids": schema.ListAttribute{
 				Computed:    true,
+				ElementType: types.StringType,
 				Description: "Indicates a child or parent query has been updated and that the changes need to be committed..",
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
 				},
 			},
-			"created_at": {
-				Type:        schema.TypeInt,
+			"created_at": schema.Int64Attribute{
 				Computed:    true,
 				Description: "Unix Epoch timestamp when scope was created.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
-			"updated_at": {
-				Type:        schema.TypeInt,
+			"updated_at": schema.Int64Attribute{
 				Computed:    true,
 				Description: "Unix Epoch timestamp when scope was last updated.",
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"query":    frameworkQueryBlock("Inventory filter query of the scope, built out of nested filter blocks. Conflicts with query_json and short_query."),
+			"timeouts": deleteTimeoutsBlock(defaultScopeDeleteTimeout),
+		},
 	}
 }
 
-var requiredCreateScopeParams = []string{"short_name", "parent_app_scope_id"}
+func (r *scopeResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client, r.configured = clientFromProviderData(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *scopeResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config scopeResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	conflictingAttributes(map[string]bool{
+		"query":       config.Query.IsUnknown() || len(config.Query.Elements()) > 0,
+		"query_json":  !config.QueryJSON.IsNull(),
+		"short_query": !config.ShortQuery.IsNull(),
+	}, &resp.Diagnostics)
+}
 
-func resourceSecureWorkloadScopeCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	for _, param := range requiredCreateScopeParams {
-		if d.Get(param) == "" {
-			return fmt.Errorf("%s is required but was not provided", param)
+// ModifyPlan plans the query built by the query block, or set by one of the
+// JSON attributes, as the value of both JSON attributes, and previews the
+// inventory items that join or leave existing scopes when it changes.
+func (r *scopeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan, config scopeResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	state := scopeResourceModel{QueryJSON: queryJSONNull(), ShortQuery: queryJSONNull()}
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var desired string
+	known := true
+	switch {
+	case config.Query.IsUnknown() || len(config.Query.Elements()) > 0:
+		queryJSON, ok, err := queryJSONFromFramework(config.Query, "query")
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("query"), "Invalid query", err.Error())
+			return
+		}
+		desired, known = queryJSON, ok
+	case !config.QueryJSON.IsNull():
+		desired, known = config.QueryJSON.ValueString(), !config.QueryJSON.IsUnknown()
+	case !config.ShortQuery.IsNull():
+		desired, known = config.ShortQuery.ValueString(), !config.ShortQuery.IsUnknown()
+	default:
+		// Keep the query of existing scopes
+		desired, known = state.QueryJSON.ValueString(), !state.QueryJSON.IsNull()
+	}
+	for _, planned := range []struct {
+		configured queryJSONValue
+		prior      queryJSONValue
+		value      *queryJSONValue
+	}{
+		{config.QueryJSON, state.QueryJSON, &plan.QueryJSON},
+		{config.ShortQuery, state.ShortQuery, &plan.ShortQuery},
+	} {
+		switch {
+		case !planned.configured.IsNull():
+		case !known:
+			*planned.value = queryJSONUnknown()
+		default:
+			*planned.value = queryJSONKeepingPrior(planned.prior, desired)
 		}
 	}
+	if !req.State.Raw.IsNull() && plan.ShortName.Equal(state.ShortName) {
+		plan.Name = state.Name
+	}
+	// The priorities only change along with the policy priority and the
+	// scope only becomes dirty when its query changes. updated_at is left
+	// unknown, it changes on every update.
+	if !req.State.Raw.IsNull() && plan.PolicyPriority.Equal(state.PolicyPriority) {
+		plan.Priority = state.Priority
+		plan.ShortPriority = state.ShortPriority
+	}
+	if !req.State.Raw.IsNull() && known && queryJSONEqual(desired, state.QueryJSON.ValueString()) {
+		plan.Dirty = state.Dirty
+	}
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+	if req.State.Raw.IsNull() || !known || !r.configured {
+		return
+	}
+	parent, err := scopeById(r.client.WithContext(ctx), plan.ParentAppScopeId.ValueString())
+	if err != nil {
+		log.Printf("[WARN] Error %s previewing the members of scope %s", err, state.Name.ValueString())
+		return
+	}
+	summary, detail, ok := previewMembershipChange(ctx, r.client, "scope", state.Name.ValueString(), parent.Name, state.QueryJSON.ValueString(), desired)
+	if ok {
+		resp.Diagnostics.AddAttributeWarning(path.Root("query_json"), summary, detail)
+	}
+}
+
+func (r *scopeResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan scopeResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := r.client.WithContext(ctx)
 	createScopeParams := CreateScopeRequest{
-		ShortName:        d.Get("short_name").(string),
-		Description:      d.Get("description").(string),
-		ParentAppScopeId: d.Get("parent_app_scope_id").(string),
-		SubType:          d.Get("sub_type").(string),
-		ShortQuery:       []byte(d.Get("short_query").(string)),
-		PolicyPriority:   d.Get("policy_priority").(int),
+		ShortName:        plan.ShortName.ValueString(),
+		Description:      plan.Description.ValueString(),
+		ParentAppScopeId: plan.ParentAppScopeId.ValueString(),
+		SubType:          plan.SubType.ValueString(),
+		ShortQuery:       []byte(plan.QueryJSON.ValueString()),
+		PolicyPriority:   int(plan.PolicyPriority.ValueInt64()),
+	}
+	if len(createScopeParams.ShortQuery) == 0 {
+		createScopeParams.ShortQuery = []byte(plan.ShortQuery.ValueString())
 	}
 	scope, err := client.CreateScope(createScopeParams)
 	if err != nil {
-		return err
+		resp.Diagnostics.AddError("Unable to create scope", err.Error())
+		return
+	}
+	// Read back the attributes computed by the API
+	scope, err = client.DescribeScope(scope.Id)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to read scope", err.Error())
+		return
 	}
-	d.Set("policy_priority", scope.PolicyPriority)
-	d.Set("description", scope.Description)
-	d.SetId(scope.Id)
-	return nil
+	resp.Diagnostics.Append(scopeToModel(ctx, scope, &plan)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
 
-func resourceSecureWorkloadScopeRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	scope, err := client.DescribeScope(d.Id())
+func (r *scopeResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state scopeResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	scope, err := r.client.WithContext(ctx).DescribeScope(state.Id.ValueString())
+	if IsNotFound(err) {
+		log.Printf("[WARN] Resource %s no longer exists, removing it from state", state.Id.ValueString())
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
-		return err
-	}
-	d.Set("short_name", scope.ShortName)
-	d.Set("description", scope.Description)
-	d.Set("parent_app_scope_id", scope.ParentAppScopeId)
-	d.Set("policy_priority", scope.PolicyPriority)
-	d.Set("name", scope.Name)
-	d.Set("root_app_scope_id", scope.RootAppScopeId)
-	d.Set("vrf_id", scope.VRFId)
-	d.Set("priority", scope.Priority)
-	d.Set("short_priority", scope.ShortPriority)
-	d.Set("dirty", scope.Dirty)
-	d.Set("child_app_scope_ids", scope.ChildAppScopeIds)
-	d.Set("created_at", scope.CreatedAt)
-	d.Set("updated_at", scope.UpdatedAt)
-	return nil
-}
-
-func resourceSecureWorkloadScopeUpdate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	client.DeleteScope(d.Id())
-	createScopeParams := CreateScopeRequest{
-		ShortName:        d.Get("short_name").(string),
-		Description:      d.Get("description").(string),
-		ParentAppScopeId: d.Get("parent_app_scope_id").(string),
-		ShortQuery:       []byte(d.Get("short_query").(string)),
-		SubType:          d.Get("sub_type").(string),
-		PolicyPriority:   d.Get("policy_priority").(int),
+		resp.Diagnostics.AddError("Unable to read scope", err.Error())
+		return
 	}
-	scope, err := client.CreateScope(createScopeParams)
+	resp.Diagnostics.Append(scopeToModel(ctx, scope, &state)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *scopeResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state scopeResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := r.client.WithContext(ctx)
+	updateScopeParams := UpdateScopeRequest{
+		ShortName:   plan.ShortName.ValueString(),
+		Description: plan.Description.ValueString(),
+	}
+	// Only send the query when it changed, the API marks the
+	// scope as dirty for every query it receives. query_json is
+	// also planned from the query block and short_query.
+	if !queryJSONEqual(plan.QueryJSON.ValueString(), state.QueryJSON.ValueString()) {
+		updateScopeParams.ShortQuery = []byte(plan.QueryJSON.ValueString())
+	} else if !queryJSONEqual(plan.ShortQuery.ValueString(), state.ShortQuery.ValueString()) {
+		updateScopeParams.ShortQuery = []byte(plan.ShortQuery.ValueString())
+	}
+	if !plan.PolicyPriority.IsUnknown() && !plan.PolicyPriority.Equal(state.PolicyPriority) {
+		policyPriority := int(plan.PolicyPriority.ValueInt64())
+		updateScopeParams.PolicyPriority = &policyPriority
+	}
+	_, err := client.UpdateScope(state.Id.ValueString(), updateScopeParams)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to update scope", err.Error())
+		return
+	}
+	scope, err := client.DescribeScope(state.Id.ValueString())
 	if err != nil {
-		return err
-	}
-	d.Set("description", scope.Description)
-	d.Set("policy_priority", scope.PolicyPriority)
-	d.SetId(scope.Id)
-	return nil
-}
-
-func resourceSecureWorkloadScopeDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	err := client.DeleteScope(d.Id())
-	for err != nil {
-		if strings.Contains(err.Error(), "error:cannot delete scope because it is in use") {
-			if timer >= 20 {
-				return err
-			}
-			time.Sleep(60 * time.Second)
-			timer = timer + 1
-			err = client.DeleteScope(d.Id())
-		} else {
-			return err
+		resp.Diagnostics.AddError("Unable to read scope", err.Error())
+		return
+	}
+	plan.Id = state.Id
+	resp.Diagnostics.Append(scopeToModel(ctx, scope, &plan)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *scopeResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state scopeResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	timeout := deleteTimeout(state.Timeouts, defaultScopeDeleteTimeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	client := r.client.WithContext(ctx)
+	// Scopes still used by workspaces or policies that are being
+	// deleted in the same apply are released eventually, so keep
+	// trying until the delete timeout elapses
+	var err error
+	deleted := AwaitContext(ctx, func() bool {
+		err = client.DeleteScope(state.Id.ValueString())
+		return !IsInUse(err)
+	}, scopeDeletePollInterval)
+	if !deleted {
+		resp.Diagnostics.AddError("Unable to delete scope", fmt.Sprintf("scope %s is still in use after %s: %s", state.Id.ValueString(), timeout, err))
+		return
+	}
+	if err != nil && !IsNotFound(err) {
+		resp.Diagnostics.AddError("Unable to delete scope", err.Error())
+	}
+}
+
+// UpgradeState upgrades the state written by terraform-plugin-sdk/v2,
+// which stored unset strings as empty strings.
+func (r *scopeResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	priorSchema := schemaResp.Schema
+	upgrader := resource.StateUpgrader{
+		PriorSchema:   &priorSchema,
+		StateUpgrader: upgradeScopeStateFromSDK,
+	}
+	return map[int64]resource.StateUpgrader{
+		0: upgrader,
+		1: upgrader,
+	}
+}
+
+func upgradeScopeStateFromSDK(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var state scopeResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	for _, value := range []*types.String{&state.SubType, &state.Description} {
+		if value.ValueString() == "" {
+			*value = types.StringNull()
 		}
 	}
-	return err
+	for _, value := range []*queryJSONValue{&state.QueryJSON, &state.ShortQuery} {
+		if value.ValueString() == "" {
+			*value = queryJSONNull()
+		}
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *scopeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// scopeToModel refreshes the model of a scope from the scope returned by
+// the API. The query attributes keep their value while they hold the same
+// query, and the query block is only refreshed when it is used.
+func scopeToModel(ctx context.Context, scope Scope, model *scopeResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	model.Id = types.StringValue(scope.Id)
+	model.ShortName = types.StringValue(scope.ShortName)
+	model.Description = stringKeepingNull(model.Description, scope.Description)
+	model.ParentAppScopeId = types.StringValue(scope.ParentAppScopeId)
+	model.PolicyPriority = types.Int64Value(int64(scope.PolicyPriority))
+	if scope.ShortQuery.Type != "" {
+		encoded, err := json.Marshal(scope.ShortQuery)
+		if err != nil {
+			diags.AddError("Unable to encode the query of the scope", err.Error())
+			return diags
+		}
+		model.QueryJSON = queryJSONKeepingPrior(model.QueryJSON, string(encoded))
+		model.ShortQuery = queryJSONKeepingPrior(model.ShortQuery, string(encoded))
+		query, err := scopeQueryToFramework(ctx, model.Query, scope.ShortQuery, "query", "query_json")
+		if err != nil {
+			diags.AddAttributeError(path.Root("query"), "Unable to refresh the query block", err.Error())
+			return diags
+		}
+		model.Query = query
+	}
+	if model.QueryJSON.IsUnknown() {
+		model.QueryJSON = queryJSONNull()
+	}
+	if model.ShortQuery.IsUnknown() {
+		model.ShortQuery = queryJSONNull()
+	}
+	model.Name = types.StringValue(scope.Name)
+	model.RootAppScopeId = types.StringValue(scope.RootAppScopeId)
+	model.VRFId = types.Int64Value(int64(scope.VRFId))
+	model.Priority = types.StringValue(scope.Priority)
+	model.ShortPriority = types.Int64Value(int64(scope.ShortPriority))
+	model.Dirty = types.BoolValue(scope.Dirty)
+	childAppScopeIds := scope.ChildAppScopeIds
+	if childAppScopeIds == nil {
+		childAppScopeIds = []string{}
+	}
+	var childDiags diag.Diagnostics
+	model.ChildAppScopeIds, childDiags = types.ListValueFrom(ctx, types.StringType, childAppScopeIds)
+	diags.Append(childDiags...)
+	model.CreatedAt = types.Int64Value(scope.CreatedAt)
+	model.UpdatedAt = types.Int64Value(scope.UpdatedAt)
+	return diags
 }
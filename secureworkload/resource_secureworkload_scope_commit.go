@@ -0,0 +1,135 @@
+package secureworkload
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	// Longest wait between two checks of the dirty flag of a scope tree.
+	scopeCommitPollInterval = 15 * time.Second
+)
+
+func resourceSecureWorkloadScopeCommit() *schema.Resource {
+	return &schema.Resource{
+		Description: "Resource for committing the pending query changes of a scope tree in Secure Workload\n" +
+			"\n" +
+			"Scope query changes are not applied until they are committed. This resource commits every dirty scope " +
+			"under the given root scope and waits until the changes have been applied.\n" +
+			"\n" +
+			"## Example\n" +
+			"An example is shown below: \n" +
+			"```hcl\n" +
+			"resource \"secureworkload_scope_commit\" \"commit\" {\n" +
+			"    root_app_scope_id = data.secureworkload_scope.root.id\n" +
+			"    triggers = {\n" +
+			"        web = secureworkload_scope.web.short_query\n" +
+			"        db  = secureworkload_scope.db.short_query\n" +
+			"    }\n" +
+			"    timeouts {\n" +
+			"        create = \"30m\"\n" +
+			"    }\n" +
+			"}\n" +
+			"```\n" +
+			"**Note:** The commit runs when the resource is created, use `triggers` to commit again whenever the referenced scope queries change. " +
+			"Destroying this resource does not revert committed changes.\n",
+		CreateContext: resourceSecureWorkloadScopeCommitCreate,
+		ReadContext:   resourceSecureWorkloadScopeCommitRead,
+		DeleteContext: resourceSecureWorkloadScopeCommitDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			"root_app_scope_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the root scope whose dirty scopes will be committed.",
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Arbitrary map of values that, when changed, will commit the scope tree again.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"dirty": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Indicates a scope of the tree has been updated and that the changes need to be committed.",
+			},
+		},
+	}
+}
+
+func resourceSecureWorkloadScopeCommitCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	rootAppScopeId := d.Get("root_app_scope_id").(string)
+	err := client.CommitScopeQueries(CommitScopeQueriesRequest{
+		RootAppScopeId: rootAppScopeId,
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var dirtyScopes []Scope
+	var listErr error
+	committed := AwaitContext(ctx, func() bool {
+		scopes, err := client.ListDirtyScopes(rootAppScopeId)
+		if err != nil {
+			listErr = err
+			return true
+		}
+		dirtyScopes = scopes
+		return len(dirtyScopes) == 0
+	}, scopeCommitPollInterval)
+	if listErr != nil && ctx.Err() == nil {
+		return diag.FromErr(listErr)
+	}
+	if !committed || listErr != nil {
+		return diag.FromErr(dirtyScopesError(dirtyScopes, d.Timeout(schema.TimeoutCreate)))
+	}
+	d.SetId(rootAppScopeId)
+	return resourceSecureWorkloadScopeCommitRead(ctx, d, meta)
+}
+
+// dirtyScopesError reports every scope whose changes
+// were still not committed once the timeout elapsed.
+func dirtyScopesError(dirtyScopes []Scope, timeout time.Duration) error {
+	var err *multierror.Error
+	for _, scope := range dirtyScopes {
+		err = multierror.Append(err, fmt.Errorf("scope %q (%s) still has uncommitted query changes after %s", scope.Name, scope.Id, timeout))
+	}
+	return err.ErrorOrNil()
+}
+
+func resourceSecureWorkloadScopeCommitRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	scope, err := client.DescribeScope(d.Id())
+	if err != nil {
+		return diag.FromErr(removeIfNotFound(d, err))
+	}
+	dirtyScopes, err := client.ListDirtyScopes(scope.Id)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.Set("root_app_scope_id", scope.Id)
+	d.Set("dirty", len(dirtyScopes) > 0)
+	return nil
+}
+
+func resourceSecureWorkloadScopeCommitDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Committed query changes can't be reverted,
+	// so only remove the resource from state
+	return nil
+}
@@ -0,0 +1,185 @@
+package secureworkload
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ServicePorts are the ports a named service listens on for one protocol.
+type ServicePorts struct {
+	// Protocol name or number, see protocolNumber.
+	Protocol string
+	// Ports expression, see parsePorts, empty for all ports.
+	Ports string
+}
+
+// defaultServices is the catalog of well known services that policies can
+// reference by name, extended or overridden by the services configured on
+// the provider.
+var defaultServices = map[string][]ServicePorts{
+	"dns":      {{Protocol: "tcp", Ports: "53"}, {Protocol: "udp", Ports: "53"}},
+	"ftp":      {{Protocol: "tcp", Ports: "20-21"}},
+	"http":     {{Protocol: "tcp", Ports: "80"}},
+	"https":    {{Protocol: "tcp", Ports: "443"}},
+	"icmp":     {{Protocol: "icmp"}},
+	"imap":     {{Protocol: "tcp", Ports: "143"}},
+	"imaps":    {{Protocol: "tcp", Ports: "993"}},
+	"kerberos": {{Protocol: "tcp", Ports: "88"}, {Protocol: "udp", Ports: "88"}},
+	"ldap":     {{Protocol: "tcp", Ports: "389"}, {Protocol: "udp", Ports: "389"}},
+	"ldaps":    {{Protocol: "tcp", Ports: "636"}},
+	"mongodb":  {{Protocol: "tcp", Ports: "27017"}},
+	"mssql":    {{Protocol: "tcp", Ports: "1433"}},
+	"mysql":    {{Protocol: "tcp", Ports: "3306"}},
+	"ntp":      {{Protocol: "udp", Ports: "123"}},
+	"postgres": {{Protocol: "tcp", Ports: "5432"}},
+	"rdp":      {{Protocol: "tcp", Ports: "3389"}},
+	"redis":    {{Protocol: "tcp", Ports: "6379"}},
+	"smtp":     {{Protocol: "tcp", Ports: "25,465,587"}},
+	"snmp":     {{Protocol: "udp", Ports: "161"}},
+	"ssh":      {{Protocol: "tcp", Ports: "22"}},
+	"syslog":   {{Protocol: "udp", Ports: "514"}},
+}
+
+// serviceCatalog maps the names of services to their ports.
+type serviceCatalog map[string][]ServicePorts
+
+// services returns the catalog of services available to the policies
+// managed with the client, the default ones and the ones configured on
+// the provider, which replace the default ones with the same name.
+func (c Client) services() serviceCatalog {
+	catalog := serviceCatalog{}
+	for name, ports := range defaultServices {
+		catalog[name] = ports
+	}
+	for name, ports := range c.Config.Services {
+		catalog[name] = ports
+	}
+	return catalog
+}
+
+// layer4NetworkPolicies returns the layer 4 parameters of a service.
+func (catalog serviceCatalog) layer4NetworkPolicies(name string, approved bool) ([]Layer4NetworkPolicy, error) {
+	servicePorts, ok := catalog[name]
+	if !ok {
+		names := make([]string, 0, len(catalog))
+		for name := range catalog {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown service %q, known services are %s", name, strings.Join(names, ", "))
+	}
+	var layer4NetworkPolicies []Layer4NetworkPolicy
+	for _, ports := range servicePorts {
+		expanded, err := layer4NetworkPoliciesForPorts(ports.Protocol, ports.Ports, approved)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %s", name, err)
+		}
+		layer4NetworkPolicies = append(layer4NetworkPolicies, expanded...)
+	}
+	return layer4NetworkPolicies, nil
+}
+
+// layer4NetworkPoliciesForPorts returns the layer 4 parameters for the
+// ports expression of a protocol, one per port range, or a single one
+// for all ports when the expression is empty.
+func layer4NetworkPoliciesForPorts(protocol string, ports string, approved bool) ([]Layer4NetworkPolicy, error) {
+	number, err := protocolNumber(protocol)
+	if err != nil {
+		return nil, err
+	}
+	portRanges := [][2]int{{0, 0}}
+	if ports != "" {
+		portRanges, err = parsePorts(ports)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var layer4NetworkPolicies []Layer4NetworkPolicy
+	for _, portRange := range portRanges {
+		layer4NetworkPolicies = append(layer4NetworkPolicies, Layer4NetworkPolicy{
+			Protocol:  number,
+			PortRange: portRange,
+			Approved:  approved,
+		})
+	}
+	return layer4NetworkPolicies, nil
+}
+
+// parsePorts parses a comma separated list of ports and port ranges, such
+// as 80,443,8000-8100, into the smallest sorted list of port ranges
+// covering the same ports, merging the ranges that overlap or touch.
+func parsePorts(expression string) ([][2]int, error) {
+	var portRanges [][2]int
+	for _, item := range strings.Split(expression, ",") {
+		item = strings.TrimSpace(item)
+		bounds := strings.SplitN(item, "-", 2)
+		var portRange [2]int
+		for i, bound := range []string{bounds[0], bounds[len(bounds)-1]} {
+			port, err := strconv.Atoi(strings.TrimSpace(bound))
+			if err != nil || port < 0 || port > maxPort {
+				return nil, fmt.Errorf("invalid ports %q: %q is not a port or a range of ports between 0 and %d", expression, item, maxPort)
+			}
+			portRange[i] = port
+		}
+		if err := checkPortRange(fmt.Sprintf("invalid ports %q", expression), portRange[0], portRange[1]); err != nil {
+			return nil, err
+		}
+		portRanges = append(portRanges, portRange)
+	}
+	sort.Slice(portRanges, func(i, j int) bool {
+		return portRanges[i][0] < portRanges[j][0]
+	})
+	merged := portRanges[:1]
+	for _, portRange := range portRanges[1:] {
+		last := &merged[len(merged)-1]
+		if portRange[0] <= last[1]+1 {
+			if portRange[1] > last[1] {
+				last[1] = portRange[1]
+			}
+			continue
+		}
+		merged = append(merged, portRange)
+	}
+	return merged, nil
+}
+
+// checkServiceProtocol checks that no protocol other than any is set
+// along with a service, as services define their own protocols.
+func checkServiceProtocol(protocol string) error {
+	if number, err := protocolNumber(protocol); err != nil || number != 0 {
+		return fmt.Errorf("protocol %q can not be combined with service, services define their own protocols", protocol)
+	}
+	return nil
+}
+
+// validatePorts is a ValidateFunc for ports expressions, see parsePorts,
+// accepting empty ones as unset.
+func validatePorts(val interface{}, key string) (warns []string, errs []error) {
+	if val.(string) == "" {
+		return
+	}
+	if _, err := parsePorts(val.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%q: %s", key, err))
+	}
+	return
+}
+
+// servicesFromTerraform returns the services configured with service
+// blocks, where the blocks with the same name make up a single service.
+func servicesFromTerraform(tfServices []interface{}) map[string][]ServicePorts {
+	services := map[string][]ServicePorts{}
+	for _, tfService := range tfServices {
+		if tfService == nil {
+			continue
+		}
+		tf := tfService.(terraformObject)
+		name := tf["name"].(string)
+		services[name] = append(services[name], ServicePorts{
+			Protocol: tf["protocol"].(string),
+			Ports:    tf["ports"].(string),
+		})
+	}
+	return services
+}
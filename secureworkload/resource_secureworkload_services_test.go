@@ -0,0 +1,63 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePorts(t *testing.T) {
+	for _, test := range []struct {
+		expression string
+		expected   [][2]int
+	}{
+		{expression: "443", expected: [][2]int{{443, 443}}},
+		{expression: "80,443,8000-8100", expected: [][2]int{{80, 80}, {443, 443}, {8000, 8100}}},
+		{expression: " 8000 - 8100 , 443 ", expected: [][2]int{{443, 443}, {8000, 8100}}},
+		{expression: "8000-8100,8050-8200,8201", expected: [][2]int{{8000, 8201}}},
+		{expression: "22,22,21,23", expected: [][2]int{{21, 23}}},
+		{expression: "0-65535,80", expected: [][2]int{{0, 65535}}},
+	} {
+		portRanges, err := parsePorts(test.expression)
+		if err != nil {
+			t.Errorf("Expected no error parsing %q, got %s", test.expression, err)
+			continue
+		}
+		if !reflect.DeepEqual(portRanges, test.expected) {
+			t.Errorf("Expected %q to be parsed as %v, got %v", test.expression, test.expected, portRanges)
+		}
+	}
+	for _, expression := range []string{"", "80,", "http", "80-", "-80", "8100-8000", "65536", "1-2-3"} {
+		if _, err := parsePorts(expression); err == nil {
+			t.Errorf("Expected %q to be rejected", expression)
+		}
+	}
+}
+
+func TestServicesFromTerraformGroupsBlocksByName(t *testing.T) {
+	services := servicesFromTerraform([]interface{}{
+		terraformObject{"name": "statsd", "protocol": "udp", "ports": "8125"},
+		terraformObject{"name": "ssh", "protocol": "tcp", "ports": "2222"},
+		terraformObject{"name": "statsd", "protocol": "tcp", "ports": "8125-8126"},
+	})
+	expected := map[string][]ServicePorts{
+		"statsd": {{Protocol: "udp", Ports: "8125"}, {Protocol: "tcp", Ports: "8125-8126"}},
+		"ssh":    {{Protocol: "tcp", Ports: "2222"}},
+	}
+	if !reflect.DeepEqual(services, expected) {
+		t.Errorf("Expected %v, got %v", expected, services)
+	}
+	catalog := Client{Config: Config{Services: services}}.services()
+	if !reflect.DeepEqual(catalog["ssh"], expected["ssh"]) || !reflect.DeepEqual(catalog["https"], defaultServices["https"]) {
+		t.Errorf("Expected configured services to extend and replace the default ones, got %v", catalog)
+	}
+}
+
+func TestDefaultServicesExpand(t *testing.T) {
+	for name := range defaultServices {
+		if _, err := (Client{}).services().layer4NetworkPolicies(name, false); err != nil {
+			t.Errorf("Expected default service %s to expand, got %s", name, err)
+		}
+	}
+}
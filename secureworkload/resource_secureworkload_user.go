@@ -1,6 +1,9 @@
 package secureworkload
 
 import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	// client "github.com/secureworkload-exchange/terraform-go-sdk"
 	// secureworkload "github.com/secureworkload-exchange/terraform-go-sdk"
@@ -22,21 +25,30 @@ func resourceSecureWorkloadUser() *schema.Resource {
 			"    enable_existing = true \n" +
 			"}\n" +
 			"```\n" +
-			"**Note:** If creating multiple rules during a single `terraform apply`, remember to use `depends_on` to chain the rules so that terraform creates it in the same order that you intended.\n" ,
+			"**Note:** If creating multiple rules during a single `terraform apply`, remember to use `depends_on` to chain the rules so that terraform creates it in the same order that you intended.\n" +
+			"\n" +
+			"## Import\n" +
+			"Users can be imported using their ID:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_user.new_user 5f3d5b7c497d4f6a2e8b9c0d\n" +
+			"```\n",
 
-		Create: resourceSecureWorkloadUserCreate,
-		Update: nil,
-		Read:   resourceSecureWorkloadUserRead,
-		Delete: resourceSecureWorkloadUserDelete,
+		CreateContext: resourceSecureWorkloadUserCreate,
+		ReadContext:   resourceSecureWorkloadUserRead,
+		DeleteContext: resourceSecureWorkloadUserDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceSecureWorkloadUserImport,
+		},
 
 		SchemaVersion: 1,
 
 		Schema: map[string]*schema.Schema{
 			"email": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "Email address associated with the user account.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateEmail,
+				Description:  "Email address associated with the user account.",
 			},
 			"first_name": {
 				Type:        schema.TypeString,
@@ -83,16 +95,16 @@ func resourceSecureWorkloadUser() *schema.Resource {
 	}
 }
 
-func resourceSecureWorkloadUserCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadUserCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	enableExistingUser := d.Get("enable_existing").(bool)
 	if enableExistingUser {
-		users, err := client.ListUsers(ListUsersRequest{
+		users, err := client.LookupUsers(ListUsersRequest{
 			AppScopeId:      d.Get("app_scope_id").(string),
 			IncludeDisabled: true,
 		})
 		if err != nil {
-			return err
+			return diag.FromErr(err)
 		}
 		var userExists bool
 		var user User
@@ -106,7 +118,7 @@ func resourceSecureWorkloadUserCreate(d *schema.ResourceData, meta interface{})
 		if userExists {
 			user, err = client.EnableUser(user.Id)
 			if err != nil {
-				return err
+				return diag.FromErr(err)
 			}
 			d.SetId(user.Id)
 			return nil
@@ -126,17 +138,17 @@ func resourceSecureWorkloadUserCreate(d *schema.ResourceData, meta interface{})
 	}
 	user, err := client.CreateUser(createUserParams)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.SetId(user.Id)
 	return nil
 }
 
-func resourceSecureWorkloadUserRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadUserRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	user, err := client.DescribeUser(d.Id())
 	if err != nil {
-		return err
+		return diag.FromErr(removeIfNotFound(d, err))
 	}
 	d.Set("email", user.Email)
 	d.Set("first_name", user.FirstName)
@@ -147,7 +159,12 @@ func resourceSecureWorkloadUserRead(d *schema.ResourceData, meta interface{}) er
 	return nil
 }
 
-func resourceSecureWorkloadUserDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	return client.DeleteUser(d.Id())
+func resourceSecureWorkloadUserImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	d.Set("enable_existing", false)
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceSecureWorkloadUserDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	return diag.FromErr(client.DeleteUser(d.Id()))
 }
@@ -0,0 +1,102 @@
+package secureworkload
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	// "github.com/secureworkload-exchange/terraform-go-sdk/signer"
+	"terraform-provider-secureworkload/secureworkload/signer"
+)
+
+const (
+	// Largest TCP or UDP port number.
+	maxPort = 65535
+	// Largest IANA protocol number.
+	maxProtocolNumber = 255
+)
+
+var (
+	// Actions of policies and of catch all rules.
+	policyActions = []string{"ALLOW", "DENY"}
+	// Ranks of the policies of a workspace.
+	policyRanks = []string{"DEFAULT", "ABSOLUTE", "CATCHALL"}
+)
+
+// validateStringInSlice returns a ValidateFunc accepting only the given
+// values, compared case insensitively when ignoreCase is true.
+func validateStringInSlice(valid []string, ignoreCase bool) schema.SchemaValidateFunc {
+	return func(val interface{}, key string) (warns []string, errs []error) {
+		value := val.(string)
+		for _, allowed := range valid {
+			if value == allowed || ignoreCase && strings.EqualFold(value, allowed) {
+				return
+			}
+		}
+		errs = append(errs, fmt.Errorf("%q must be in %v, got: %q", key, valid, value))
+		return
+	}
+}
+
+// validateIPOrCIDR is a ValidateFunc for IPv4 or IPv6 addresses and subnets.
+func validateIPOrCIDR(val interface{}, key string) (warns []string, errs []error) {
+	value := val.(string)
+	if net.ParseIP(value) != nil {
+		return
+	}
+	if _, _, err := net.ParseCIDR(value); err != nil {
+		errs = append(errs, fmt.Errorf("%q must be an IP address or a subnet like 10.0.0.0/24, got: %q", key, value))
+	}
+	return
+}
+
+// validatePort is a ValidateFunc for TCP and UDP port numbers.
+func validatePort(val interface{}, key string) (warns []string, errs []error) {
+	if port := val.(int); port < 0 || port > maxPort {
+		errs = append(errs, fmt.Errorf("%q must be a port between 0 and %d, got: %d", key, maxPort, port))
+	}
+	return
+}
+
+// validateEmail is a ValidateFunc for bare email addresses,
+// without a display name.
+func validateEmail(val interface{}, key string) (warns []string, errs []error) {
+	value := val.(string)
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		errs = append(errs, fmt.Errorf("%q must be an email address, got: %q", key, value))
+	}
+	return
+}
+
+// validateAPISecret is a ValidateFunc for API secrets,
+// which must be long enough for signer.New to accept them.
+func validateAPISecret(val interface{}, key string) (warns []string, errs []error) {
+	if length := len(val.(string)); length < signer.APISecretByteLength {
+		errs = append(errs, fmt.Errorf("%q must be %d bytes long, got %d bytes", key, signer.APISecretByteLength, length))
+	}
+	return
+}
+
+// checkPortRange checks that a port range does not start after it ends.
+func checkPortRange(path string, start int, end int) error {
+	if start > end {
+		return fmt.Errorf("%s: start port %d must not be greater than end port %d", path, start, end)
+	}
+	return nil
+}
+
+// customizePortRangeDiff checks at plan time that the port range
+// between the startKey and endKey attributes does not start after
+// it ends, once both are known.
+func customizePortRangeDiff(startKey string, endKey string) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if !d.NewValueKnown(startKey) || !d.NewValueKnown(endKey) {
+			return nil
+		}
+		return checkPortRange(startKey, d.Get(startKey).(int), d.Get(endKey).(int))
+	}
+}
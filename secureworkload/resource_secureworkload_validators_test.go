@@ -0,0 +1,128 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestValidators(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		validate schema.SchemaValidateFunc
+		valid    []interface{}
+		invalid  []interface{}
+	}{
+		{
+			name:     "validateIPOrCIDR",
+			validate: validateIPOrCIDR,
+			valid:    []interface{}{"10.0.0.1", "10.0.0.0/24", "2001:db8::1", "2001:db8::/64"},
+			invalid:  []interface{}{"", "10.0.0.256", "10.0.0.0/33", "web.example.com"},
+		},
+		{
+			name:     "validatePort",
+			validate: validatePort,
+			valid:    []interface{}{0, 443, 65535},
+			invalid:  []interface{}{-1, 65536},
+		},
+		{
+			name:     "validateProtocol",
+			validate: validateProtocol,
+			valid:    []interface{}{"", "any", "TCP", "udp", "icmpv6", "0", "6", "255"},
+			invalid:  []interface{}{"-1", "256", "sctp"},
+		},
+		{
+			name:     "validateEmail",
+			validate: validateEmail,
+			valid:    []interface{}{"jane.doe@example.com"},
+			invalid:  []interface{}{"", "jane.doe", "Jane Doe <jane.doe@example.com>"},
+		},
+		{
+			name:     "validateAPISecret",
+			validate: validateAPISecret,
+			valid:    []interface{}{strings.Repeat("X", 40)},
+			invalid:  []interface{}{"", strings.Repeat("X", 39)},
+		},
+		{
+			name:     "policy actions",
+			validate: validateStringInSlice(policyActions, false),
+			valid:    []interface{}{"ALLOW", "DENY"},
+			invalid:  []interface{}{"allow", "DROP"},
+		},
+		{
+			name:     "abilities",
+			validate: validateStringInSlice(ValidAbilities2, true),
+			valid:    []interface{}{"SCOPE_READ", "scope_owner"},
+			invalid:  []interface{}{"SCOPE_ADMIN"},
+		},
+	} {
+		for _, value := range test.valid {
+			if _, errs := test.validate(value, "key"); len(errs) > 0 {
+				t.Errorf("Expected %s to accept %v, got %v", test.name, value, errs)
+			}
+		}
+		for _, value := range test.invalid {
+			if _, errs := test.validate(value, "key"); len(errs) == 0 {
+				t.Errorf("Expected %s to reject %v", test.name, value)
+			}
+		}
+	}
+}
+
+func TestPortRangesAreCheckedWhenPlanning(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		resource *schema.Resource
+		config   map[string]interface{}
+	}{
+		{
+			name:     "secureworkload_port",
+			resource: resourceSecureWorkloadPort(),
+			config: map[string]interface{}{
+				"policy_id":  "1234",
+				"start_port": 443,
+				"end_port":   80,
+			},
+		},
+		{
+			name:     "secureworkload_policies",
+			resource: resourceSecureWorkloadPolicy(),
+			config: map[string]interface{}{
+				"workspace_id":       "1234",
+				"consumer_filter_id": "1",
+				"provider_filter_id": "2",
+				"policy_action":      "ALLOW",
+				"l4_params": []interface{}{
+					map[string]interface{}{"protocol": "tcp", "start_port": 443, "end_port": 80},
+				},
+			},
+		},
+		{
+			name:     "secureworkload_workspace",
+			resource: resourceSecureWorkloadApplication(),
+			config: map[string]interface{}{
+				"app_scope_id": "1234",
+				"default_policy": []interface{}{
+					map[string]interface{}{
+						"consumer_filter_id": "1",
+						"provider_filter_id": "2",
+						"action":             "ALLOW",
+						"layer_4_network_policy": []interface{}{
+							map[string]interface{}{"protocol": "tcp", "port_range": []interface{}{443, 80}},
+						},
+					},
+				},
+			},
+		},
+	} {
+		_, err := test.resource.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(test.config), nil)
+		if err == nil || !strings.Contains(err.Error(), "start port 443 must not be greater than end port 80") {
+			t.Errorf("Expected %s to reject a reversed port range, got %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package secureworkload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceSecureWorkloadWorkspacePoliciesJSON() *schema.Resource {
+	return &schema.Resource{
+		Description: "Resource for managing the policies of a workspace in Secure Workload from an exported policy document\n" +
+			"\n" +
+			"The document holds the `clusters`, `inventory_filters`, `absolute_policies`, `default_policies` and " +
+			"`catch_all_action` of a workspace, in the same format used when exporting the workspace from Secure Workload. " +
+			"It is imported into the latest version of the workspace, replacing its policies. When the document changes, " +
+			"plans log the clusters, inventory filters and policies that are added, removed or changed, shown with `TF_LOG=INFO`.\n" +
+			"\n" +
+			"## Example\n" +
+			"An example is shown below: \n" +
+			"```hcl\n" +
+			"resource \"secureworkload_workspace_policies_json\" \"policies\" {\n" +
+			"    workspace_id  = secureworkload_workspace.workspace1.id\n" +
+			"    policies_json = file(\"${path.module}/policies.json\")\n" +
+			"}\n" +
+			"```\n" +
+			"**Note:** Do not manage the same workspace policies with this resource and with the `cluster`, `filter` or policy blocks " +
+			"of `secureworkload_workspace` or with `secureworkload_policies`, as they would overwrite each other.\n" +
+			"\n" +
+			"## Import\n" +
+			"Workspace policies can be imported using the ID of the workspace:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_workspace_policies_json.policies 5f3d4c2e497d4f7c3f8e9a11\n" +
+			"```\n",
+		CreateContext: resourceSecureWorkloadWorkspacePoliciesJSONCreate,
+		ReadContext:   resourceSecureWorkloadWorkspacePoliciesJSONRead,
+		UpdateContext: resourceSecureWorkloadWorkspacePoliciesJSONUpdate,
+		DeleteContext: resourceSecureWorkloadWorkspacePoliciesJSONDelete,
+		CustomizeDiff: customizePolicyDocumentDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			"workspace_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the workspace to import the policies into.",
+			},
+			"policies_json": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "JSON policy document with the clusters, inventory filters, policies and catch all action of the workspace.",
+				ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+					if _, err := policyDocumentFromJSON(val.(string)); err != nil {
+						errs = append(errs, fmt.Errorf("%q is not a valid policy document: %s", key, err))
+					}
+					return
+				},
+				StateFunc: func(val interface{}) string {
+					normalized, err := normalizePolicyDocumentJSON(val.(string))
+					if err != nil {
+						return val.(string)
+					}
+					return normalized
+				},
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return policyDocumentsEquivalent(old, new)
+				},
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Version of the workspace the policies were read from.",
+			},
+		},
+	}
+}
+
+func resourceSecureWorkloadWorkspacePoliciesJSONCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	workspaceId := d.Get("workspace_id").(string)
+	document, err := policyDocumentFromJSON(d.Get("policies_json").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = client.ImportApplicationPolicies(workspaceId, document)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(workspaceId)
+	return resourceSecureWorkloadWorkspacePoliciesJSONRead(ctx, d, meta)
+}
+
+func resourceSecureWorkloadWorkspacePoliciesJSONRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	details, err := client.DescribeApplicationDetails(DescribeApplicationRequest{
+		ApplicationId: d.Id(),
+	})
+	if err != nil {
+		return diag.FromErr(removeIfNotFound(d, err))
+	}
+	d.Set("workspace_id", d.Id())
+	d.Set("version", details.Version)
+	document := details.ApplicationPolicyDocument
+	normalizePolicyDocument(&document)
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if current, ok := d.Get("policies_json").(string); ok && policyDocumentsEquivalent(current, string(encoded)) {
+		return nil
+	}
+	return diag.FromErr(d.Set("policies_json", string(encoded)))
+}
+
+func resourceSecureWorkloadWorkspacePoliciesJSONUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	document, err := policyDocumentFromJSON(d.Get("policies_json").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = client.ImportApplicationPolicies(d.Id(), document)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceSecureWorkloadWorkspacePoliciesJSONRead(ctx, d, meta)
+}
+
+func resourceSecureWorkloadWorkspacePoliciesJSONDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	// Leave the workspace with no policies, keeping the
+	// catch all action so that traffic is not suddenly allowed
+	document := ApplicationPolicyDocument{
+		CatchAllAction: "DENY",
+	}
+	if current, err := policyDocumentFromJSON(d.Get("policies_json").(string)); err == nil && current.CatchAllAction != "" {
+		document.CatchAllAction = current.CatchAllAction
+	}
+	normalizePolicyDocument(&document)
+	err := client.ImportApplicationPolicies(d.Id(), document)
+	if err != nil && !IsNotFound(err) {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// policyDocumentAttributes lists the top level attributes of a policy
+// document, followed by the attributes of the workspace found in exports.
+var policyDocumentAttributes = map[string]bool{
+	"clusters":          true,
+	"inventory_filters": true,
+	"absolute_policies": true,
+	"default_policies":  true,
+	"catch_all_action":  true,
+
+	"id":                   true,
+	"name":                 true,
+	"description":          true,
+	"app_scope_id":         true,
+	"version":              true,
+	"author":               true,
+	"primary":              true,
+	"vrf":                  true,
+	"created_at":           true,
+	"alternate_query_mode": true,
+	"enforcement_enabled":  true,
+	"enforced_version":     true,
+	"latest_adm_version":   true,
+}
+
+// policyDocumentFromJSON decodes and validates a policy document. The
+// attributes of the workspace found in exports, like its id, name and
+// author, are ignored while any other unknown attribute is rejected.
+func policyDocumentFromJSON(raw string) (ApplicationPolicyDocument, error) {
+	var document ApplicationPolicyDocument
+	var attributes map[string]json.RawMessage
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	if err := decoder.Decode(&attributes); err != nil {
+		return document, err
+	}
+	if decoder.More() {
+		return document, fmt.Errorf("unexpected data after the policy document")
+	}
+	for attribute := range attributes {
+		if !policyDocumentAttributes[attribute] {
+			return document, fmt.Errorf("unknown attribute %q", attribute)
+		}
+	}
+	if err := json.Unmarshal([]byte(raw), &document); err != nil {
+		return document, err
+	}
+	if err := validatePolicyDocument(document); err != nil {
+		return document, err
+	}
+	return document, nil
+}
+
+func validatePolicyDocument(document ApplicationPolicyDocument) error {
+	if !isPolicyAction(document.CatchAllAction) {
+		return fmt.Errorf("catch_all_action must be ALLOW or DENY, got %q", document.CatchAllAction)
+	}
+	for i, cluster := range document.Clusters {
+		if cluster.Id == "" {
+			return fmt.Errorf("clusters[%d] is missing an id", i)
+		}
+	}
+	for i, filter := range document.Filters {
+		if filter.Id == "" {
+			return fmt.Errorf("inventory_filters[%d] is missing an id", i)
+		}
+		var query interface{}
+		if err := json.Unmarshal(filter.Query, &query); err != nil || query == nil {
+			return fmt.Errorf("inventory_filters[%d] has an invalid query", i)
+		}
+	}
+	ranks := map[string][]Policy{
+		"absolute_policies": document.AbsolutePolicies,
+		"default_policies":  document.DefaultPolicies,
+	}
+	for rank, policies := range ranks {
+		for i, policy := range policies {
+			if policy.ConsumerFilterId == "" || policy.ProviderFilterId == "" {
+				return fmt.Errorf("%s[%d] must have a consumer_filter_id and a provider_filter_id", rank, i)
+			}
+			if !isPolicyAction(policy.Action) {
+				return fmt.Errorf("%s[%d] action must be ALLOW or DENY, got %q", rank, i, policy.Action)
+			}
+			for j, l4Param := range policy.Layer4NetworkPolicies {
+				if l4Param.Protocol < 0 || l4Param.Protocol > maxProtocolNumber {
+					return fmt.Errorf("%s[%d].l4_params[%d] proto must be between 0 and %d, got %d", rank, i, j, maxProtocolNumber, l4Param.Protocol)
+				}
+				for _, port := range l4Param.PortRange {
+					if port < 0 || port > maxPort {
+						return fmt.Errorf("%s[%d].l4_params[%d] port %d must be between 0 and %d", rank, i, j, port, maxPort)
+					}
+				}
+				if l4Param.PortRange[0] > l4Param.PortRange[1] {
+					return fmt.Errorf("%s[%d].l4_params[%d] port range %v starts after it ends", rank, i, j, l4Param.PortRange)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isPolicyAction(action string) bool {
+	action = strings.ToUpper(action)
+	return action == "ALLOW" || action == "DENY"
+}
+
+// normalizePolicyDocument puts a policy document in a canonical form:
+// actions are upper cased, clusters and filters are sorted by id,
+// queries have their keys ordered and ids only set by the API are
+// dropped. The order of policies is kept as it sets their priority.
+func normalizePolicyDocument(document *ApplicationPolicyDocument) {
+	document.CatchAllAction = strings.ToUpper(document.CatchAllAction)
+	if document.Clusters == nil {
+		document.Clusters = []Cluster{}
+	}
+	sort.SliceStable(document.Clusters, func(i, j int) bool {
+		return document.Clusters[i].Id < document.Clusters[j].Id
+	})
+	for i := range document.Clusters {
+		if document.Clusters[i].Nodes == nil {
+			document.Clusters[i].Nodes = []Node{}
+		}
+		nodes := document.Clusters[i].Nodes
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return nodes[i].IPAddress < nodes[j].IPAddress
+		})
+	}
+	if document.Filters == nil {
+		document.Filters = []PolicyFilter{}
+	}
+	sort.SliceStable(document.Filters, func(i, j int) bool {
+		return document.Filters[i].Id < document.Filters[j].Id
+	})
+	for i := range document.Filters {
+		if normalized, err := normalizeQueryJSON(string(document.Filters[i].Query)); err == nil {
+			document.Filters[i].Query = json.RawMessage(normalized)
+		}
+	}
+	if document.AbsolutePolicies == nil {
+		document.AbsolutePolicies = []Policy{}
+	}
+	if document.DefaultPolicies == nil {
+		document.DefaultPolicies = []Policy{}
+	}
+	for _, policies := range [][]Policy{document.AbsolutePolicies, document.DefaultPolicies} {
+		for i := range policies {
+			policies[i].Id = ""
+			policies[i].Action = strings.ToUpper(policies[i].Action)
+			if policies[i].Layer4NetworkPolicies == nil {
+				policies[i].Layer4NetworkPolicies = []Layer4NetworkPolicy{}
+			}
+		}
+	}
+}
+
+// normalizePolicyDocumentJSON returns the canonical JSON encoding of a policy document.
+func normalizePolicyDocumentJSON(raw string) (string, error) {
+	document, err := policyDocumentFromJSON(raw)
+	if err != nil {
+		return "", err
+	}
+	normalizePolicyDocument(&document)
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// policyDocumentsEquivalent reports whether two policy documents define
+// the same policies. Clusters and filters are compared by name, as
+// Secure Workload assigns them new ids when a document is imported.
+func policyDocumentsEquivalent(a, b string) bool {
+	aDocument, err := policyDocumentFromJSON(a)
+	if err != nil {
+		return false
+	}
+	bDocument, err := policyDocumentFromJSON(b)
+	if err != nil {
+		return false
+	}
+	aEncoded, err := json.Marshal(policyDocumentByName(aDocument))
+	if err != nil {
+		return false
+	}
+	bEncoded, err := json.Marshal(policyDocumentByName(bDocument))
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aEncoded, bEncoded)
+}
+
+// policyDocumentByName returns a normalized copy of a policy document
+// where clusters, filters and the policies referencing them use the
+// cluster and filter names in place of their ids.
+func policyDocumentByName(document ApplicationPolicyDocument) ApplicationPolicyDocument {
+	names := map[string]string{}
+	for i, cluster := range document.Clusters {
+		names[cluster.Id] = "cluster:" + cluster.Name
+		document.Clusters[i].Id = names[cluster.Id]
+	}
+	for i, filter := range document.Filters {
+		names[filter.Id] = "filter:" + filter.Name
+		document.Filters[i].Id = names[filter.Id]
+	}
+	for _, policies := range [][]Policy{document.AbsolutePolicies, document.DefaultPolicies} {
+		for i := range policies {
+			if name, ok := names[policies[i].ConsumerFilterId]; ok {
+				policies[i].ConsumerFilterId = name
+			}
+			if name, ok := names[policies[i].ProviderFilterId]; ok {
+				policies[i].ProviderFilterId = name
+			}
+		}
+	}
+	normalizePolicyDocument(&document)
+	return document
+}
+
+// customizePolicyDocumentDiff logs the clusters, filters and policies that
+// a change of the policy document adds, removes or changes, as the diff of
+// the document itself is hard to read.
+func customizePolicyDocumentDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("policies_json") || !d.NewValueKnown("policies_json") {
+		return nil
+	}
+	old, new := d.GetChange("policies_json")
+	oldDocument, err := policyDocumentFromJSON(old.(string))
+	if err != nil {
+		return nil
+	}
+	newDocument, err := policyDocumentFromJSON(new.(string))
+	if err != nil {
+		return nil
+	}
+	for _, change := range policyDocumentChanges(oldDocument, newDocument) {
+		log.Printf("[INFO] Policy document of workspace %s: %s", d.Id(), change)
+	}
+	return nil
+}
+
+// policyDocumentChanges describes the clusters, filters and policies added,
+// removed or changed between two policy documents. Clusters and filters are
+// matched by name and policies by rank, consumer, provider and action.
+func policyDocumentChanges(old, new ApplicationPolicyDocument) []string {
+	old, new = policyDocumentByName(old), policyDocumentByName(new)
+	changes := []string{}
+	if old.CatchAllAction != new.CatchAllAction {
+		changes = append(changes, fmt.Sprintf("catch all action changed from %s to %s", old.CatchAllAction, new.CatchAllAction))
+	}
+	oldEntries, newEntries := policyDocumentEntries(old), policyDocumentEntries(new)
+	keys := []string{}
+	for key := range oldEntries {
+		keys = append(keys, key)
+	}
+	for key := range newEntries {
+		if _, ok := oldEntries[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		oldEntry, newEntry := oldEntries[key], newEntries[key]
+		for i := 0; i < len(oldEntry) || i < len(newEntry); i++ {
+			switch {
+			case i >= len(newEntry):
+				changes = append(changes, key+" removed")
+			case i >= len(oldEntry):
+				changes = append(changes, key+" added")
+			default:
+				oldEncoded, _ := json.Marshal(oldEntry[i])
+				newEncoded, _ := json.Marshal(newEntry[i])
+				if !bytes.Equal(oldEncoded, newEncoded) {
+					changes = append(changes, key+" changed")
+				}
+			}
+		}
+	}
+	return changes
+}
+
+// policyDocumentEntries returns the clusters, filters and policies of a
+// policy document by name, see policyDocumentByName.
+func policyDocumentEntries(document ApplicationPolicyDocument) map[string][]interface{} {
+	entries := map[string][]interface{}{}
+	for _, cluster := range document.Clusters {
+		entries[cluster.Id] = append(entries[cluster.Id], cluster)
+	}
+	for _, filter := range document.Filters {
+		entries[filter.Id] = append(entries[filter.Id], filter)
+	}
+	for rank, policies := range map[string][]Policy{"absolute": document.AbsolutePolicies, "default": document.DefaultPolicies} {
+		for _, policy := range policies {
+			key := fmt.Sprintf("%s policy from %s to %s with action %s", rank, policy.ConsumerFilterId, policy.ProviderFilterId, policy.Action)
+			entries[key] = append(entries[key], policy)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,154 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const unitTestPolicyDocument = `{
+	"catch_all_action": "deny",
+	"clusters": [
+		{"id": "web", "name": "Web", "nodes": [{"ip": "10.0.0.2"}, {"ip": "10.0.0.1"}]},
+		{"id": "db", "name": "Database", "nodes": []}
+	],
+	"inventory_filters": [
+		{"id": "admins", "name": "Admins", "query": {"type": "eq", "value": "10.1.0.1", "field": "ip"}}
+	],
+	"absolute_policies": [
+		{"consumer_filter_id": "admins", "provider_filter_id": "db", "action": "allow", "l4_params": [{"proto": 6, "port": [22, 22]}]}
+	],
+	"default_policies": [
+		{"consumer_filter_id": "web", "provider_filter_id": "db", "action": "ALLOW", "l4_params": [{"proto": 6, "port": [5432, 5432]}]}
+	]
+}`
+
+func TestPolicyDocumentFromJSONValidatesDocument(t *testing.T) {
+	if _, err := policyDocumentFromJSON(unitTestPolicyDocument); err != nil {
+		t.Fatalf("Expected document to be valid, got %s", err)
+	}
+	invalid := map[string]string{
+		"unknown attribute":    `{"catch_all_action": "DENY", "policies": []}`,
+		"misspelled attribute": `{"catch_all_action": "DENY", "default_polices": []}`,
+		"invalid catch all":    `{"catch_all_action": "REJECT"}`,
+		"cluster without id":   `{"catch_all_action": "DENY", "clusters": [{"name": "Web"}]}`,
+		"filter without query": `{"catch_all_action": "DENY", "inventory_filters": [{"id": "a", "name": "A"}]}`,
+		"invalid action":       `{"catch_all_action": "DENY", "default_policies": [{"consumer_filter_id": "a", "provider_filter_id": "b", "action": "DROP"}]}`,
+		"reversed port range":  `{"catch_all_action": "DENY", "default_policies": [{"consumer_filter_id": "a", "provider_filter_id": "b", "action": "ALLOW", "l4_params": [{"proto": 6, "port": [90, 80]}]}]}`,
+		"port out of range":    `{"catch_all_action": "DENY", "default_policies": [{"consumer_filter_id": "a", "provider_filter_id": "b", "action": "ALLOW", "l4_params": [{"proto": 6, "port": [80, 70000]}]}]}`,
+		"unknown protocol":     `{"catch_all_action": "DENY", "default_policies": [{"consumer_filter_id": "a", "provider_filter_id": "b", "action": "ALLOW", "l4_params": [{"proto": 256, "port": [80, 80]}]}]}`,
+		"trailing data":        `{"catch_all_action": "DENY"} {}`,
+	}
+	for name, document := range invalid {
+		if _, err := policyDocumentFromJSON(document); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestPolicyDocumentFromJSONAcceptsExports(t *testing.T) {
+	exported := `{
+		"id": "5f3d4c2e497d4f7c3f8e9a11",
+		"name": "Web",
+		"app_scope_id": "5f3d4c2e497d4f7c3f8e9a00",
+		"version": "p3",
+		"author": "Jane Doe",
+		"primary": true,
+		"catch_all_action": "DENY",
+		"clusters": [
+			{"id": "5f1", "name": "Database", "description": "", "approved": false, "external": false, "nodes": [{"ip": "10.0.0.3", "name": "db-1"}], "consistent_uuid": "5f1"}
+		],
+		"inventory_filters": [
+			{"id": "5f2", "name": "Admins", "query": {"type": "eq", "field": "ip", "value": "10.1.0.1"}, "parent_app_scope": {"id": "5f3d4c2e497d4f7c3f8e9a00", "name": "Root"}}
+		],
+		"absolute_policies": [
+			{"consumer_filter_id": "5f2", "provider_filter_id": "5f1", "action": "ALLOW", "priority": 100, "l4_params": [{"proto": 6, "port": [22, 22], "approved": false, "confidence": 1.0}]}
+		],
+		"default_policies": [],
+		"vrf": {"id": 1, "name": "Default"}
+	}`
+	document, err := policyDocumentFromJSON(exported)
+	if err != nil {
+		t.Fatalf("Expected exported document to be valid, got %s", err)
+	}
+	if len(document.Clusters) != 1 || len(document.Filters) != 1 || len(document.AbsolutePolicies) != 1 {
+		t.Errorf("Expected the clusters, filters and policies of the export, got %+v", document)
+	}
+	normalized, err := normalizePolicyDocumentJSON(exported)
+	if err != nil {
+		t.Fatalf("Error %s normalizing exported document", err)
+	}
+	if strings.Contains(normalized, "author") || strings.Contains(normalized, "priority") {
+		t.Errorf("Expected attributes of the export outside the document to be stripped, got %s", normalized)
+	}
+}
+
+func TestPolicyDocumentsEquivalentIgnoresIdsAndOrdering(t *testing.T) {
+	normalized, err := normalizePolicyDocumentJSON(unitTestPolicyDocument)
+	if err != nil {
+		t.Fatalf("Error %s normalizing document", err)
+	}
+	if !policyDocumentsEquivalent(unitTestPolicyDocument, normalized) {
+		t.Errorf("Expected normalized document to be equivalent to the original")
+	}
+	imported := `{
+		"catch_all_action": "DENY",
+		"clusters": [
+			{"id": "5f1", "name": "Database"},
+			{"id": "5f2", "name": "Web", "nodes": [{"ip": "10.0.0.1"}, {"ip": "10.0.0.2"}]}
+		],
+		"inventory_filters": [
+			{"id": "5f3", "name": "Admins", "query": {"field": "ip", "type": "eq", "value": "10.1.0.1"}}
+		],
+		"absolute_policies": [
+			{"id": "5f4", "consumer_filter_id": "5f3", "provider_filter_id": "5f1", "action": "ALLOW", "l4_params": [{"proto": 6, "port": [22, 22]}]}
+		],
+		"default_policies": [
+			{"id": "5f5", "consumer_filter_id": "5f2", "provider_filter_id": "5f1", "action": "ALLOW", "l4_params": [{"proto": 6, "port": [5432, 5432]}]}
+		]
+	}`
+	if !policyDocumentsEquivalent(unitTestPolicyDocument, imported) {
+		t.Errorf("Expected document with ids assigned by the API to be equivalent")
+	}
+	changed := `{"catch_all_action": "ALLOW", "clusters": [], "inventory_filters": [], "absolute_policies": [], "default_policies": []}`
+	if policyDocumentsEquivalent(unitTestPolicyDocument, changed) {
+		t.Errorf("Expected different documents not to be equivalent")
+	}
+}
+
+func TestPolicyDocumentChangesDescribesChanges(t *testing.T) {
+	old, err := policyDocumentFromJSON(unitTestPolicyDocument)
+	if err != nil {
+		t.Fatalf("Error %s decoding document", err)
+	}
+	// Same document with new ids, a node less in the Web cluster, a new
+	// filter, no default policy and a different catch all action
+	new, err := policyDocumentFromJSON(`{
+		"catch_all_action": "ALLOW",
+		"clusters": [
+			{"id": "2", "name": "Database", "nodes": []},
+			{"id": "1", "name": "Web", "nodes": [{"ip": "10.0.0.1"}]}
+		],
+		"inventory_filters": [
+			{"id": "3", "name": "Admins", "query": {"field": "ip", "type": "eq", "value": "10.1.0.1"}},
+			{"id": "4", "name": "Guests", "query": {"field": "ip", "type": "eq", "value": "10.2.0.1"}}
+		],
+		"absolute_policies": [
+			{"consumer_filter_id": "3", "provider_filter_id": "2", "action": "ALLOW", "l4_params": [{"proto": 6, "port": [22, 22]}]}
+		]
+	}`)
+	if err != nil {
+		t.Fatalf("Error %s decoding document", err)
+	}
+	expected := []string{
+		"catch all action changed from DENY to ALLOW",
+		"cluster:Web changed",
+		"default policy from cluster:Web to cluster:Database with action ALLOW removed",
+		"filter:Guests added",
+	}
+	if changes := policyDocumentChanges(old, new); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %q, got %q", expected, changes)
+	}
+}
@@ -1,8 +1,10 @@
 package secureworkload
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	// client "github.com/secureworkload-exchange/terraform-go-sdk"
 )
@@ -19,13 +21,33 @@ func resourceSecureWorkloadPolicy() *schema.Resource {
 			"	 consumer_filter_id = secureworkload_filter.any.id\n" +
 			"	 provider_filter_id = secureworkload_cluster.web.id\n" +
 			"    policy_action = \"ALLOW\"\n" +
+			"    l4_params {\n" +
+			"        protocol   = \"tcp\"\n" +
+			"        start_port = 443\n" +
+			"        end_port   = 443\n" +
+			"    }\n" +
 			"}\n" +
 			"```\n" +
-			"**Note:** If creating multiple rules during a single `terraform apply`, remember to use `depends_on` to chain the rules so that terraform creates it in the same order that you intended.\n",
-		Create: resourceSecureWorkloadPolicyCreate,
-		Update: nil,
-		Read:   resourceSecureWorkloadPolicyRead,
-		Delete: resourceSecureWorkloadPolicyDelete,
+			"**Note:** If creating multiple rules during a single `terraform apply`, remember to use `depends_on` to chain the rules so that terraform creates it in the same order that you intended.\n" +
+			"Ports declared in `l4_params` are kept in sync with the policy, do not also attach ports to a policy with `l4_params` using `secureworkload_port`.\n" +
+			"\n" +
+			"Instead of `start_port` and `end_port`, an `l4_params` block can list several ports with `ports`, e.g. `ports = \"80,443,8000-8100\"`, " +
+			"or reference a named service with `service`, e.g. `service = \"https\"`. Services are looked up in the default catalog of well known " +
+			"services and in the `service` blocks of the provider configuration.\n" +
+			"\n" +
+			"## Import\n" +
+			"Policies can be imported using the workspace ID and the policy ID separated by a slash, `<workspace_id>/<policy_id>`:\n" +
+			"```shell\n" +
+			"terraform import secureworkload_policies.policy1 5f3d4c2e497d4f7c3f8e9a11/5f3d6a0b497d4f0c9d1e2f3a\n" +
+			"```\n",
+		CustomizeDiff: customizePolicyL4ParamsDiff,
+		CreateContext: resourceSecureWorkloadPolicyCreate,
+		UpdateContext: resourceSecureWorkloadPolicyUpdate,
+		ReadContext:   resourceSecureWorkloadPolicyRead,
+		DeleteContext: resourceSecureWorkloadPolicyDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateWithParent("workspace_id"),
+		},
 
 		SchemaVersion: 1,
 
@@ -55,16 +77,18 @@ func resourceSecureWorkloadPolicy() *schema.Resource {
 				Description: "Indicates the version of the workspace the cluster will be added to.",
 			},
 			"rank": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				ForceNew:    true,
-				Description: "Values can be DEFAULT, ABSOLUTE or CATCHALL for ranking",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validateStringInSlice(policyRanks, false),
+				Description:  "Values can be DEFAULT, ABSOLUTE or CATCHALL for ranking",
 			},
 			"policy_action": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "Values can be ALLOW or DENY: means whether we should allow or drop traffic from consumer to provider on the given service port/protocol",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateStringInSlice(policyActions, false),
+				Description:  "Values can be ALLOW or DENY: means whether we should allow or drop traffic from consumer to provider on the given service port/protocol",
 			},
 			"priority": {
 				Type:        schema.TypeInt,
@@ -72,54 +96,93 @@ func resourceSecureWorkloadPolicy() *schema.Resource {
 				ForceNew:    true,
 				Description: "Used to sort policy.",
 			},
-			// "protocol": {
-			// 	Type:        schema.TypeInt,
-			// 	ForceNew:    true,
-			// 	Optional:    true,
-			// 	Description: "Protocol integer value (NULL means all protocols).",
-			// },
-			// "start_port": {
-			// 	Type:        schema.TypeInt,
-			// 	Optional:    true,
-			// 	ForceNew:    true,
-			// 	Description: "Start port of the range.",
-			// },
-			// "end_port": {
-			// 	Type:        schema.TypeInt,
-			// 	Optional:    true,
-			// 	ForceNew:    true,
-			// 	Description: "End port of the range.",
-			// },
-			// "policy_approved": {
-			// 	Type:        schema.TypeBool,
-			// 	Optional:    true,
-			// 	ForceNew:    true,
-			// 	Default:     false,
-			// 	Description: "(Optional) Indicates whether the policy is approved. Default is false.",
-			// },
+			"l4_params": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Protocols and ports the policy applies to.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"protocol": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Default:          "any",
+							ValidateFunc:     validateProtocol,
+							DiffSuppressFunc: suppressEquivalentProtocol,
+							Description:      "Protocol name (any, icmp, tcp, udp or icmpv6) or number. Default is any, which means all protocols.",
+						},
+						"start_port": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validatePort,
+							Description:  "Start port of the range.",
+						},
+						"end_port": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validatePort,
+							Description:  "End port of the range.",
+						},
+						"ports": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validatePorts,
+							Description:  "Comma separated list of ports and port ranges, e.g. 80,443,8000-8100, instead of start_port and end_port.",
+						},
+						"service": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Name of a service of the catalog, e.g. https or ssh, instead of protocol and ports. Services can be added to the catalog in the provider configuration.",
+						},
+						"approved": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "(Optional) Indicates whether the policy is approved. Default is false.",
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
+// customizePolicyL4ParamsDiff checks at plan time that the port ranges of
+// the l4_params blocks do not start after they end and that their ports
+// and services can be expanded, once known.
+func customizePolicyL4ParamsDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("l4_params") {
+		return nil
+	}
+	client, _ := meta.(Client)
+	for i, tfL4Param := range d.Get("l4_params").([]interface{}) {
+		path := fmt.Sprintf("l4_params.%d", i)
+		if tfL4Param == nil || !d.NewValueKnown(path+".ports") || !d.NewValueKnown(path+".service") {
+			continue
+		}
+		tf := tfL4Param.(terraformObject)
+		if err := checkPortRange(path, tf["start_port"].(int), tf["end_port"].(int)); err != nil {
+			return err
+		}
+		if _, err := policyL4ParamFromTerraform(tf, client.services()); err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	return nil
+}
+
 var requiredCreatePolicyParams = []string{"consumer_filter_id", "provider_filter_id", "policy_action"}
 
-func resourceSecureWorkloadPolicyCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadPolicyCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	for _, param := range requiredCreatePolicyParams {
 		if d.Get(param) == "" {
-			return fmt.Errorf("%s is required but was not provided", param)
+			return diag.Errorf("%s is required but was not provided", param)
 		}
 	}
-	// proto := d.Get("protocol").(int)
-	// ranges := [2]int{d.Get("start_port").(int), d.Get("end_port").(int)}
-	// approved := d.Get("policy_approved").(bool)
-	// L4Port := []Layer4Network{
-	// 	{
-	// 		Protocol:  proto,
-	// 		PortRange: ranges,
-	// 		Approved:  approved,
-	// 	},
-	// }
+	l4Params, err := policyL4ParamsFromTerraform(d.Get("l4_params").([]interface{}), client.services())
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	createPolicyParams := CreatePolicyRequest{
 		ConsumerId: d.Get("consumer_filter_id").(string),
 		ProviderId: d.Get("provider_filter_id").(string),
@@ -127,21 +190,24 @@ func resourceSecureWorkloadPolicyCreate(d *schema.ResourceData, meta interface{}
 		Rank:       d.Get("rank").(string),
 		Action:     d.Get("policy_action").(string),
 		Priority:   d.Get("priority").(int),
-		// Layer4NetworkPolicies: L4Port,
 	}
 	policy, err := client.CreatePolicy(createPolicyParams, d.Get("workspace_id").(string))
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.SetId(policy.Id)
-	return nil
+	err = reconcileLayer4NetworkPolicies(client, createPolicyParams.Version, policy, l4Params)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceSecureWorkloadPolicyRead(ctx, d, meta)
 }
 
-func resourceSecureWorkloadPolicyRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
+func resourceSecureWorkloadPolicyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
 	policy, err := client.DescribePolicy(d.Id())
 	if err != nil {
-		return err
+		return diag.FromErr(removeIfNotFound(d, err))
 	}
 	d.Set("consumer_filter_id", policy.ConsumerId)
 	d.Set("provider_filter_id", policy.ProviderId)
@@ -149,10 +215,115 @@ func resourceSecureWorkloadPolicyRead(d *schema.ResourceData, meta interface{})
 	d.Set("rank", policy.Rank)
 	d.Set("policy_action", policy.Action)
 	d.Set("priority", policy.Priority)
-	return nil
+	return diag.FromErr(d.Set("l4_params", refreshPolicyL4Params(d.Get("l4_params").([]interface{}), policy.L4Params, client.services())))
+}
+
+func resourceSecureWorkloadPolicyUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	if d.HasChange("l4_params") {
+		l4Params, err := policyL4ParamsFromTerraform(d.Get("l4_params").([]interface{}), client.services())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		policy, err := client.DescribePolicy(d.Id())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		err = reconcileLayer4NetworkPolicies(client, d.Get("version").(string), policy, l4Params)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return resourceSecureWorkloadPolicyRead(ctx, d, meta)
+}
+
+func resourceSecureWorkloadPolicyDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(Client).WithContext(ctx)
+	return diag.FromErr(client.DeletePolicy(d.Get("workspace_id").(string), d.Id()))
+}
+
+func policyL4ParamsFromTerraform(tfL4Params []interface{}, services serviceCatalog) ([]Layer4NetworkPolicy, error) {
+	var l4Params []Layer4NetworkPolicy
+	for i, tfL4Param := range tfL4Params {
+		if tfL4Param == nil {
+			continue
+		}
+		expanded, err := policyL4ParamFromTerraform(tfL4Param.(terraformObject), services)
+		if err != nil {
+			return nil, fmt.Errorf("l4_params.%d: %s", i, err)
+		}
+		l4Params = append(l4Params, expanded...)
+	}
+	return l4Params, nil
+}
+
+// policyL4ParamFromTerraform returns the layer 4 parameters of an l4_params
+// block, one for its start and end ports or one per port range of its ports
+// or service.
+func policyL4ParamFromTerraform(tf terraformObject, services serviceCatalog) ([]Layer4NetworkPolicy, error) {
+	startPort, endPort := tf["start_port"].(int), tf["end_port"].(int)
+	ports, service := tf["ports"].(string), tf["service"].(string)
+	approved := tf["approved"].(bool)
+	switch {
+	case service != "":
+		if ports != "" || startPort != 0 || endPort != 0 {
+			return nil, fmt.Errorf("service can not be combined with ports, start_port or end_port")
+		}
+		if err := checkServiceProtocol(tf["protocol"].(string)); err != nil {
+			return nil, err
+		}
+		return services.layer4NetworkPolicies(service, approved)
+	case ports != "":
+		if startPort != 0 || endPort != 0 {
+			return nil, fmt.Errorf("ports can not be combined with start_port or end_port")
+		}
+		return layer4NetworkPoliciesForPorts(tf["protocol"].(string), ports, approved)
+	}
+	protocol, err := protocolNumber(tf["protocol"].(string))
+	if err != nil {
+		return nil, err
+	}
+	if startPort > endPort {
+		return nil, fmt.Errorf("start_port %d must not be greater than end_port %d", startPort, endPort)
+	}
+	return []Layer4NetworkPolicy{{
+		Protocol:  protocol,
+		PortRange: [2]int{startPort, endPort},
+		Approved:  approved,
+	}}, nil
 }
 
-func resourceSecureWorkloadPolicyDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(Client)
-	return client.DeletePolicy(d.Get("workspace_id").(string), d.Id())
+// refreshPolicyL4Params returns the l4_params blocks of a policy from the
+// layer 4 parameters returned by the API, keeping the order and protocol
+// spelling of the blocks already in state and appending the ones added
+// outside of terraform. Policies without l4_params are left alone, as
+// their ports may be managed with secureworkload_port.
+func refreshPolicyL4Params(tfL4Params []interface{}, current []PolicyL4Param, services serviceCatalog) []interface{} {
+	if len(tfL4Params) == 0 {
+		return tfL4Params
+	}
+	remaining := newLayer4Pool(current)
+	refreshed := []interface{}{}
+	for _, tfL4Param := range tfL4Params {
+		if tfL4Param == nil {
+			continue
+		}
+		tf := tfL4Param.(terraformObject)
+		l4Params, err := policyL4ParamFromTerraform(tf, services)
+		if err != nil || !remaining.claim(l4Params) {
+			continue
+		}
+		refreshed = append(refreshed, tf)
+	}
+	for _, l4Param := range remaining.unclaimed(current) {
+		refreshed = append(refreshed, terraformObject{
+			"protocol":   protocolToTerraform(configuredProtocol(tfL4Params, l4Param.Proto), l4Param.Proto),
+			"start_port": l4Param.Port[0],
+			"end_port":   l4Param.Port[1],
+			"ports":      "",
+			"service":    "",
+			"approved":   l4Param.Approved,
+		})
+	}
+	return refreshed
 }
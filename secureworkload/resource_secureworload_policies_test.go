@@ -0,0 +1,115 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestRefreshPolicyL4ParamsDetectsDrift(t *testing.T) {
+	inState := []interface{}{
+		terraformObject{"protocol": "6", "start_port": 443, "end_port": 443, "ports": "", "service": "", "approved": false},
+		terraformObject{"protocol": "17", "start_port": 53, "end_port": 53, "ports": "", "service": "", "approved": false},
+	}
+	current := []PolicyL4Param{
+		{Id: "1", Proto: 6, Port: [2]int{443, 443}},
+		{Id: "2", Proto: 6, Port: [2]int{22, 22}, Approved: true},
+	}
+	expected := []interface{}{
+		terraformObject{"protocol": "6", "start_port": 443, "end_port": 443, "ports": "", "service": "", "approved": false},
+		terraformObject{"protocol": "6", "start_port": 22, "end_port": 22, "ports": "", "service": "", "approved": true},
+	}
+	if refreshed := refreshPolicyL4Params(inState, current, Client{}.services()); !reflect.DeepEqual(refreshed, expected) {
+		t.Errorf("Expected %v, got %v", expected, refreshed)
+	}
+	if refreshed := refreshPolicyL4Params([]interface{}{}, current, Client{}.services()); len(refreshed) != 0 {
+		t.Errorf("Expected policies without l4_params to be left alone, got %v", refreshed)
+	}
+}
+
+func TestPolicyL4ParamsFromTerraformRejectsReversedRanges(t *testing.T) {
+	_, err := policyL4ParamsFromTerraform([]interface{}{
+		terraformObject{"protocol": "tcp", "start_port": 8100, "end_port": 8000, "ports": "", "service": "", "approved": false},
+	}, Client{}.services())
+	if err == nil {
+		t.Errorf("Expected reversed port range to be rejected")
+	}
+}
+
+func TestPolicyL4ParamsFromTerraformExpandsPortsAndServices(t *testing.T) {
+	client := Client{Config: Config{Services: map[string][]ServicePorts{
+		"ssh":    {{Protocol: "tcp", Ports: "2222"}},
+		"statsd": {{Protocol: "udp", Ports: "8125"}, {Protocol: "tcp", Ports: "8125-8126"}},
+	}}}
+	l4Params, err := policyL4ParamsFromTerraform([]interface{}{
+		terraformObject{"protocol": "tcp", "start_port": 0, "end_port": 0, "ports": "443,80,8000-8100,8050-8200", "service": "", "approved": false},
+		terraformObject{"protocol": "any", "start_port": 0, "end_port": 0, "ports": "", "service": "ssh", "approved": true},
+		terraformObject{"protocol": "any", "start_port": 0, "end_port": 0, "ports": "", "service": "statsd", "approved": false},
+		terraformObject{"protocol": "any", "start_port": 0, "end_port": 0, "ports": "", "service": "https", "approved": false},
+	}, client.services())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Layer4NetworkPolicy{
+		{Protocol: 6, PortRange: [2]int{80, 80}},
+		{Protocol: 6, PortRange: [2]int{443, 443}},
+		{Protocol: 6, PortRange: [2]int{8000, 8200}},
+		{Protocol: 6, PortRange: [2]int{2222, 2222}, Approved: true},
+		{Protocol: 17, PortRange: [2]int{8125, 8125}},
+		{Protocol: 6, PortRange: [2]int{8125, 8126}},
+		{Protocol: 6, PortRange: [2]int{443, 443}},
+	}
+	if !reflect.DeepEqual(l4Params, expected) {
+		t.Errorf("Expected %v, got %v", expected, l4Params)
+	}
+}
+
+func TestPolicyL4ParamsFromTerraformRejectsInvalidPortsAndServices(t *testing.T) {
+	for _, test := range []struct {
+		l4Param terraformObject
+		error   string
+	}{
+		{
+			l4Param: terraformObject{"protocol": "tcp", "start_port": 80, "end_port": 80, "ports": "443", "service": ""},
+			error:   "l4_params.0: ports can not be combined with start_port or end_port",
+		},
+		{
+			l4Param: terraformObject{"protocol": "any", "start_port": 0, "end_port": 0, "ports": "443", "service": "https"},
+			error:   "service can not be combined with ports, start_port or end_port",
+		},
+		{
+			l4Param: terraformObject{"protocol": "udp", "start_port": 0, "end_port": 0, "ports": "", "service": "https"},
+			error:   `protocol "udp" can not be combined with service`,
+		},
+		{
+			l4Param: terraformObject{"protocol": "any", "start_port": 0, "end_port": 0, "ports": "", "service": "gopher"},
+			error:   `unknown service "gopher", known services are dns, ftp`,
+		},
+	} {
+		test.l4Param["approved"] = false
+		_, err := policyL4ParamsFromTerraform([]interface{}{test.l4Param}, Client{}.services())
+		if err == nil || !strings.Contains(err.Error(), test.error) {
+			t.Errorf("Expected error %q expanding %v, got %v", test.error, test.l4Param, err)
+		}
+	}
+}
+
+func TestUnknownServicesAreRejectedWhenPlanning(t *testing.T) {
+	_, err := resourceSecureWorkloadPolicy().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"workspace_id":       "1234",
+		"consumer_filter_id": "1",
+		"provider_filter_id": "2",
+		"policy_action":      "ALLOW",
+		"l4_params": []interface{}{
+			map[string]interface{}{"service": "gopher"},
+		},
+	}), nil)
+	if err == nil || !strings.Contains(err.Error(), `l4_params.0: unknown service "gopher"`) {
+		t.Errorf("Expected unknown services to be rejected, got %v", err)
+	}
+}
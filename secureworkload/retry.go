@@ -1,6 +1,15 @@
 package secureworkload
 
 import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -9,23 +18,118 @@ import (
 // bool for readiness and error (if any).
 type Ready func() bool
 
-// Await waits until the ready function is ready
-// or errors, returning success and error (if any).
-// To stop waiting, send on the stop channel.z
-// It checks if the function is ready once and then retries
-// the specified number of times with an exponential backoff between each attempt
-func Await(ready Ready, maxRetries int) bool {
-	for tries := 0; tries <= maxRetries; tries++ {
-		success := ready()
-		if !success {
-			if tries != maxRetries {
-				// exponentially back off before the next attempt
-				// https://github.com/adonovan/gopl.io/blob/77e9f810f3c2502e9c641b97e09f9721424090f5/ch5/wait/wait.go#L30
-				time.Sleep((1 * time.Second) << uint(tries))
-			}
-			continue
+// retryBackoff returns the wait before the next attempt of a request
+// that already failed attempt+1 times, using full jitter over an
+// exponential backoff starting at one second and capped at maxWait.
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+func retryBackoff(attempt int, maxWait time.Duration) time.Duration {
+	backoff := maxWait
+	if attempt < 30 {
+		if exp := (1 * time.Second) << uint(attempt); exp < maxWait {
+			backoff = exp
 		}
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// retryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date, returning the wait it asks for
+// and whether the header held a usable value.
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+	if wait := at.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// isIdempotentMethod reports whether sending a request with
+// the given method more than once has the same effect as sending it once.
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRetryableStatus reports whether a response with the given status
+// code should be retried. Rate limited requests were never processed
+// so they are always retried, server errors only for idempotent methods.
+func isRetryableStatus(method string, statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests:
 		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotentMethod(method)
 	}
 	return false
 }
+
+// isRetryableTransportError reports whether a request that failed
+// without a response should be retried. Refused connections never
+// reached the API and are always retried, other connection failures
+// such as resets only for idempotent methods.
+func isRetryableTransportError(method string, err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	if !isIdempotentMethod(method) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// AwaitTimeout waits until the ready function is ready or the
+// timeout elapses, returning whether it became ready.
+// It checks if the function is ready once and then retries
+// with an exponential backoff capped at maxInterval between each attempt.
+func AwaitTimeout(ready Ready, timeout time.Duration, maxInterval time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return AwaitContext(ctx, ready, maxInterval)
+}
+
+// AwaitContext is like AwaitTimeout, but waits until the ready function
+// is ready or ctx is done, e.g. when the timeout of a terraform operation
+// elapses or terraform is interrupted.
+func AwaitContext(ctx context.Context, ready Ready, maxInterval time.Duration) bool {
+	for tries := 0; ; tries++ {
+		if ready() {
+			return true
+		}
+		interval := maxInterval
+		if tries < 30 {
+			if backoff := (1 * time.Second) << uint(tries); backoff < maxInterval {
+				interval = backoff
+			}
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package secureworkload
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	// "github.com/secureworkload-exchange/terraform-go-sdk/signer"
+	"terraform-provider-secureworkload/secureworkload/signer"
+)
+
+var (
+	AnnotationsAPIV1BasePath = fmt.Sprintf("%s/assets/cmdb", SecureWorkloadAPIV1BasePath)
+)
+
+const (
+	// AnnotationsOperationAdd adds the uploaded annotations, replacing
+	// all the annotations of the addresses that are already annotated.
+	AnnotationsOperationAdd = "add"
+	// AnnotationsOperationMerge merges the uploaded annotations into the
+	// existing ones, empty values keep the existing value of their key.
+	AnnotationsOperationMerge = "merge"
+	// AnnotationsOperationDelete deletes the annotations of the uploaded addresses.
+	AnnotationsOperationDelete = "delete"
+
+	// Column holding the address or subnet of an annotation.
+	annotationsIPColumn = "IP"
+	// Column holding the VRF of an annotation in downloaded annotations.
+	annotationsVRFColumn = "VRF"
+	// Form field selecting the operation of an upload.
+	annotationsOperationField = "X-Tetration-Oper"
+
+	// MaxAnnotationKeys is the largest number of annotation keys of a root scope.
+	MaxAnnotationKeys = 32
+)
+
+// Annotation holds the user annotations, also known as labels,
+// of an address or subnet in a root scope.
+type Annotation struct {
+	// IPv4/IPv6 address or subnet.
+	Ip string
+	// Key/value map of the annotations, without empty values.
+	Attributes map[string]string
+}
+
+// UploadAnnotations uploads annotations for a root scope as a single CSV
+// file, applying the given operation, one of the AnnotationsOperation
+// constants, to all of them at once.
+func (c Client) UploadAnnotations(rootScopeName string, operation string, annotations []Annotation) error {
+	// Uploads add the keys of their columns
+	defer c.invalidateLookups(annotationKeyLookups)
+	var file bytes.Buffer
+	if err := writeAnnotationsCSV(&file, annotations); err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField(annotationsOperationField, operation); err != nil {
+		return err
+	}
+	part, err := form.CreateFormFile("file", "annotations.csv")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(file.Bytes()); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+	url := c.Config.APIURL + AnnotationsAPIV1BasePath + fmt.Sprintf("/upload/%s", rootScopeName)
+	request, err := http.NewRequestWithContext(c.Context(), http.MethodPost, url, &body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set(signer.ContentTypeHeaderKey, form.FormDataContentType())
+	return c.Do(request, nil)
+}
+
+// DownloadAnnotations returns all the annotations of a root scope,
+// sorted by address.
+func (c Client) DownloadAnnotations(rootScopeName string) ([]Annotation, error) {
+	url := c.Config.APIURL + AnnotationsAPIV1BasePath + fmt.Sprintf("/download/%s", rootScopeName)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	var annotations annotationsCSV
+	if err := c.Do(request, &annotations); err != nil {
+		return nil, err
+	}
+	return annotations, nil
+}
+
+// annotationsCSV decodes downloaded annotations from the CSV response body.
+type annotationsCSV []Annotation
+
+func (annotations *annotationsCSV) decodeBody(body io.Reader) error {
+	decoded, err := readAnnotationsCSV(body)
+	if err != nil {
+		return err
+	}
+	*annotations = decoded
+	return nil
+}
+
+// writeAnnotationsCSV writes annotations as CSV, with the IP column
+// followed by one column per key, sorted, in the upload format.
+func writeAnnotationsCSV(w io.Writer, annotations []Annotation) error {
+	keys := map[string]bool{}
+	for _, annotation := range annotations {
+		for key := range annotation.Attributes {
+			keys[key] = true
+		}
+	}
+	header := []string{annotationsIPColumn}
+	for key := range keys {
+		header = append(header, key)
+	}
+	sort.Strings(header[1:])
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, annotation := range annotations {
+		record := []string{annotation.Ip}
+		for _, key := range header[1:] {
+			record = append(record, annotation.Attributes[key])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// readAnnotationsCSV reads annotations from CSV with a header naming
+// the IP column and the keys, leaving out empty values and the VRF
+// column of downloaded annotations. The annotations are sorted by address.
+func readAnnotationsCSV(r io.Reader) ([]Annotation, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []Annotation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Spreadsheet exports often start with a byte order mark
+	ipColumn := -1
+	for i, column := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		if strings.EqualFold(header[i], annotationsIPColumn) {
+			ipColumn = i
+		}
+	}
+	if ipColumn < 0 {
+		return nil, fmt.Errorf("annotations have no %s column, got columns %v", annotationsIPColumn, header)
+	}
+	annotations := []Annotation{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ipColumn >= len(record) || strings.TrimSpace(record[ipColumn]) == "" {
+			continue
+		}
+		annotation := Annotation{
+			Ip:         strings.TrimSpace(record[ipColumn]),
+			Attributes: map[string]string{},
+		}
+		for i, value := range record {
+			if i == ipColumn || i >= len(header) || strings.EqualFold(header[i], annotationsVRFColumn) || value == "" {
+				continue
+			}
+			annotation.Attributes[header[i]] = value
+		}
+		annotations = append(annotations, annotation)
+	}
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return annotations[i].Ip < annotations[j].Ip
+	})
+	return annotations, nil
+}
+
+// AnnotationKey is a key of the user annotations of a root scope,
+// also known as a label key.
+type AnnotationKey struct {
+	Name string `json:"name"`
+	// Whether inventory and flows can be searched by the key.
+	Enabled bool `json:"enabled"`
+}
+
+// UnmarshalJSON decodes an annotation key, accepting the bare
+// names of enabled keys returned by older clusters.
+func (key *AnnotationKey) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*key = AnnotationKey{Name: name, Enabled: true}
+		return nil
+	}
+	type annotationKey AnnotationKey
+	return json.Unmarshal(data, (*annotationKey)(key))
+}
+
+// ListAnnotationKeys lists the annotation keys of a root scope,
+// returning the listed keys and error (if any).
+func (c Client) ListAnnotationKeys(rootScopeName string) ([]AnnotationKey, error) {
+	url := c.Config.APIURL + AnnotationsAPIV1BasePath + fmt.Sprintf("/annotations/%s", rootScopeName)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	var keys []AnnotationKey
+	if err := c.Do(request, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// SetAnnotationKey creates an annotation key of a root scope, or
+// enables or disables it for search when it already exists,
+// returning error (if any).
+func (c Client) SetAnnotationKey(rootScopeName string, key AnnotationKey) error {
+	defer c.invalidateLookups(annotationKeyLookups)
+	url := c.Config.APIURL + AnnotationsAPIV1BasePath + fmt.Sprintf("/annotations/%s/%s", rootScopeName, url.PathEscape(key.Name))
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPut, url, map[string]bool{"enabled": key.Enabled})
+	if err != nil {
+		return err
+	}
+	return c.Do(request, nil)
+}
+
+// DeleteAnnotationKey deletes an annotation key of a root scope along
+// with its values for every address, returning error (if any).
+func (c Client) DeleteAnnotationKey(rootScopeName string, name string) error {
+	defer c.invalidateLookups(annotationKeyLookups)
+	url := c.Config.APIURL + AnnotationsAPIV1BasePath + fmt.Sprintf("/annotations/%s/%s", rootScopeName, url.PathEscape(name))
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, nil)
+}
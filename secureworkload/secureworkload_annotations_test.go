@@ -0,0 +1,93 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUploadAnnotationsSendsCSVFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != AnnotationsAPIV1BasePath+"/upload/acme" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+		if operation := r.FormValue(annotationsOperationField); operation != AnnotationsOperationMerge {
+			t.Errorf("Expected the merge operation, got %q", operation)
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Error %s reading the uploaded file", err)
+		}
+		content, _ := ioutil.ReadAll(file)
+		expected := "IP,Environment,Owner\n10.0.0.1,production,payments\n10.0.1.0/24,test,\n"
+		if string(content) != expected {
+			t.Errorf("Expected file %q, got %q", expected, content)
+		}
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	err := client.UploadAnnotations("acme", AnnotationsOperationMerge, []Annotation{
+		{Ip: "10.0.0.1", Attributes: map[string]string{"Owner": "payments", "Environment": "production"}},
+		{Ip: "10.0.1.0/24", Attributes: map[string]string{"Environment": "test"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDownloadAnnotationsParsesCSVFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != AnnotationsAPIV1BasePath+"/download/acme" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("\ufeffIP,VRF,Environment,Owner\n10.0.1.0/24,acme,test,\n10.0.0.1,acme,production,\"payments, EMEA\"\n"))
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	annotations, err := client.DownloadAnnotations("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Annotation{
+		{Ip: "10.0.0.1", Attributes: map[string]string{"Environment": "production", "Owner": "payments, EMEA"}},
+		{Ip: "10.0.1.0/24", Attributes: map[string]string{"Environment": "test"}},
+	}
+	if !reflect.DeepEqual(annotations, expected) {
+		t.Errorf("Expected %v, got %v", expected, annotations)
+	}
+}
+
+func TestReadAnnotationsCSVRequiresIPColumn(t *testing.T) {
+	if _, err := readAnnotationsCSV(strings.NewReader("Address,Environment\n10.0.0.1,test\n")); err == nil {
+		t.Errorf("Expected annotations without an IP column to be rejected")
+	}
+	annotations, err := readAnnotationsCSV(strings.NewReader(""))
+	if err != nil || len(annotations) != 0 {
+		t.Errorf("Expected no annotations from an empty file, got %v, %v", annotations, err)
+	}
+}
+
+func TestListAnnotationKeysAcceptsBareNames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != AnnotationsAPIV1BasePath+"/annotations/acme" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+		w.Write([]byte(`["Environment", {"name": "Owner", "enabled": false}]`))
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	keys, err := client.ListAnnotationKeys("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []AnnotationKey{{Name: "Environment", Enabled: true}, {Name: "Owner", Enabled: false}}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+}
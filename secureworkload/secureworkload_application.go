@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"terraform-provider-secureworkload/secureworkload/signer"
 	// "github.com/secureworkload-exchange/terraform-go-sdk/signer"
 )
@@ -102,6 +103,8 @@ type Node struct {
 
 // Policy describes an application level policy, either absolute or default.
 type Policy struct {
+	// Unique identifier for the policy, only set by the API.
+	Id string `json:"id,omitempty"`
 	// ID of a cluster, user inventory filter, or application scope.
 	ConsumerFilterId string `json:"consumer_filter_id"`
 	// ID of a cluster, user inventory filter, or application scope.
@@ -125,7 +128,7 @@ type Layer4NetworkPolicy struct {
 func (c Client) GetApplicationByParam(getUrl string) ([]Application, error) {
 	var scope []Application
 	url := c.Config.APIURL + ApplicationsAPIV1BasePath + getUrl
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return scope, err
 	}
@@ -135,9 +138,10 @@ func (c Client) GetApplicationByParam(getUrl string) ([]Application, error) {
 // CreateApplication creates a application with
 // the specified params, returning the created application and error (if any).
 func (c Client) CreateApplication(params CreateApplicationRequest) (Application, error) {
+	defer c.invalidateLookups(workspaceLookups)
 	var application Application
 	url := c.Config.APIURL + ApplicationsAPIV1BasePath
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return application, err
 	}
@@ -161,7 +165,7 @@ func (c Client) DescribeApplication(params DescribeApplicationRequest) (Applicat
 	if params.Version != "" {
 		url += fmt.Sprintf("/versions/%s", params.Version)
 	}
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return application, err
 	}
@@ -169,10 +173,108 @@ func (c Client) DescribeApplication(params DescribeApplicationRequest) (Applicat
 	return application, err
 }
 
+// ApplicationPolicyDocument wraps the clusters, filters and policies
+// of an application version, as exported from and imported into Secure Workload.
+type ApplicationPolicyDocument struct {
+	// Groups of nodes used to define policies.
+	Clusters []Cluster `json:"clusters"`
+	// Filters on data center assets.
+	Filters []PolicyFilter `json:"inventory_filters"`
+	// Ordered policies with the absolute rank.
+	AbsolutePolicies []Policy `json:"absolute_policies"`
+	// Ordered policies with the default rank.
+	DefaultPolicies []Policy `json:"default_policies"`
+	// “ALLOW” or “DENY”
+	CatchAllAction string `json:"catch_all_action"`
+}
+
+// ApplicationDetails wraps an application together with the clusters,
+// filters and policies of one of its versions.
+type ApplicationDetails struct {
+	Application
+	ApplicationPolicyDocument
+	// The p* or v* version the details belong to.
+	Version string `json:"version"`
+}
+
+// DescribeApplicationDetails describes the clusters, filters and policies
+// of an application by id and version (defaulting to latest)
+// returning the application details and error (if any).
+func (c Client) DescribeApplicationDetails(params DescribeApplicationRequest) (ApplicationDetails, error) {
+	var details ApplicationDetails
+	url := c.Config.APIURL + ApplicationsAPIV1BasePath + fmt.Sprintf("/%s/details", params.ApplicationId)
+	if params.Version != "" {
+		url += fmt.Sprintf("?version=%s", params.Version)
+	}
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		return details, err
+	}
+	err = c.Do(request, &details)
+	return details, err
+}
+
+// ImportApplicationPolicies imports the clusters, filters and policies
+// of a policy document into a application, replacing the ones of its
+// latest version and returning error (if any).
+func (c Client) ImportApplicationPolicies(applicationId string, document ApplicationPolicyDocument) error {
+	url := c.Config.APIURL + ApplicationsAPIV1BasePath + fmt.Sprintf("/%s/import", applicationId)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, document)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, nil)
+}
+
+// UpdateApplicationRequest wraps parameters for making a request to update a application.
+type UpdateApplicationRequest struct {
+	// (Optional) User-specified name for the application.
+	Name string `json:"name,omitempty"`
+	// (Optional) User-specified description of the application.
+	Description string `json:"description"`
+	// (Optional) Set to true to indicate this application is primary for the given scope.
+	Primary bool `json:"primary"`
+}
+
+// UpdateApplication updates the metadata of a application by id,
+// returning the updated application and error (if any).
+func (c Client) UpdateApplication(applicationId string, params UpdateApplicationRequest) (Application, error) {
+	defer c.invalidateLookups(workspaceLookups)
+	var application Application
+	url := c.Config.APIURL + ApplicationsAPIV1BasePath + fmt.Sprintf("/%s", applicationId)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPut, url, params)
+	if err != nil {
+		return application, err
+	}
+	err = c.Do(request, &application)
+	return application, err
+}
+
+// UpdateCatchAllRequest wraps parameters for making a request
+// to update the catch all action of a application.
+type UpdateCatchAllRequest struct {
+	// (Optional) The p* version to update; defaults to latest.
+	Version string `json:"version,omitempty"`
+	// “ALLOW” or “DENY”
+	Action string `json:"policy_action"`
+}
+
+// UpdateCatchAll updates the action applied to traffic not matching
+// any policy of a application, returning error (if any).
+func (c Client) UpdateCatchAll(applicationId string, params UpdateCatchAllRequest) error {
+	url := c.Config.APIURL + ApplicationsAPIV1BasePath + fmt.Sprintf("/%s/catch_all", applicationId)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPut, url, params)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, nil)
+}
+
 // DeleteApplication deletes a application by id returning error (if any).
 func (c Client) DeleteApplication(applicationId string) error {
+	defer c.invalidateLookups(workspaceLookups)
 	url := c.Config.APIURL + ApplicationsAPIV1BasePath + fmt.Sprintf("/%s", applicationId)
-	request, err := signer.CreateJSONRequest(http.MethodDelete, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
@@ -183,18 +285,32 @@ func (c Client) DeleteApplication(applicationId string) error {
 // credentials for the given client, returning
 // the listed applications and error (if any)
 func (c Client) ListApplications(app_scope_id string) ([]Application, error) {
-	var applications []Application
-	appendUrl := "?app_scope_id=" + app_scope_id
-	url := c.Config.APIURL + ApplicationsAPIV1BasePath + appendUrl
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return applications, err
-	}
-	err = c.Do(request, &applications)
+	applications, err := collect(c.IterateApplications(app_scope_id, ListOptions{}))
 	for _, application := range applications {
 		if application.Primary {
 			return applications, err
 		}
 	}
-	return nil , err
+	return nil, err
+}
+
+// IterateApplications returns an iterator over the applications of the
+// given scope, or of all scopes when empty, readable by the API
+// credentials for the given client. Each call sends a single GET,
+// whose response is decoded as a stream.
+// Applications are filtered by name while decoding the response.
+func (c Client) IterateApplications(appScopeId string, options ListOptions) *ListIterator[Application] {
+	endpoint := listEndpoint[Application]{
+		url:   c.Config.APIURL + ApplicationsAPIV1BasePath,
+		query: url.Values{},
+	}
+	if appScopeId != "" {
+		endpoint.query.Set("app_scope_id", appScopeId)
+	}
+	if options.Name != "" {
+		endpoint.match = func(application Application) bool {
+			return application.Name == options.Name
+		}
+	}
+	return newListIterator(c, endpoint)
 }
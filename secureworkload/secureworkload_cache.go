@@ -0,0 +1,198 @@
+package secureworkload
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// lookupKind is a kind of object whose list is cached
+// for resolving names to IDs.
+type lookupKind string
+
+const (
+	scopeLookups     lookupKind = "scopes"
+	filterLookups    lookupKind = "filters"
+	roleLookups      lookupKind = "roles"
+	userLookups      lookupKind = "users"
+	workspaceLookups lookupKind = "workspaces"
+	// Annotation keys are cached per root scope.
+	annotationKeyLookups lookupKind = "annotation_keys"
+)
+
+// sharedLookupCache is the lookup cache of the clients created by New.
+// The provider process only lives for a single plan or apply, and both
+// halves of the muxed provider create a client, so caching for the life
+// of the process caches for one plan or apply across all resources and
+// data sources.
+var sharedLookupCache = newLookupCache()
+
+// lookupKey identifies one cached list.
+type lookupKey struct {
+	// The API URL and key the list was fetched with.
+	tenant string
+	kind   lookupKind
+	// Query parameters the list was fetched with, if any.
+	query string
+}
+
+// lookupEntry holds a list once it has been fetched.
+type lookupEntry struct {
+	// done is closed once items and err are set.
+	done  chan struct{}
+	items interface{}
+	err   error
+}
+
+// lookupCache caches lists of objects for name to ID lookups. It is safe
+// for concurrent use, and concurrent lookups of the same list share a
+// single request. Lists are invalidated by the mutations made through
+// the client, see Client.invalidateLookups.
+type lookupCache struct {
+	mutex   sync.Mutex
+	entries map[lookupKey]*lookupEntry
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{entries: map[lookupKey]*lookupEntry{}}
+}
+
+// cachedLookup returns the list of the given kind from the cache of the
+// client, calling list to fetch it when it has not been cached yet.
+// Failed lists are not cached.
+func cachedLookup[T any](c Client, kind lookupKind, query url.Values, list func() ([]T, error)) ([]T, error) {
+	if c.cache == nil {
+		return list()
+	}
+	key := lookupKey{tenant: c.tenant(), kind: kind, query: query.Encode()}
+	for {
+		c.cache.mutex.Lock()
+		entry, cached := c.cache.entries[key]
+		if !cached {
+			entry = &lookupEntry{done: make(chan struct{})}
+			c.cache.entries[key] = entry
+		}
+		c.cache.mutex.Unlock()
+		if !cached {
+			entry.items, entry.err = list()
+			if entry.err != nil {
+				c.cache.remove(key, entry)
+			}
+			close(entry.done)
+		} else {
+			select {
+			case <-entry.done:
+			case <-c.Context().Done():
+				return nil, c.Context().Err()
+			}
+			// Fetch the list again if the request
+			// another lookup was waiting on failed
+			if entry.err != nil {
+				continue
+			}
+		}
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		// Copy the list so callers can't modify the cached one
+		return append([]T(nil), entry.items.([]T)...), nil
+	}
+}
+
+// remove removes an entry from the cache, unless
+// it has already been replaced by another one.
+func (cache *lookupCache) remove(key lookupKey, entry *lookupEntry) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.entries[key] == entry {
+		delete(cache.entries, key)
+	}
+}
+
+// tenant identifies the tenant and credentials of the client in the cache.
+func (c Client) tenant() string {
+	return c.Config.APIURL + " " + c.Config.APIKey
+}
+
+// invalidateLookups removes the cached lists of the given
+// kinds, after they have been changed through the client.
+func (c Client) invalidateLookups(kinds ...lookupKind) {
+	if c.cache == nil {
+		return
+	}
+	tenant := c.tenant()
+	c.cache.mutex.Lock()
+	defer c.cache.mutex.Unlock()
+	for key := range c.cache.entries {
+		for _, kind := range kinds {
+			if key.tenant == tenant && key.kind == kind {
+				delete(c.cache.entries, key)
+			}
+		}
+	}
+}
+
+// LookupScopes returns all scopes readable by the API credentials for the
+// given client, listing them at most once per plan or apply.
+func (c Client) LookupScopes() ([]Scope, error) {
+	return cachedLookup(c, scopeLookups, nil, c.ListScopes)
+}
+
+// LookupFilters returns all filters readable by the API credentials for
+// the given client, listing them at most once per plan or apply.
+func (c Client) LookupFilters() ([]Filter, error) {
+	return cachedLookup(c, filterLookups, nil, c.ListFilters)
+}
+
+// LookupRoles returns all roles readable by the API credentials for the
+// given client, listing them at most once per plan or apply.
+func (c Client) LookupRoles() ([]Role, error) {
+	return cachedLookup(c, roleLookups, nil, c.ListRoles)
+}
+
+// LookupUsers returns the users matching params, listing
+// them at most once per plan or apply.
+func (c Client) LookupUsers(params ListUsersRequest) ([]User, error) {
+	return cachedLookup(c, userLookups, params.query(), func() ([]User, error) {
+		return c.ListUsers(params)
+	})
+}
+
+// LookupApplications returns all workspaces readable by the API credentials
+// for the given client, listing them at most once per plan or apply.
+func (c Client) LookupApplications() ([]Application, error) {
+	return cachedLookup(c, workspaceLookups, nil, func() ([]Application, error) {
+		return collect(c.IterateApplications("", ListOptions{}))
+	})
+}
+
+// LookupRootScopeName returns the name of the root scope readable by the
+// API credentials for the given client, for the objects whose root scope
+// is not configured. It fails unless exactly one root scope is readable.
+func (c Client) LookupRootScopeName() (string, error) {
+	scopes, err := c.LookupScopes()
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, scope := range scopes {
+		if scope.ParentAppScopeId == "" {
+			names = append(names, scope.Name)
+		}
+	}
+	if len(names) != 1 {
+		sort.Strings(names)
+		return "", fmt.Errorf("expected the API credentials to read a single root scope, found %d: %s", len(names), strings.Join(names, ", "))
+	}
+	return names[0], nil
+}
+
+// LookupAnnotationKeys returns the annotation keys of a root
+// scope, listing them at most once per plan or apply.
+func (c Client) LookupAnnotationKeys(rootScopeName string) ([]AnnotationKey, error) {
+	return cachedLookup(c, annotationKeyLookups, url.Values{"root_scope_name": {rootScopeName}}, func() ([]AnnotationKey, error) {
+		return c.ListAnnotationKeys(rootScopeName)
+	})
+}
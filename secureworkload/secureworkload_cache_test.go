@@ -0,0 +1,140 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLookupScopesListsOnceForConcurrentLookups(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		fmt.Fprint(w, `[{"id": "1", "name": "Root", "short_name": "Root"}]`)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = newLookupCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scopes, err := client.LookupScopes()
+			if err != nil || len(scopes) != 1 {
+				t.Errorf("Expected the cached scope, got %+v, %v", scopes, err)
+			}
+		}()
+	}
+	close(release)
+	wg.Wait()
+	if requests != 1 {
+		t.Errorf("Expected concurrent lookups to share a single request, got %d", requests)
+	}
+}
+
+func TestLookupsAreInvalidatedByMutations(t *testing.T) {
+	var lists int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			lists++
+			fmt.Fprint(w, `[]`)
+		case http.MethodPost:
+			fmt.Fprint(w, `{"id": "2", "name": "web", "query": {"type": "eq", "field": "ip", "value": "10.0.0.1"}}`)
+		}
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = newLookupCache()
+	for i := 0; i < 2; i++ {
+		if _, err := client.LookupFilters(); err != nil {
+			t.Fatalf("Expected no error looking up filters, got %s", err)
+		}
+	}
+	if lists != 1 {
+		t.Errorf("Expected filters to be listed once, got %d", lists)
+	}
+	// Scopes are cached separately from filters
+	if _, err := client.LookupScopes(); err != nil {
+		t.Fatalf("Expected no error looking up scopes, got %s", err)
+	}
+	if _, err := client.CreateFilter(CreateFilterRequest{Name: "web"}); err != nil {
+		t.Fatalf("Expected no error creating filter, got %s", err)
+	}
+	if _, err := client.LookupFilters(); err != nil {
+		t.Fatalf("Expected no error looking up filters, got %s", err)
+	}
+	if _, err := client.LookupScopes(); err != nil {
+		t.Fatalf("Expected no error looking up scopes, got %s", err)
+	}
+	if lists != 3 {
+		t.Errorf("Expected only filters to be listed again after creating a filter, got %d lists", lists)
+	}
+}
+
+func TestFailedLookupsAreNotCached(t *testing.T) {
+	var lists int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lists++
+		if lists == 1 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `[{"id": "1", "name": "admin"}]`)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = newLookupCache()
+	if _, err := client.LookupRoles(); err == nil {
+		t.Fatal("Expected the first lookup to fail")
+	}
+	roles, err := client.LookupRoles()
+	if err != nil || len(roles) != 1 {
+		t.Errorf("Expected the roles to be listed again, got %+v, %v", roles, err)
+	}
+}
+
+func TestLookupsAreKeyedByTenantAndParams(t *testing.T) {
+	var lists int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lists++
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = newLookupCache()
+	otherTenant := client
+	otherTenant.Config.APIKey = "YYYYYYYYYYYYYYYYYYYYYYYYYYYYYYYY"
+	client.LookupUsers(ListUsersRequest{})
+	client.LookupUsers(ListUsersRequest{AppScopeId: "1234"})
+	otherTenant.LookupUsers(ListUsersRequest{})
+	client.LookupUsers(ListUsersRequest{AppScopeId: "1234"})
+	if lists != 3 {
+		t.Errorf("Expected one list per tenant and params, got %d", lists)
+	}
+}
+
+func TestLookupRootScopeNameRequiresASingleRootScope(t *testing.T) {
+	scopes := `[{"id": "1", "name": "acme"}, {"id": "2", "name": "acme:web", "parent_app_scope_id": "1"}]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, scopes)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	client.cache = nil
+	if name, err := client.LookupRootScopeName(); err != nil || name != "acme" {
+		t.Errorf("Expected root scope acme, got %q, %v", name, err)
+	}
+	scopes = `[{"id": "1", "name": "acme"}, {"id": "3", "name": "globex"}]`
+	if _, err := client.LookupRootScopeName(); err == nil {
+		t.Errorf("Expected an error with two root scopes")
+	}
+}
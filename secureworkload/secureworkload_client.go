@@ -2,23 +2,57 @@ package secureworkload
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
-	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	// "github.com/secureworkload-exchange/terraform-go-sdk/signer"
 	"terraform-provider-secureworkload/secureworkload/signer"
 )
 
+const (
+	// DefaultMaxRetries is the number of times a failed request
+	// is retried when the config does not specify otherwise.
+	DefaultMaxRetries = 5
+	// DefaultRetryMaxWait is the longest the client will wait
+	// between two attempts when the config does not specify otherwise.
+	DefaultRetryMaxWait = 30 * time.Second
+	// DefaultRequestTimeout is the longest a single attempt of a request
+	// may take when the config does not specify otherwise.
+	DefaultRequestTimeout = 2 * time.Minute
+	// DefaultMembershipWarningThreshold disables the previews of membership
+	// changes when the config does not specify otherwise, as they search
+	// the inventory twice for every query change.
+	DefaultMembershipWarningThreshold = -1
+)
+
 // Configuration for creating a SecureWorkload API client
 type Config struct {
 	APIKey                 string
 	APISecret              string
 	APIURL                 string
 	DisableTLSVerification bool
+	// Maximum number of retries for a request that failed with a
+	// retryable error, zero disables retries.
+	MaxRetries int
+	// Upper bound for the wait between two attempts, including
+	// waits requested by the API through the Retry-After header.
+	RetryMaxWait time.Duration
+	// Upper bound for a single attempt of a request, including
+	// reading the response body.
+	RequestTimeout time.Duration
+	// Services policies can reference by name, in addition
+	// to or replacing the default ones, see Client.services.
+	Services map[string][]ServicePorts
+	// Number of inventory items that may join or leave a scope or filter
+	// whose query changes before plans warn about it, negative numbers
+	// disable the previews of membership changes.
+	MembershipWarningThreshold int
 }
 
 // A client for making signed HTTP requests to a SecureWorkload API
@@ -26,6 +60,12 @@ type Client struct {
 	Config Config
 	client *http.Client
 	signer signer.Signer
+	// ctx cancels the requests made by the client, see WithContext.
+	ctx context.Context
+	// cache holds the lists used for lookups, nil disables caching.
+	cache *lookupCache
+	// sleep waits between attempts, overridable for tests.
+	sleep func(time.Duration)
 }
 
 // New creates a new SecureWorkload client based off the provided
@@ -37,50 +77,158 @@ func New(config Config) (Client, error) {
 	}
 	// Remove any trailing slash to be more forgiving of user input
 	config.APIURL = strings.TrimSuffix(config.APIURL, "/")
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.RetryMaxWait <= 0 {
+		config.RetryMaxWait = DefaultRetryMaxWait
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = DefaultRequestTimeout
+	}
 	client := Client{
 		Config: config,
 		signer: signer,
+		client: &http.Client{Timeout: config.RequestTimeout},
+		cache:  sharedLookupCache,
 	}
 	if config.DisableTLSVerification {
-		transport := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.client = &http.Client{Transport: transport}
-	} else {
-		client.client = http.DefaultClient
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		client.client.Transport = transport
 	}
 	return client, nil
 }
 
+// WithContext returns a copy of the client whose requests, including
+// the waits between retries, are cancelled along with ctx.
+// Every client method has a context-aware variant through it, e.g.
+// client.WithContext(ctx).DescribeScope(scopeId).
+func (c Client) WithContext(ctx context.Context) Client {
+	c.ctx = ctx
+	return c
+}
+
+// Context returns the context the requests of the client are bound to.
+func (c Client) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 // Do signs and sends a request, if the provided result
-// interface is not nil, the response will be json decoded to the provided interface
+// interface is not nil, the response will be json decoded to the provided interface.
+// Requests failing with a retryable error are retried up to Config.MaxRetries
+// times, honouring the Retry-After header and re-signing every attempt.
 func (c *Client) Do(request *http.Request, result interface{}) error {
-	err := c.signer.Sign(request)
-	if err != nil {
-		return err
+	// Buffer the body once so that it can be replayed for every attempt
+	var bodyBytes []byte
+	if request.Body != nil && request.Body != http.NoBody {
+		var err error
+		bodyBytes, err = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return err
+		}
 	}
-	response, err := c.client.Do(request)
-	if err != nil {
-		return err
+	for attempt := 0; ; attempt++ {
+		if request.Body != nil && request.Body != http.NoBody {
+			request.Body = ioutil.NopCloser(bytes.NewReader(bodyBytes))
+		}
+		// The signature covers the request timestamp, so it has to be
+		// recalculated for every attempt
+		err := c.signer.Sign(request)
+		if err != nil {
+			return err
+		}
+		response, err := c.client.Do(request)
+		if err != nil {
+			// Never retry requests that were cancelled by their context
+			if request.Context().Err() == nil && attempt < c.Config.MaxRetries && isRetryableTransportError(request.Method, err) {
+				if err := c.wait(request.Context(), retryBackoff(attempt, c.Config.RetryMaxWait)); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+		if attempt < c.Config.MaxRetries && isRetryableStatus(request.Method, response.StatusCode) {
+			wait, ok := retryAfter(response.Header.Get("Retry-After"), time.Now())
+			if !ok {
+				wait = retryBackoff(attempt, c.Config.RetryMaxWait)
+			} else if wait > c.Config.RetryMaxWait {
+				wait = c.Config.RetryMaxWait
+			}
+			// Drain the body so the underlying connection can be reused
+			io.Copy(ioutil.Discard, response.Body)
+			response.Body.Close()
+			if err := c.wait(request.Context(), wait); err != nil {
+				return err
+			}
+			continue
+		}
+		return decodeResponse(request, response, result)
 	}
+}
+
+// wait pauses between two attempts of the same request,
+// returning early with the context error if ctx is done.
+func (c *Client) wait(ctx context.Context, d time.Duration) error {
+	if c.sleep != nil {
+		c.sleep(d)
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// streamDecoder is implemented by results that decode the response
+// body as a stream, rather than into a single value.
+type streamDecoder interface {
+	decodeStream(decoder *json.Decoder) error
+}
+
+// bodyDecoder is implemented by results that are not JSON
+// encoded, such as CSV files, and decode the raw response body.
+type bodyDecoder interface {
+	decodeBody(body io.Reader) error
+}
+
+// decodeResponse closes the response, returning an APIError for any
+// non-2xx status and otherwise json decoding the body into result.
+func decodeResponse(request *http.Request, response *http.Response, result interface{}) error {
 	defer response.Body.Close()
 	if !(response.StatusCode >= 200 && response.StatusCode <= 299) {
-		var rawBodyBuffer bytes.Buffer
 		// Decode raw response, usually contains
 		// additional error details
-		body := io.TeeReader(response.Body, &rawBodyBuffer)
+		rawBody, _ := ioutil.ReadAll(response.Body)
 		var responseBody interface{}
-		json.NewDecoder(body).Decode(&responseBody)
-		return fmt.Errorf("Request %+v\n failed with status code %d\n response %+v\n%+v", request,
-			response.StatusCode, responseBody,
-			response)
+		if err := json.Unmarshal(rawBody, &responseBody); err != nil {
+			if text := strings.TrimSpace(string(rawBody)); text != "" {
+				responseBody = text
+			}
+		}
+		return newAPIError(request, response, responseBody)
 	}
 	// If no result is expected, don't attempt to decode a potentially
 	// empty response stream and avoid incurring EOF errors
 	if result == nil {
 		return nil
 	}
-	err = json.NewDecoder(response.Body).Decode(&result)
+	if raw, ok := result.(bodyDecoder); ok {
+		return raw.decodeBody(response.Body)
+	}
+	if stream, ok := result.(streamDecoder); ok {
+		return stream.decodeStream(json.NewDecoder(response.Body))
+	}
+	err := json.NewDecoder(response.Body).Decode(&result)
 	if err != nil {
 		return err
 	}
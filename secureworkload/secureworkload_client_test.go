@@ -0,0 +1,278 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"terraform-provider-secureworkload/secureworkload/signer"
+)
+
+var (
+	unitTestAPIKey    = "XXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX"
+	unitTestAPISecret = "XXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX"
+)
+
+// newUnitTestClient returns a client for the given test server
+// which records its waits instead of sleeping.
+func newUnitTestClient(t *testing.T, server *httptest.Server, maxRetries int) (Client, *[]time.Duration) {
+	client, err := New(Config{
+		APIKey:       unitTestAPIKey,
+		APISecret:    unitTestAPISecret,
+		APIURL:       server.URL,
+		MaxRetries:   maxRetries,
+		RetryMaxWait: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Error %s creating client", err)
+	}
+	var waits []time.Duration
+	client.sleep = func(d time.Duration) {
+		waits = append(waits, d)
+	}
+	return client, &waits
+}
+
+func TestDoRetriesRateLimitedRequestHonouringRetryAfter(t *testing.T) {
+	var attempts int
+	var timestamps []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		timestamps = append(timestamps, r.Header.Get(signer.TimestampHeaderKey))
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"id": "created"}`))
+	}))
+	defer server.Close()
+	client, waits := newUnitTestClient(t, server, 5)
+	request, err := signer.CreateJSONRequest(http.MethodPost, server.URL, map[string]string{"name": "scope"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Id string `json:"id"`
+	}
+	err = client.Do(request, &result)
+	if err != nil {
+		t.Fatalf("Expected request to succeed after retries, got %s", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+	if result.Id != "created" {
+		t.Errorf("Expected decoded result from final attempt, got %+v", result)
+	}
+	for _, wait := range *waits {
+		if wait != 2*time.Second {
+			t.Errorf("Expected waits to follow Retry-After, got %v", *waits)
+		}
+	}
+	for i, body := range bodies {
+		if body != bodies[0] || body == "" {
+			t.Errorf("Expected body to be replayed on attempt %d, got %q", i, body)
+		}
+		if timestamps[i] == "" {
+			t.Errorf("Expected attempt %d to be signed", i)
+		}
+	}
+}
+
+func TestDoDoesNotRetryServerErrorForNonIdempotentRequest(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 5)
+	request, err := signer.CreateJSONRequest(http.MethodPost, server.URL, map[string]string{"name": "scope"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Do(request, nil); err == nil {
+		t.Error("Expected error for failed request")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt for POST, got %d", attempts)
+	}
+}
+
+func TestDoStopsRetryingAfterMaxRetries(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client, waits := newUnitTestClient(t, server, 3)
+	request, err := signer.CreateJSONRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Do(request, nil); err == nil {
+		t.Error("Expected error once retries are exhausted")
+	}
+	if attempts != 4 {
+		t.Errorf("Expected 4 attempts, got %d", attempts)
+	}
+	for _, wait := range *waits {
+		if wait < 0 || wait > 10*time.Second {
+			t.Errorf("Expected waits to be capped by RetryMaxWait, got %v", *waits)
+		}
+	}
+}
+
+func TestRetryAfterParsesSecondsAndDates(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	var retryAfterTests = []struct {
+		header string
+		wait   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{"Sun, 01 Jan 2023 00:00:05 GMT", 5 * time.Second, true},
+		{"Sat, 31 Dec 2022 23:59:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, test := range retryAfterTests {
+		wait, ok := retryAfter(test.header, now)
+		if wait != test.wait || ok != test.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, expected %v, %v", test.header, wait, ok, test.wait, test.ok)
+		}
+	}
+}
+
+func TestRetryBackoffIsCapped(t *testing.T) {
+	for attempt := 0; attempt < 64; attempt++ {
+		if wait := retryBackoff(attempt, 5*time.Second); wait < 0 || wait > 5*time.Second {
+			t.Errorf("retryBackoff(%d) = %v, expected at most 5s", attempt, wait)
+		}
+	}
+}
+
+func TestAwaitTimeoutStopsWhenReadyOrTimedOut(t *testing.T) {
+	var checks int
+	ready := AwaitTimeout(func() bool {
+		checks++
+		return checks == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	if !ready || checks != 2 {
+		t.Errorf("Expected ready after 2 checks, got %v after %d", ready, checks)
+	}
+	start := time.Now()
+	ready = AwaitTimeout(func() bool { return false }, 50*time.Millisecond, 10*time.Millisecond)
+	if ready {
+		t.Error("Expected never ready function to time out")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected to give up after the timeout, waited %s", elapsed)
+	}
+}
+
+func TestDoStopsRetryingWhenContextIsCancelled(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		// Ask for a wait longer than the context deadline, a jittered
+		// backoff could be short enough for a second attempt
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	client, err := New(Config{
+		APIKey:       unitTestAPIKey,
+		APISecret:    unitTestAPISecret,
+		APIURL:       server.URL,
+		MaxRetries:   5,
+		RetryMaxWait: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Error %s creating client", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.WithContext(ctx).DescribeScope("1234")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt before the context expired, got %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected to stop waiting once the context expired, waited %s", elapsed)
+	}
+}
+
+func TestDoCancelsAttemptsAfterRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	client, err := New(Config{
+		APIKey:         unitTestAPIKey,
+		APISecret:      unitTestAPISecret,
+		APIURL:         server.URL,
+		RequestTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Error %s creating client", err)
+	}
+	start := time.Now()
+	if err := client.DeleteScope("1234"); err == nil {
+		t.Error("Expected hung request to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected request to be cancelled after the request timeout, waited %s", elapsed)
+	}
+}
+
+func TestAwaitContextStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var checks int
+	ready := AwaitContext(ctx, func() bool {
+		checks++
+		cancel()
+		return false
+	}, time.Minute)
+	if ready || checks != 1 {
+		t.Errorf("Expected to give up after cancellation, got %v after %d checks", ready, checks)
+	}
+}
+
+func TestUpdateScopeSendsZeroPolicyPriority(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	zero := 0
+	for _, params := range []UpdateScopeRequest{{ShortName: "web", PolicyPriority: &zero}, {ShortName: "web"}} {
+		if _, err := client.UpdateScope("1", params); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !strings.Contains(bodies[0], `"policy_priority":0`) || strings.Contains(bodies[1], "policy_priority") {
+		t.Errorf("Expected only the first update to send the policy priority, got %v", bodies)
+	}
+}
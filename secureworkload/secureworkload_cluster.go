@@ -38,7 +38,7 @@ type CreateClusterRequest struct {
 func (c Client) GetClusterByParam(getUrl string, name string) ([]Clusters, error) {
 	var cluster []Clusters
 	url := c.Config.APIURL + ClustersAPIV1BasePath + getUrl
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +49,7 @@ func (c Client) GetClusterByParam(getUrl string, name string) ([]Clusters, error
 func (c Client) CreateCluster(params CreateClusterRequest, workspace_id string) (Clusters, error) {
 	var cluster Clusters
 	url := c.Config.APIURL + ClustersAPIV1BasePath + workspace_id + "/clusters"
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return cluster, err
 	}
@@ -61,10 +61,34 @@ func (c Client) CreateCluster(params CreateClusterRequest, workspace_id string)
 	return cluster, err
 }
 
+// UpdateClusterRequest wraps parameters for making a request to update a cluster.
+type UpdateClusterRequest struct {
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description"`
+	Query       json.RawMessage `json:"query,omitempty"`
+}
+
+// UpdateCluster updates a cluster by id, returning
+// the updated cluster and error (if any).
+func (c Client) UpdateCluster(clusterId string, params UpdateClusterRequest) (Clusters, error) {
+	var cluster Clusters
+	url := c.Config.APIURL + SecureWorkloadAPIV1BasePath + "/clusters" + fmt.Sprintf("/%s", clusterId)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPut, url, params)
+	if err != nil {
+		return cluster, err
+	}
+	err = c.Do(request, &cluster)
+	if err != nil {
+		return cluster, err
+	}
+	err = unmarshalQuery(cluster.QueryJSON, &cluster.Query)
+	return cluster, err
+}
+
 func (c Client) DescribeCluster(clusterId string) (Clusters, error) {
 	var cluster Clusters
 	url := c.Config.APIURL + SecureWorkloadAPIV1BasePath + "/clusters" + fmt.Sprintf("/%s", clusterId)
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return cluster, err
 	}
@@ -78,7 +102,7 @@ func (c Client) DescribeCluster(clusterId string) (Clusters, error) {
 
 func (c Client) DeleteCluster(workspace_id string, clusterId string) error {
 	url := c.Config.APIURL + SecureWorkloadAPIV1BasePath + "/clusters" + fmt.Sprintf("/%s", clusterId)
-	request, err := signer.CreateJSONRequest(http.MethodDelete, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
@@ -86,21 +110,23 @@ func (c Client) DeleteCluster(workspace_id string, clusterId string) error {
 }
 
 func (c Client) ListCluster(workspace_id string) ([]Clusters, error) {
-	var clusters []Clusters
-	url := c.Config.APIURL + ClustersAPIV1BasePath + workspace_id + "/clusters"
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return clusters, err
-	}
-	err = c.Do(request, &clusters)
-	if err != nil {
-		return clusters, err
+	return collect(c.IterateClusters(workspace_id, ListOptions{}))
+}
+
+// IterateClusters returns an iterator over the clusters of the given
+// workspace. Each call sends a single GET, whose response is decoded as
+// a stream. Clusters are filtered by name while decoding the response.
+func (c Client) IterateClusters(workspaceId string, options ListOptions) *ListIterator[Clusters] {
+	endpoint := listEndpoint[Clusters]{
+		url: c.Config.APIURL + ClustersAPIV1BasePath + workspaceId + "/clusters",
+		prepare: func(cluster *Clusters) error {
+			return unmarshalQuery(cluster.QueryJSON, &cluster.Query)
+		},
 	}
-	for _, filter := range clusters {
-		err = json.Unmarshal(filter.QueryJSON, &filter.Query)
-		if err != nil {
-			return clusters, err
+	if options.Name != "" {
+		endpoint.match = func(cluster Clusters) bool {
+			return cluster.Name == options.Name
 		}
 	}
-	return clusters, err
+	return newListIterator(c, endpoint)
 }
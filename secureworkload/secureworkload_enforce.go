@@ -3,7 +3,6 @@ package secureworkload
 import (
 	"fmt"
 	"net/http"
-	"time"
 
 	// "github.com/secureworkload-exchange/terraform-go-sdk/signer"
 	"terraform-provider-secureworkload/secureworkload/signer"
@@ -25,9 +24,10 @@ type CreateEnforceRequest struct {
 }
 
 func (c Client) CreateEnforce(params CreateEnforceRequest, workspace_id string) (Enforce, error) {
+	defer c.invalidateLookups(workspaceLookups)
 	var enforce Enforce
 	url := c.Config.APIURL + EnforceAPIV1BasePath + workspace_id + "/enable_enforce"
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return enforce, err
 	}
@@ -36,12 +36,11 @@ func (c Client) CreateEnforce(params CreateEnforceRequest, workspace_id string)
 }
 
 func (c Client) DeleteEnforce(workspace_id string) error {
+	defer c.invalidateLookups(workspaceLookups)
 	url := c.Config.APIURL + EnforceAPIV1BasePath + workspace_id + "/disable_enforce"
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, nil)
 	if err != nil {
 		return err
 	}
-	c.Do(request, nil)
-	time.Sleep(60 * time.Second)
-	return nil
+	return c.Do(request, nil)
 }
@@ -0,0 +1,26 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeleteEnforceReturnsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != EnforceAPIV1BasePath+"workspace/disable_enforce" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "workspace is not enforced"}`))
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	err := client.DeleteEnforce("workspace")
+	apiErr, ok := AsAPIError(err)
+	if !ok || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected bad request error, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package secureworkload
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// HTTP Response Header identifying a request in the SecureWorkload logs.
+	RequestIdHeaderKey = "X-Request-Id"
+)
+
+// APIError wraps a non-2xx response returned by the SecureWorkload API.
+type APIError struct {
+	// HTTP status code of the response.
+	StatusCode int
+	// HTTP method of the failed request.
+	Method string
+	// URL path of the failed request, without query parameters.
+	Path string
+	// Decoded response body, usually contains additional error details.
+	Body interface{}
+	// Error message extracted from the response body (if any).
+	Message string
+	// Identifier of the request as reported by the API (if any).
+	RequestId string
+}
+
+// Error implements the error interface without exposing request
+// headers, which carry the signed authorization values.
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("%s %s failed with status code %d", e.Method, e.Path, e.StatusCode)
+	if e.Message != "" {
+		message += ": " + e.Message
+	} else if e.Body != nil {
+		message += fmt.Sprintf(": %v", e.Body)
+	}
+	if e.RequestId != "" {
+		message += fmt.Sprintf(" (request id %s)", e.RequestId)
+	}
+	return message
+}
+
+// newAPIError creates an APIError for the given failed request,
+// response and decoded response body.
+func newAPIError(request *http.Request, response *http.Response, body interface{}) *APIError {
+	return &APIError{
+		StatusCode: response.StatusCode,
+		Method:     request.Method,
+		Path:       request.URL.Path,
+		Body:       body,
+		Message:    apiErrorMessage(body),
+		RequestId:  response.Header.Get(RequestIdHeaderKey),
+	}
+}
+
+// apiErrorMessage extracts the human readable message from a decoded
+// error body, which is either a plain string or an object carrying
+// the message under one of a handful of keys.
+func apiErrorMessage(body interface{}) string {
+	switch value := body.(type) {
+	case string:
+		return value
+	case map[string]interface{}:
+		for _, key := range []string{"error", "message", "errors", "detail"} {
+			if message, ok := value[key]; ok && message != nil {
+				if str, ok := message.(string); ok {
+					return str
+				}
+				return fmt.Sprintf("%v", message)
+			}
+		}
+	}
+	return ""
+}
+
+// AsAPIError returns the APIError wrapped by err and true,
+// or nil and false if err is not an APIError.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+func hasStatusCode(err error, statusCodes ...int) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return false
+	}
+	for _, statusCode := range statusCodes {
+		if apiErr.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound reports whether err is an API error for an object that does not exist.
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is an API error for a request
+// conflicting with the current state of an object.
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+// IsRateLimited reports whether err is an API error for a request
+// rejected because too many requests were made.
+func IsRateLimited(err error) bool {
+	return hasStatusCode(err, http.StatusTooManyRequests)
+}
+
+// IsInUse reports whether err is an API error for an object that
+// cannot be deleted or modified because other objects depend on it,
+// e.g. a scope referenced by a workspace or a filter used in a policy.
+func IsInUse(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity:
+		return strings.Contains(strings.ToLower(apiErr.Message), "in use")
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"terraform-provider-secureworkload/secureworkload/signer"
+)
+
+func TestDoReturnsAPIErrorWithoutAuthorizationHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RequestIdHeaderKey, "abc123")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error": "error:cannot delete scope because it is in use"}`))
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	request, err := signer.CreateJSONRequest(http.MethodDelete, server.URL+ScopesAPIV1BasePath+"/1234?force=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Do(request, nil)
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		t.Fatalf("Expected APIError, got %T %s", err, err)
+	}
+	if apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Method != http.MethodDelete ||
+		apiErr.Path != ScopesAPIV1BasePath+"/1234" || apiErr.RequestId != "abc123" {
+		t.Errorf("Unexpected APIError %+v", apiErr)
+	}
+	if !IsInUse(err) {
+		t.Errorf("Expected %s to be reported as in use", err)
+	}
+	if IsNotFound(err) || IsConflict(err) || IsRateLimited(err) {
+		t.Errorf("Expected %s to only be reported as in use", err)
+	}
+	for _, header := range []string{request.Header.Get(signer.AuthorizationHeaderKey), unitTestAPIKey} {
+		if strings.Contains(err.Error(), header) {
+			t.Errorf("Expected error %q not to contain request headers", err)
+		}
+	}
+}
+
+func TestAPIErrorHelpersMatchStatusCodes(t *testing.T) {
+	var helperTests = []struct {
+		statusCode  int
+		notFound    bool
+		conflict    bool
+		rateLimited bool
+	}{
+		{http.StatusNotFound, true, false, false},
+		{http.StatusConflict, false, true, false},
+		{http.StatusTooManyRequests, false, false, true},
+		{http.StatusInternalServerError, false, false, false},
+	}
+	for _, test := range helperTests {
+		err := fmt.Errorf("wrapped: %w", &APIError{StatusCode: test.statusCode})
+		if IsNotFound(err) != test.notFound || IsConflict(err) != test.conflict || IsRateLimited(err) != test.rateLimited {
+			t.Errorf("Unexpected helper results for status code %d", test.statusCode)
+		}
+	}
+	if IsNotFound(fmt.Errorf("not an api error")) {
+		t.Error("Expected plain errors not to be reported as not found")
+	}
+}
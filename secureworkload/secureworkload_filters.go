@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	// "github.com/secureworkload-exchange/terraform-go-sdk/signer"
 	"terraform-provider-secureworkload/secureworkload/signer"
@@ -52,7 +53,7 @@ type CreateFilterRequest struct {
 func (c Client) GetFilterByParam(getUrl string) ([]Application, error) {
 	var filter []Application
 	url := c.Config.APIURL + FiltersAPIV1BasePath
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return filter, err
 	}
@@ -63,9 +64,10 @@ func (c Client) GetFilterByParam(getUrl string) ([]Application, error) {
 // CreateFilter creates a filters with the specified params,
 // returning the created filters and error (if any).
 func (c Client) CreateFilter(params CreateFilterRequest) (Filter, error) {
+	defer c.invalidateLookups(filterLookups)
 	var filter Filter
 	url := c.Config.APIURL + FiltersAPIV1BasePath
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return filter, err
 	}
@@ -82,7 +84,7 @@ func (c Client) CreateFilter(params CreateFilterRequest) (Filter, error) {
 func (c Client) DescribeFilter(filterId string) (Filter, error) {
 	var filter Filter
 	url := c.Config.APIURL + FiltersAPIV1BasePath + fmt.Sprintf("/%s", filterId)
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return filter, err
 	}
@@ -94,10 +96,37 @@ func (c Client) DescribeFilter(filterId string) (Filter, error) {
 	return filter, err
 }
 
+// UpdateFilterRequest wraps parameters for making a request to update a filter.
+type UpdateFilterRequest struct {
+	// (Optional) User-specified name for the inventory filter.
+	Name string `json:"name,omitempty"`
+	// (Optional) Filter (or match criteria) associated with the filter.
+	Query json.RawMessage `json:"query,omitempty"`
+}
+
+// UpdateFilter updates a filter by id, returning
+// the updated filter and error (if any).
+func (c Client) UpdateFilter(filterId string, params UpdateFilterRequest) (Filter, error) {
+	defer c.invalidateLookups(filterLookups)
+	var filter Filter
+	url := c.Config.APIURL + FiltersAPIV1BasePath + fmt.Sprintf("/%s", filterId)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPut, url, params)
+	if err != nil {
+		return filter, err
+	}
+	err = c.Do(request, &filter)
+	if err != nil {
+		return filter, err
+	}
+	err = unmarshalQuery(filter.QueryJSON, &filter.Query)
+	return filter, err
+}
+
 // DeleteFilter deletes a filter by id returning error (if any).
 func (c Client) DeleteFilter(filterId string) error {
+	defer c.invalidateLookups(filterLookups)
 	url := c.Config.APIURL + FiltersAPIV1BasePath + fmt.Sprintf("/%s", filterId)
-	request, err := signer.CreateJSONRequest(http.MethodDelete, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
@@ -108,21 +137,26 @@ func (c Client) DeleteFilter(filterId string) error {
 // credentials for the given client, returning
 // the listed filters and error (if any)
 func (c Client) ListFilters() ([]Filter, error) {
-	var filters []Filter
-	url := c.Config.APIURL + FiltersAPIV1BasePath
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return filters, err
-	}
-	err = c.Do(request, &filters)
-	if err != nil {
-		return filters, err
+	return collect(c.IterateFilters(ListOptions{}))
+}
+
+// IterateFilters returns an iterator over the filters readable by the
+// API credentials for the given client. Each call sends a single GET,
+// as the endpoint does not support paging, and decodes the response as
+// a stream. Filters are filtered by name server-side.
+func (c Client) IterateFilters(options ListOptions) *ListIterator[Filter] {
+	endpoint := listEndpoint[Filter]{
+		url:   c.Config.APIURL + FiltersAPIV1BasePath,
+		query: url.Values{},
+		prepare: func(filter *Filter) error {
+			return unmarshalQuery(filter.QueryJSON, &filter.Query)
+		},
 	}
-	for _, filter := range filters {
-		err = json.Unmarshal(filter.QueryJSON, &filter.Query)
-		if err != nil {
-			return filters, err
+	if options.Name != "" {
+		endpoint.query.Set("exact_name", options.Name)
+		endpoint.match = func(filter Filter) bool {
+			return filter.Name == options.Name
 		}
 	}
-	return filters, err
+	return newListIterator(c, endpoint)
 }
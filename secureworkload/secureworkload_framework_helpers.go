@@ -0,0 +1,362 @@
+package secureworkload
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var (
+	_ basetypes.StringTypable                    = queryJSONType{}
+	_ basetypes.StringValuableWithSemanticEquals = queryJSONValue{}
+)
+
+// queryJSONType is the type of the attributes of framework resources
+// holding JSON queries. Queries that are the same once normalized, see
+// normalizeQueryJSON, are semantically equal, so that formatting
+// differences and the values normalized by the API are not reported as drift.
+type queryJSONType struct {
+	basetypes.StringType
+}
+
+func (t queryJSONType) Equal(o attr.Type) bool {
+	other, ok := o.(queryJSONType)
+	return ok && t.StringType.Equal(other.StringType)
+}
+
+func (t queryJSONType) String() string {
+	return "queryJSONType"
+}
+
+func (t queryJSONType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return queryJSONValue{StringValue: in}, nil
+}
+
+func (t queryJSONType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	value, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	stringValue, ok := value.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type %T", value)
+	}
+	return queryJSONValue{StringValue: stringValue}, nil
+}
+
+func (t queryJSONType) ValueType(ctx context.Context) attr.Value {
+	return queryJSONValue{}
+}
+
+// queryJSONValue is a JSON query, see queryJSONType.
+type queryJSONValue struct {
+	basetypes.StringValue
+}
+
+func (v queryJSONValue) Equal(o attr.Value) bool {
+	other, ok := o.(queryJSONValue)
+	return ok && v.StringValue.Equal(other.StringValue)
+}
+
+func (v queryJSONValue) Type(ctx context.Context) attr.Type {
+	return queryJSONType{}
+}
+
+func (v queryJSONValue) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	newValue, ok := newValuable.(queryJSONValue)
+	if !ok {
+		return false, nil
+	}
+	return queryJSONEqual(v.ValueString(), newValue.ValueString()), nil
+}
+
+func queryJSONNull() queryJSONValue {
+	return queryJSONValue{StringValue: types.StringNull()}
+}
+
+func queryJSONUnknown() queryJSONValue {
+	return queryJSONValue{StringValue: types.StringUnknown()}
+}
+
+// queryJSONString returns the normalized JSON query, see normalizeQueryJSONState.
+func queryJSONString(raw string) queryJSONValue {
+	return queryJSONValue{StringValue: types.StringValue(normalizeQueryJSONState(raw))}
+}
+
+// queryJSONKeepingPrior returns the JSON query encoded as raw, or the prior
+// value when it holds the same query, as semantic equality does for new state.
+func queryJSONKeepingPrior(prior queryJSONValue, raw string) queryJSONValue {
+	if !prior.IsNull() && !prior.IsUnknown() && queryJSONEqual(prior.ValueString(), raw) {
+		return prior
+	}
+	return queryJSONString(raw)
+}
+
+// jsonValidator validates that strings are JSON documents, like validateJSON.
+type jsonValidator struct{}
+
+func (v jsonValidator) Description(ctx context.Context) string {
+	return "value must be a JSON document"
+}
+
+func (v jsonValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v jsonValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	_, errs := validateJSON(req.ConfigValue.ValueString(), req.Path.String())
+	for _, err := range errs {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid JSON", err.Error())
+	}
+}
+
+// ipOrCIDRValidator validates that strings are IPv4 or IPv6
+// addresses or subnets, like validateIPOrCIDR.
+type ipOrCIDRValidator struct{}
+
+func (v ipOrCIDRValidator) Description(ctx context.Context) string {
+	return "value must be an IP address or a subnet"
+}
+
+func (v ipOrCIDRValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v ipOrCIDRValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	_, errs := validateIPOrCIDR(req.ConfigValue.ValueString(), req.Path.String())
+	for _, err := range errs {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid IP address", err.Error())
+	}
+}
+
+// stringOneOfValidator validates that strings are one of the given values.
+type stringOneOfValidator struct {
+	values []string
+}
+
+func (v stringOneOfValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must be one of %s", strings.Join(v.values, ", "))
+}
+
+func (v stringOneOfValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v stringOneOfValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	for _, value := range v.values {
+		if req.ConfigValue.ValueString() == value {
+			return
+		}
+	}
+	resp.Diagnostics.AddAttributeError(req.Path, "Invalid value",
+		fmt.Sprintf("%s must be one of %s, got %q", req.Path, strings.Join(v.values, ", "), req.ConfigValue.ValueString()))
+}
+
+// listSizeAtMostValidator validates that lists, e.g. of
+// blocks, hold at most max elements, like MaxItems.
+type listSizeAtMostValidator struct {
+	max int
+}
+
+func (v listSizeAtMostValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("list must hold at most %d elements", v.max)
+}
+
+func (v listSizeAtMostValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v listSizeAtMostValidator) ValidateList(ctx context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if count := len(req.ConfigValue.Elements()); count > v.max {
+		resp.Diagnostics.AddAttributeError(req.Path, "Too many elements",
+			fmt.Sprintf("%s must hold at most %d elements, got %d", req.Path, v.max, count))
+	}
+}
+
+// setSizeAtMostValidator validates that sets, e.g. of
+// blocks, hold at most max elements, like MaxItems.
+type setSizeAtMostValidator struct {
+	max int
+}
+
+func (v setSizeAtMostValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("set must hold at most %d elements", v.max)
+}
+
+func (v setSizeAtMostValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v setSizeAtMostValidator) ValidateSet(ctx context.Context, req validator.SetRequest, resp *validator.SetResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if count := len(req.ConfigValue.Elements()); count > v.max {
+		resp.Diagnostics.AddAttributeError(req.Path, "Too many elements",
+			fmt.Sprintf("%s must hold at most %d elements, got %d", req.Path, v.max, count))
+	}
+}
+
+// conflictingAttributes reports an error for each pair of the given
+// attributes, by name, that are both configured, like ConflictsWith.
+func conflictingAttributes(configured map[string]bool, diagnostics *diag.Diagnostics) {
+	var names []string
+	for name, ok := range configured {
+		if ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 1 {
+		sort.Strings(names)
+		diagnostics.AddError("Conflicting attributes",
+			fmt.Sprintf("only one of %s can be specified", strings.Join(names, ", ")))
+	}
+}
+
+// frameworkValueKnown reports whether a value and all the values nested in it are known.
+func frameworkValueKnown(value attr.Value) bool {
+	if value.IsUnknown() {
+		return false
+	}
+	switch value := value.(type) {
+	case basetypes.ListValue:
+		for _, element := range value.Elements() {
+			if !frameworkValueKnown(element) {
+				return false
+			}
+		}
+	case basetypes.ObjectValue:
+		for _, attribute := range value.Attributes() {
+			if !frameworkValueKnown(attribute) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// frameworkToTerraform converts a known framework value to the value
+// terraform-plugin-sdk/v2 would hand to resources, e.g. a terraformObject,
+// so that the helpers written for them can be shared. Null strings
+// become empty strings and null lists empty lists.
+func frameworkToTerraform(value attr.Value) interface{} {
+	switch value := value.(type) {
+	case basetypes.StringValue:
+		return value.ValueString()
+	case queryJSONValue:
+		return value.ValueString()
+	case basetypes.BoolValue:
+		return value.ValueBool()
+	case basetypes.Int64Value:
+		return int(value.ValueInt64())
+	case basetypes.ListValue:
+		elements := []interface{}{}
+		for _, element := range value.Elements() {
+			elements = append(elements, frameworkToTerraform(element))
+		}
+		return elements
+	case basetypes.MapValue:
+		elements := map[string]interface{}{}
+		for key, element := range value.Elements() {
+			elements[key] = frameworkToTerraform(element)
+		}
+		return elements
+	case basetypes.ObjectValue:
+		tf := terraformObject{}
+		for name, attribute := range value.Attributes() {
+			tf[name] = frameworkToTerraform(attribute)
+		}
+		return tf
+	}
+	return nil
+}
+
+// terraformToFramework converts a value built for terraform-plugin-sdk/v2
+// resources to a framework value of the given type. Empty strings and
+// empty lists of primitives become null, as they are when unset, while
+// lists of objects, i.e. of blocks, stay empty.
+func terraformToFramework(value interface{}, typ attr.Type) attr.Value {
+	switch typ := typ.(type) {
+	case basetypes.StringType:
+		if s, _ := value.(string); s != "" {
+			return types.StringValue(s)
+		}
+		return types.StringNull()
+	case basetypes.BoolType:
+		b, _ := value.(bool)
+		return types.BoolValue(b)
+	case basetypes.Int64Type:
+		i, _ := value.(int)
+		return types.Int64Value(int64(i))
+	case basetypes.ListType:
+		tfElements, _ := value.([]interface{})
+		_, blocks := typ.ElemType.(basetypes.ObjectType)
+		if len(tfElements) == 0 && !blocks {
+			return types.ListNull(typ.ElemType)
+		}
+		elements := []attr.Value{}
+		for _, tfElement := range tfElements {
+			elements = append(elements, terraformToFramework(tfElement, typ.ElemType))
+		}
+		return types.ListValueMust(typ.ElemType, elements)
+	case basetypes.ObjectType:
+		tf, _ := value.(terraformObject)
+		attributes := map[string]attr.Value{}
+		for name, attributeType := range typ.AttrTypes {
+			attributes[name] = terraformToFramework(tf[name], attributeType)
+		}
+		return types.ObjectValueMust(typ.AttrTypes, attributes)
+	}
+	return nil
+}
+
+// stringKeepingNull returns the string returned by the API, or null when
+// it is empty and the prior value was null, as terraform-plugin-sdk/v2 did
+// for optional attributes that are not configured.
+func stringKeepingNull(prior types.String, value string) types.String {
+	if value == "" && prior.IsNull() {
+		return types.StringNull()
+	}
+	return types.StringValue(value)
+}
+
+// durationValidator validates that strings are durations, e.g. 30m.
+type durationValidator struct{}
+
+func (v durationValidator) Description(ctx context.Context) string {
+	return "value must be a duration, e.g. 30m"
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := time.ParseDuration(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid duration", err.Error())
+	}
+}
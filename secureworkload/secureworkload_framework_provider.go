@@ -0,0 +1,282 @@
+package secureworkload
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+)
+
+// ProviderServer returns a server for both the resources written on
+// terraform-plugin-framework and the ones still written on
+// terraform-plugin-sdk/v2, muxed behind a single provider.
+func ProviderServer(ctx context.Context) (func() tfprotov5.ProviderServer, error) {
+	providers := []func() tfprotov5.ProviderServer{
+		providerserver.NewProtocol5(NewFrameworkProvider()),
+		func() tfprotov5.ProviderServer {
+			return Provider().GRPCProvider()
+		},
+	}
+	muxServer, err := tf5muxserver.NewMuxServer(ctx, providers...)
+	if err != nil {
+		return nil, err
+	}
+	return muxServer.ProviderServer, nil
+}
+
+// frameworkProvider is the terraform-plugin-framework half of the provider.
+// Its schema must match the one of Provider, as terraform sees a single provider.
+type frameworkProvider struct{}
+
+// frameworkProviderModel maps the provider configuration.
+type frameworkProviderModel struct {
+	APIKey                     types.String            `tfsdk:"api_key"`
+	APISecret                  types.String            `tfsdk:"api_secret"`
+	APIURL                     types.String            `tfsdk:"api_url"`
+	DisableTLSVerification     types.Bool              `tfsdk:"disable_tls_verification"`
+	MaxRetries                 types.Int64             `tfsdk:"max_retries"`
+	RetryMaxWait               types.Int64             `tfsdk:"retry_max_wait"`
+	RequestTimeout             types.Int64             `tfsdk:"request_timeout"`
+	MembershipWarningThreshold types.Int64             `tfsdk:"membership_warning_threshold"`
+	Services                   []frameworkServiceModel `tfsdk:"service"`
+}
+
+// frameworkServiceModel maps a service block of the provider configuration.
+type frameworkServiceModel struct {
+	Name     types.String `tfsdk:"name"`
+	Protocol types.String `tfsdk:"protocol"`
+	Ports    types.String `tfsdk:"ports"`
+}
+
+// NewFrameworkProvider returns the terraform-plugin-framework
+// provider for managing secureworkload resources.
+func NewFrameworkProvider() provider.Provider {
+	return &frameworkProvider{}
+}
+
+func (p *frameworkProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "secureworkload"
+}
+
+func (p *frameworkProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"api_key": schema.StringAttribute{
+				Optional:    true,
+				Description: "API key for calculating request signatures for SecureWorkload API calls. Can also be set with the SECUREWORKLOAD_API_KEY environment variable.",
+			},
+			"api_secret": schema.StringAttribute{
+				Optional:    true,
+				Description: "API secret for calculating request signatures for SecureWorkload API calls. Can also be set with the SECUREWORKLOAD_API_SECRET environment variable.",
+			},
+			"api_url": schema.StringAttribute{
+				Optional:    true,
+				Description: "URL for a SecureWorkload API. Can also be set with the SECUREWORKLOAD_API_URL environment variable.",
+			},
+			"disable_tls_verification": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Allow connections to SecureWorkload endpoints without validating their TLS certificate.",
+			},
+			"max_retries": schema.Int64Attribute{
+				Optional:    true,
+				Description: "Maximum number of times a request is retried after being rate limited (429), failing with a server error (5xx) or losing its connection. Set to 0 to disable retries.",
+			},
+			"retry_max_wait": schema.Int64Attribute{
+				Optional:    true,
+				Description: "Maximum number of seconds to wait between two attempts of the same request, including waits requested by the API through the Retry-After header.",
+			},
+			"request_timeout": schema.Int64Attribute{
+				Optional:    true,
+				Description: "Maximum number of seconds a single attempt of a request may take, including reading the response, before it is cancelled.",
+			},
+			"membership_warning_threshold": schema.Int64Attribute{
+				Optional:    true,
+				Description: "Number of inventory items that may join or leave a scope or filter whose query changes before plans warn about it, listing example addresses. The members are previewed by searching the inventory with both queries, so previews are disabled by default, with -1. Set to 0 to warn about any change. Can also be set with the SECUREWORKLOAD_MEMBERSHIP_WARNING_THRESHOLD environment variable.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"service": schema.ListNestedBlock{
+				Description: "Services policies can reference by name, in addition to the default ones. Several blocks with the same name make up a single service, e.g. one for tcp and one for udp. Services named like a default one replace it.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Required:    true,
+							Description: "Name of the service, referenced by the service attribute of policies.",
+						},
+						"protocol": schema.StringAttribute{
+							Required:    true,
+							Description: "Protocol name (any, icmp, tcp, udp or icmpv6) or number.",
+						},
+						"ports": schema.StringAttribute{
+							Optional:    true,
+							Description: "Ports of the service, as a comma separated list of ports and port ranges, e.g. 80,443,8000-8100. Empty means all ports.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (p *frameworkProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var model frameworkProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// Unknown values can't be used to sign requests, so report
+	// them rather than silently falling back to the environment
+	unknown := map[string]bool{
+		"api_key":                      model.APIKey.IsUnknown(),
+		"api_secret":                   model.APISecret.IsUnknown(),
+		"api_url":                      model.APIURL.IsUnknown(),
+		"disable_tls_verification":     model.DisableTLSVerification.IsUnknown(),
+		"max_retries":                  model.MaxRetries.IsUnknown(),
+		"retry_max_wait":               model.RetryMaxWait.IsUnknown(),
+		"request_timeout":              model.RequestTimeout.IsUnknown(),
+		"membership_warning_threshold": model.MembershipWarningThreshold.IsUnknown(),
+	}
+	for attribute, isUnknown := range unknown {
+		if isUnknown {
+			resp.Diagnostics.AddAttributeError(path.Root(attribute), "Unknown provider configuration",
+				"The Secure Workload provider can not be configured with a value that is only known after apply.")
+		}
+	}
+	for i, service := range model.Services {
+		if service.Name.IsUnknown() || service.Protocol.IsUnknown() || service.Ports.IsUnknown() {
+			resp.Diagnostics.AddAttributeError(path.Root("service").AtListIndex(i), "Unknown provider configuration",
+				"The Secure Workload provider can not be configured with a value that is only known after apply.")
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	config := Config{
+		APIKey:                     stringWithEnvDefault(model.APIKey, "SECUREWORKLOAD_API_KEY"),
+		APISecret:                  stringWithEnvDefault(model.APISecret, "SECUREWORKLOAD_API_SECRET"),
+		APIURL:                     stringWithEnvDefault(model.APIURL, "SECUREWORKLOAD_API_URL"),
+		DisableTLSVerification:     model.DisableTLSVerification.ValueBool(),
+		MaxRetries:                 DefaultMaxRetries,
+		RetryMaxWait:               DefaultRetryMaxWait,
+		RequestTimeout:             DefaultRequestTimeout,
+		Services:                   map[string][]ServicePorts{},
+		MembershipWarningThreshold: DefaultMembershipWarningThreshold,
+	}
+	for _, service := range model.Services {
+		name := service.Name.ValueString()
+		config.Services[name] = append(config.Services[name], ServicePorts{
+			Protocol: service.Protocol.ValueString(),
+			Ports:    service.Ports.ValueString(),
+		})
+	}
+	if model.DisableTLSVerification.IsNull() {
+		config.DisableTLSVerification, _ = strconv.ParseBool(os.Getenv("SECUREWORKLOAD_DISABLE_TLS_VERIFICATION"))
+	}
+	if maxRetries, ok := int64WithEnvDefault(model.MaxRetries, "SECUREWORKLOAD_MAX_RETRIES"); ok {
+		config.MaxRetries = int(maxRetries)
+	}
+	if retryMaxWait, ok := int64WithEnvDefault(model.RetryMaxWait, "SECUREWORKLOAD_RETRY_MAX_WAIT"); ok {
+		config.RetryMaxWait = time.Duration(retryMaxWait) * time.Second
+	}
+	if requestTimeout, ok := int64WithEnvDefault(model.RequestTimeout, "SECUREWORKLOAD_REQUEST_TIMEOUT"); ok {
+		config.RequestTimeout = time.Duration(requestTimeout) * time.Second
+	}
+	if threshold, ok := int64WithEnvDefault(model.MembershipWarningThreshold, "SECUREWORKLOAD_MEMBERSHIP_WARNING_THRESHOLD"); ok {
+		config.MembershipWarningThreshold = int(threshold)
+	}
+	if config.APIKey == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("api_key"), "Missing API key",
+			"API Key must be configured for the Secure Workload provider, either in the provider block or with SECUREWORKLOAD_API_KEY.")
+	}
+	if config.APISecret == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("api_secret"), "Missing API secret",
+			"API Secret must be configured for the Secure Workload provider, either in the provider block or with SECUREWORKLOAD_API_SECRET.")
+	}
+	if config.APIURL == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("api_url"), "Missing API URL",
+			"API URL must be configured for the Secure Workload provider, either in the provider block or with SECUREWORKLOAD_API_URL.")
+	}
+	if config.MaxRetries < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_retries"), "Invalid max retries",
+			"Max retries must not be negative for the Secure Workload provider.")
+	}
+	if config.RetryMaxWait <= 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("retry_max_wait"), "Invalid retry max wait",
+			"Retry max wait must be a positive number of seconds for the Secure Workload provider.")
+	}
+	if config.RequestTimeout <= 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("request_timeout"), "Invalid request timeout",
+			"Request timeout must be a positive number of seconds for the Secure Workload provider.")
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client, err := New(config)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create Secure Workload client", err.Error())
+		return
+	}
+	resp.DataSourceData = client
+	resp.ResourceData = client
+}
+
+func (p *frameworkProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		NewScopeResource,
+		NewLabelsBulkResource,
+		NewFilterResource,
+		NewLabelResource,
+		NewLabelSchemaResource,
+	}
+}
+
+func (p *frameworkProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		NewInventoryDataSource,
+	}
+}
+
+// stringWithEnvDefault returns the configured value, or the
+// value of the environment variable when not configured.
+func stringWithEnvDefault(value types.String, env string) string {
+	if value.IsNull() {
+		return os.Getenv(env)
+	}
+	return value.ValueString()
+}
+
+// int64WithEnvDefault returns the configured value, or the value of
+// the environment variable when not configured, reporting whether
+// either of them was set.
+func int64WithEnvDefault(value types.Int64, env string) (int64, bool) {
+	if !value.IsNull() {
+		return value.ValueInt64(), true
+	}
+	number, err := strconv.ParseInt(os.Getenv(env), 10, 64)
+	return number, err == nil
+}
+
+// clientFromProviderData returns the client the provider was configured
+// with, for the Configure method of framework resources and data sources.
+// It returns false when the provider has not been configured yet.
+func clientFromProviderData(providerData any, diagnostics *diag.Diagnostics) (Client, bool) {
+	if providerData == nil {
+		return Client{}, false
+	}
+	client, ok := providerData.(Client)
+	if !ok {
+		diagnostics.AddError("Unexpected provider data", "Expected the Secure Workload provider to be configured with a client.")
+	}
+	return client, ok
+}
@@ -0,0 +1,263 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestProviderServerServesMatchingSchemas(t *testing.T) {
+	ctx := context.Background()
+	server, err := ProviderServer(ctx)
+	if err != nil {
+		t.Fatalf("Error %s creating provider server", err)
+	}
+	resp, err := server().GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("Error %s getting provider schema", err)
+	}
+	for _, diagnostic := range resp.Diagnostics {
+		t.Errorf("Unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+	for name := range Provider().ResourcesMap {
+		if _, ok := resp.ResourceSchemas[name]; !ok {
+			t.Errorf("Expected resource %s to be served", name)
+		}
+	}
+	for name := range Provider().DataSourcesMap {
+		if _, ok := resp.DataSourceSchemas[name]; !ok {
+			t.Errorf("Expected data source %s to be served", name)
+		}
+	}
+	p := &frameworkProvider{}
+	for _, newResource := range p.Resources(ctx) {
+		metadataResp := &resource.MetadataResponse{}
+		newResource().Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "secureworkload"}, metadataResp)
+		if _, ok := resp.ResourceSchemas[metadataResp.TypeName]; !ok {
+			t.Errorf("Expected resource %s to be served", metadataResp.TypeName)
+		}
+		if _, ok := Provider().ResourcesMap[metadataResp.TypeName]; ok {
+			t.Errorf("Expected resource %s to be served by the framework only", metadataResp.TypeName)
+		}
+	}
+	for _, newDataSource := range p.DataSources(ctx) {
+		metadataResp := &datasource.MetadataResponse{}
+		newDataSource().Metadata(ctx, datasource.MetadataRequest{ProviderTypeName: "secureworkload"}, metadataResp)
+		if _, ok := resp.DataSourceSchemas[metadataResp.TypeName]; !ok {
+			t.Errorf("Expected data source %s to be served", metadataResp.TypeName)
+		}
+		if _, ok := Provider().DataSourcesMap[metadataResp.TypeName]; ok {
+			t.Errorf("Expected data source %s to be served by the framework only", metadataResp.TypeName)
+		}
+	}
+}
+
+func TestProviderServerUpgradesSDKScopeState(t *testing.T) {
+	ctx := context.Background()
+	server, err := ProviderServer(ctx)
+	if err != nil {
+		t.Fatalf("Error %s creating provider server", err)
+	}
+	// State written by the terraform-plugin-sdk/v2 scope resource
+	sdkState := `{
+		"id": "2",
+		"short_name": "web",
+		"sub_type": "",
+		"description": "",
+		"parent_app_scope_id": "1",
+		"policy_priority": 3,
+		"query": [],
+		"query_json": "{\"field\":\"ip\",\"type\":\"subnet\",\"value\":\"10.0.0.0/24\"}",
+		"short_query": "{\"field\":\"ip\",\"type\":\"subnet\",\"value\":\"10.0.0.0/24\"}",
+		"name": "acme:web",
+		"root_app_scope_id": "1",
+		"vrf_id": 1,
+		"priority": "3",
+		"short_priority": 3,
+		"dirty": false,
+		"child_app_scope_ids": null,
+		"created_at": 1600000000,
+		"updated_at": 1600000000,
+		"timeouts": {"delete": "30m"}
+	}`
+	resp, err := server().UpgradeResourceState(ctx, &tfprotov5.UpgradeResourceStateRequest{
+		TypeName: "secureworkload_scope",
+		Version:  1,
+		RawState: &tfprotov5.RawState{JSON: []byte(sdkState)},
+	})
+	if err != nil {
+		t.Fatalf("Error %s upgrading state", err)
+	}
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("Unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+	r := NewScopeResource()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	upgraded, err := resp.UpgradedState.Unmarshal(schemaResp.Schema.Type().TerraformType(ctx))
+	if err != nil {
+		t.Fatalf("Error %s decoding upgraded state", err)
+	}
+	var state scopeResourceModel
+	if diags := (tfsdk.State{Schema: schemaResp.Schema, Raw: upgraded}).Get(ctx, &state); diags.HasError() {
+		t.Fatalf("Error decoding upgraded state: %v", diags)
+	}
+	if state.Id.ValueString() != "2" || state.Name.ValueString() != "acme:web" || state.PolicyPriority.ValueInt64() != 3 ||
+		!queryJSONEqual(state.QueryJSON.ValueString(), `{"type": "subnet", "field": "ip", "value": "10.0.0.0/24"}`) {
+		t.Errorf("Unexpected upgraded state %+v", state)
+	}
+	if !state.Description.IsNull() || !state.SubType.IsNull() {
+		t.Errorf("Expected unset strings to be null, got %+v", state)
+	}
+	if state.Timeouts == nil || state.Timeouts.Delete.ValueString() != "30m" {
+		t.Errorf("Expected the delete timeout to be kept, got %+v", state.Timeouts)
+	}
+}
+
+func TestProviderServerReadsSDKLabelState(t *testing.T) {
+	ctx := context.Background()
+	server, err := ProviderServer(ctx)
+	if err != nil {
+		t.Fatalf("Error %s creating provider server", err)
+	}
+	// State written by the terraform-plugin-sdk/v2 label resource
+	sdkState := `{
+		"id": "acme|10.0.0.1",
+		"root_scope_name": "acme",
+		"ip": "10.0.0.1",
+		"attributes": {"Environment": "test"}
+	}`
+	resp, err := server().UpgradeResourceState(ctx, &tfprotov5.UpgradeResourceStateRequest{
+		TypeName: "secureworkload_label",
+		Version:  1,
+		RawState: &tfprotov5.RawState{JSON: []byte(sdkState)},
+	})
+	if err != nil {
+		t.Fatalf("Error %s upgrading state", err)
+	}
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("Unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+	r := NewLabelResource()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	upgraded, err := resp.UpgradedState.Unmarshal(schemaResp.Schema.Type().TerraformType(ctx))
+	if err != nil {
+		t.Fatalf("Error %s decoding upgraded state", err)
+	}
+	var state labelResourceModel
+	if diags := (tfsdk.State{Schema: schemaResp.Schema, Raw: upgraded}).Get(ctx, &state); diags.HasError() {
+		t.Fatalf("Error decoding upgraded state: %v", diags)
+	}
+	if state.Id.ValueString() != "acme|10.0.0.1" || state.RootScopeName.ValueString() != "acme" ||
+		state.Attributes.Elements()["Environment"].String() != `"test"` {
+		t.Errorf("Unexpected upgraded state %+v", state)
+	}
+}
+
+// frameworkResourceValue returns a value of the schema of the framework
+// resource r, with the given attributes and all the others null.
+func frameworkResourceValue(t *testing.T, r resource.Resource, attributes map[string]tftypes.Value) tftypes.Value {
+	t.Helper()
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		if value, ok := attributes[name]; ok {
+			values[name] = value
+		} else {
+			values[name] = tftypes.NewValue(attributeType, nil)
+		}
+	}
+	return tftypes.NewValue(objectType, values)
+}
+
+// modifyFrameworkPlan calls the ModifyPlan method of the framework resource r
+// with the given state, configuration and proposed plan, see frameworkResourceValue.
+func modifyFrameworkPlan(t *testing.T, r resource.ResourceWithModifyPlan, state, config, plan tftypes.Value) *resource.ModifyPlanResponse {
+	t.Helper()
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+	req := resource.ModifyPlanRequest{
+		State:  tfsdk.State{Schema: s, Raw: state},
+		Config: tfsdk.Config{Schema: s, Raw: config},
+		Plan:   tfsdk.Plan{Schema: s, Raw: plan},
+	}
+	resp := &resource.ModifyPlanResponse{Plan: req.Plan}
+	r.ModifyPlan(ctx, req, resp)
+	return resp
+}
+
+func TestScopePlanKeepsComputedAttributesThatDoNotChange(t *testing.T) {
+	ctx := context.Background()
+	r := &scopeResource{}
+	subnet := `{"field":"ip","type":"subnet","value":"10.0.0.0/24"}`
+	configured := func(description string, policyPriority int, query string) map[string]tftypes.Value {
+		return map[string]tftypes.Value{
+			"short_name":          tftypes.NewValue(tftypes.String, "web"),
+			"description":         tftypes.NewValue(tftypes.String, description),
+			"parent_app_scope_id": tftypes.NewValue(tftypes.String, "1"),
+			"policy_priority":     tftypes.NewValue(tftypes.Number, policyPriority),
+			"query_json":          tftypes.NewValue(tftypes.String, query),
+		}
+	}
+	state := configured("", 3, subnet)
+	state["id"] = tftypes.NewValue(tftypes.String, "2")
+	state["name"] = tftypes.NewValue(tftypes.String, "acme:web")
+	state["priority"] = tftypes.NewValue(tftypes.String, "3")
+	state["short_priority"] = tftypes.NewValue(tftypes.Number, 3)
+	state["dirty"] = tftypes.NewValue(tftypes.Bool, false)
+	state["updated_at"] = tftypes.NewValue(tftypes.Number, 1600000000)
+	cases := []struct {
+		config          map[string]tftypes.Value
+		keepsPriorities bool
+		keepsDirty      bool
+	}{
+		{configured("web servers", 3, subnet), true, true},
+		{configured("", 4, subnet), false, true},
+		{configured("", 3, `{"field":"ip","type":"eq","value":"10.0.0.1"}`), true, false},
+	}
+	for _, c := range cases {
+		// Planned after the attribute plan modifiers ran
+		plan := map[string]tftypes.Value{
+			"id":             state["id"],
+			"name":           state["name"],
+			"priority":       tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			"short_priority": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+			"dirty":          tftypes.NewValue(tftypes.Bool, tftypes.UnknownValue),
+			"updated_at":     tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+		}
+		for name, value := range c.config {
+			plan[name] = value
+		}
+		resp := modifyFrameworkPlan(t, r, frameworkResourceValue(t, r, state), frameworkResourceValue(t, r, c.config), frameworkResourceValue(t, r, plan))
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("Unexpected diagnostics %v", resp.Diagnostics)
+		}
+		var planned scopeResourceModel
+		if diags := resp.Plan.Get(ctx, &planned); diags.HasError() {
+			t.Fatalf("Error decoding plan: %v", diags)
+		}
+		if c.keepsPriorities && (planned.Priority.ValueString() != "3" || planned.ShortPriority.ValueInt64() != 3) ||
+			!c.keepsPriorities && (!planned.Priority.IsUnknown() || !planned.ShortPriority.IsUnknown()) {
+			t.Errorf("Expected priorities to be kept %v, got %s and %s", c.keepsPriorities, planned.Priority, planned.ShortPriority)
+		}
+		if planned.Dirty.IsUnknown() == c.keepsDirty {
+			t.Errorf("Expected dirty to be kept %v, got %s", c.keepsDirty, planned.Dirty)
+		}
+		if !planned.UpdatedAt.IsUnknown() {
+			t.Errorf("Expected updated_at to be unknown, got %s", planned.UpdatedAt)
+		}
+	}
+}
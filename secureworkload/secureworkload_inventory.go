@@ -0,0 +1,166 @@
+package secureworkload
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	// "github.com/secureworkload-exchange/terraform-go-sdk/signer"
+	"terraform-provider-secureworkload/secureworkload/signer"
+)
+
+var (
+	InventoryAPIV1BasePath = fmt.Sprintf("%s/inventory", SecureWorkloadAPIV1BasePath)
+)
+
+const (
+	// DefaultInventoryPageSize is the number of inventory items requested at
+	// once from inventory search, when the request does not specify otherwise.
+	DefaultInventoryPageSize = 1000
+)
+
+// InventorySearchRequest wraps the parameters of an inventory search.
+type InventorySearchRequest struct {
+	// Inventory filter query matched by the returned items.
+	Filter json.RawMessage `json:"filter"`
+	// (Optional) Fully qualified name of the scope the search is restricted to.
+	ScopeName string `json:"scopeName,omitempty"`
+	// (Optional) Fields returned for each item, all of them when empty.
+	Dimensions []string `json:"dimensions,omitempty"`
+	// (Optional) Start and end of the time range of the search, in
+	// seconds since the epoch, the last hour when unset.
+	T0 int64 `json:"t0,omitempty"`
+	T1 int64 `json:"t1,omitempty"`
+	// Number of items requested at once, defaults to DefaultInventoryPageSize.
+	Limit int `json:"limit,omitempty"`
+	// Opaque offset returned with the previous page, if any.
+	Offset string `json:"offset,omitempty"`
+}
+
+// InventoryItem is an address or subnet returned by an inventory search,
+// holding the values of the requested dimensions by field name.
+type InventoryItem map[string]interface{}
+
+// String returns the value of a field of the item as a string,
+// or the empty string when the field is not set.
+func (item InventoryItem) String(field string) string {
+	return queryValueToTerraform(item[field])
+}
+
+// Labels returns the user annotations, also known as labels,
+// of the item, without the prefix of their fields.
+func (item InventoryItem) Labels() map[string]string {
+	labels := map[string]string{}
+	for field := range item {
+		if strings.HasPrefix(field, annotationFieldPrefix) {
+			if value := item.String(field); value != "" {
+				labels[strings.TrimPrefix(field, annotationFieldPrefix)] = value
+			}
+		}
+	}
+	return labels
+}
+
+// inventorySearchPage is one page of inventory search results.
+type inventorySearchPage struct {
+	Results []InventoryItem `json:"results"`
+	// Offset of the next page, empty on the last one.
+	Offset string `json:"offset"`
+}
+
+// SearchInventory returns the inventory items matching the search,
+// requesting one page at a time until all of them, or maxResults of
+// them when it is positive, have been returned. The items are sorted
+// by address.
+func (c Client) SearchInventory(params InventorySearchRequest, maxResults int) ([]InventoryItem, error) {
+	items, err := c.searchInventory(params, maxResults)
+	if err != nil {
+		return nil, err
+	}
+	sortInventoryItems(items)
+	return items, nil
+}
+
+// SearchInventoryTruncated is like SearchInventory, but also returns whether
+// more than maxResults items match the search. It asks for one more item,
+// which is left out before the items are sorted, so that the same items are
+// returned whether or not there are more.
+func (c Client) SearchInventoryTruncated(params InventorySearchRequest, maxResults int) ([]InventoryItem, bool, error) {
+	items, err := c.searchInventory(params, maxResults+1)
+	if err != nil {
+		return nil, false, err
+	}
+	truncated := len(items) > maxResults
+	if truncated {
+		items = items[:maxResults]
+	}
+	sortInventoryItems(items)
+	return items, truncated, nil
+}
+
+// searchInventory returns the first maxResults inventory items matching
+// the search, or all of them, in the order returned by the API.
+func (c Client) searchInventory(params InventorySearchRequest, maxResults int) ([]InventoryItem, error) {
+	if params.Limit <= 0 {
+		params.Limit = DefaultInventoryPageSize
+	}
+	url := c.Config.APIURL + InventoryAPIV1BasePath + "/search"
+	items := []InventoryItem{}
+	for {
+		if maxResults > 0 && maxResults-len(items) < params.Limit {
+			params.Limit = maxResults - len(items)
+		}
+		request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
+		if err != nil {
+			return nil, err
+		}
+		var page inventorySearchPage
+		if err := c.Do(request, &page); err != nil {
+			return nil, err
+		}
+		items = append(items, page.Results...)
+		if page.Offset == "" || len(page.Results) == 0 || (maxResults > 0 && len(items) >= maxResults) {
+			break
+		}
+		params.Offset = page.Offset
+	}
+	// Endpoints may return more items than the limit
+	if maxResults > 0 && len(items) > maxResults {
+		items = items[:maxResults]
+	}
+	return items, nil
+}
+
+// sortInventoryItems sorts inventory items by address.
+func sortInventoryItems(items []InventoryItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].String("ip") < items[j].String("ip")
+	})
+}
+
+// inventoryTimeRange returns the bounds of an inventory search time range
+// given in RFC 3339 format, where unset bounds are left to the API.
+func inventoryTimeRange(start string, end string) (int64, int64, error) {
+	var t0, t1 int64
+	if start != "" {
+		parsed, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return 0, 0, fmt.Errorf("start_time must be an RFC 3339 time like 2024-01-02T15:04:05Z, got %q", start)
+		}
+		t0 = parsed.Unix()
+	}
+	if end != "" {
+		parsed, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return 0, 0, fmt.Errorf("end_time must be an RFC 3339 time like 2024-01-02T15:04:05Z, got %q", end)
+		}
+		t1 = parsed.Unix()
+	}
+	if t0 != 0 && t1 != 0 && t0 > t1 {
+		return 0, 0, fmt.Errorf("start_time must not be after end_time, got %s and %s", start, end)
+	}
+	return t0, t1, nil
+}
@@ -0,0 +1,111 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSearchInventoryPagesThroughResults(t *testing.T) {
+	var requests []InventorySearchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != InventoryAPIV1BasePath+"/search" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var request InventorySearchRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, request)
+		switch request.Offset {
+		case "":
+			fmt.Fprint(w, `{"offset": "page2", "results": [{"ip": "10.0.0.2"}, {"ip": "10.0.0.1", "user_Environment": "test"}]}`)
+		case "page2":
+			fmt.Fprint(w, `{"results": [{"ip": "10.0.0.3", "host_name": "web-3"}]}`)
+		default:
+			t.Errorf("Unexpected offset %q", request.Offset)
+		}
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	items, err := client.SearchInventory(InventorySearchRequest{
+		Filter:    json.RawMessage(`{"type":"subnet","field":"ip","value":"10.0.0.0/24"}`),
+		ScopeName: "acme:web",
+		Limit:     2,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ips []string
+	for _, item := range items {
+		ips = append(ips, item.String("ip"))
+	}
+	if expected := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}; !reflect.DeepEqual(ips, expected) {
+		t.Errorf("Expected items %v sorted by address, got %v", expected, ips)
+	}
+	if labels := items[0].Labels(); !reflect.DeepEqual(labels, map[string]string{"Environment": "test"}) {
+		t.Errorf("Expected the labels of the annotated item, got %v", labels)
+	}
+	if len(requests) != 2 || requests[1].ScopeName != "acme:web" || requests[1].Limit != 2 {
+		t.Errorf("Expected two pages of the same search, got %+v", requests)
+	}
+}
+
+func TestSearchInventoryStopsAtMaxResults(t *testing.T) {
+	var limits []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request InventorySearchRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		limits = append(limits, request.Limit)
+		fmt.Fprint(w, `{"offset": "next", "results": [{"ip": "10.0.0.1"}, {"ip": "10.0.0.2"}]}`)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	items, err := client.SearchInventory(InventorySearchRequest{Filter: json.RawMessage(`{}`), Limit: 2}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || !reflect.DeepEqual(limits, []int{2, 1}) {
+		t.Errorf("Expected the last page to be limited to the missing item, got %d items with limits %v", len(items), limits)
+	}
+}
+
+func TestSearchInventoryTruncatedLeavesOutTheExtraItem(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"results": [{"ip": "10.0.0.9"}, {"ip": "10.0.0.1"}, {"ip": "10.0.0.2"}]}`)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	items, truncated, err := client.SearchInventoryTruncated(InventorySearchRequest{Filter: json.RawMessage(`{}`)}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ips := []string{}
+	for _, item := range items {
+		ips = append(ips, item.String("ip"))
+	}
+	if !truncated || !reflect.DeepEqual(ips, []string{"10.0.0.1", "10.0.0.9"}) {
+		t.Errorf("Expected the first two items sorted and truncated, got %v, %t", ips, truncated)
+	}
+	items, truncated, err = client.SearchInventoryTruncated(InventorySearchRequest{Filter: json.RawMessage(`{}`)}, 3)
+	if err != nil || truncated || len(items) != 3 {
+		t.Errorf("Expected all three items, got %v, %t, %v", items, truncated, err)
+	}
+}
+
+func TestInventoryTimeRangeRejectsReversedRanges(t *testing.T) {
+	t0, t1, err := inventoryTimeRange("2024-01-02T15:04:05Z", "")
+	if err != nil || t0 != 1704207845 || t1 != 0 {
+		t.Errorf("Expected only the start of the range, got %d, %d, %v", t0, t1, err)
+	}
+	for _, bounds := range [][2]string{{"2024-01-02", ""}, {"2024-01-02T15:04:05Z", "2024-01-01T15:04:05Z"}} {
+		if _, _, err := inventoryTimeRange(bounds[0], bounds[1]); err == nil {
+			t.Errorf("Expected range %v to be rejected", bounds)
+		}
+	}
+}
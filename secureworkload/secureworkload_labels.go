@@ -31,9 +31,10 @@ type CreateTagRequest struct {
 // params, returning the created tags and error
 // (if any).
 func (c Client) CreateTag(params CreateTagRequest) (Tag, error) {
+	defer c.invalidateLookups(annotationKeyLookups)
 	var tag Tag
 	url := c.Config.APIURL + TagsAPIV1BasePath + fmt.Sprintf("/%s", params.RootScopeName)
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return tag, err
 	}
@@ -59,7 +60,7 @@ type DescribeTagRequest struct {
 // returning the tag and error (if any).
 func (c Client) DescribeTag(params DescribeTagRequest, attributesTemplate *map[string]string) error {
 	url := c.Config.APIURL + TagsAPIV1BasePath + fmt.Sprintf("/%s?ip=%s", params.RootAppScopeName, params.Ip)
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
@@ -78,7 +79,7 @@ type DeleteTagRequest struct {
 // returning error if any
 func (c Client) DeleteTag(params DeleteTagRequest) error {
 	url := c.Config.APIURL + TagsAPIV1BasePath + fmt.Sprintf("/%s", params.RootAppScopeName)
-	request, err := signer.CreateJSONRequest(http.MethodDelete, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, params)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,165 @@
+package secureworkload
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	// "github.com/secureworkload-exchange/terraform-go-sdk/signer"
+	"terraform-provider-secureworkload/secureworkload/signer"
+)
+
+// ListOptions narrows down the results of a list request.
+type ListOptions struct {
+	// (Optional) Returns only the items with this exact name. The name is
+	// sent to the API for the endpoints that filter by name server-side,
+	// and matched while decoding the response for the others.
+	// Scopes are matched on their fully qualified name.
+	Name string
+	// (Optional) Returns only the scopes with this exact short name.
+	ShortName string
+}
+
+// listEndpoint describes how the items of one kind are listed.
+type listEndpoint[T any] struct {
+	// URL of the endpoint, without query parameters.
+	url string
+	// Query parameters sent with the request.
+	query url.Values
+	// prepare finishes decoding an item, e.g. by parsing its raw query.
+	prepare func(*T) error
+	// match reports whether a decoded item is returned, nil returns all.
+	match func(T) bool
+}
+
+// ListIterator iterates over the items returned by a list endpoint.
+// None of the endpoints support paging, so all items are fetched with
+// a single GET whose response is decoded as a stream, so that items
+// which are not returned are never held in memory.
+//
+//	filters := client.IterateFilters(ListOptions{Name: "web"})
+//	for filters.Next() {
+//		filter := filters.Item()
+//	}
+//	if err := filters.Err(); err != nil {
+//		return err
+//	}
+type ListIterator[T any] struct {
+	client   Client
+	endpoint listEndpoint[T]
+	items    []T
+	item     T
+	fetched  bool
+	err      error
+}
+
+func newListIterator[T any](c Client, endpoint listEndpoint[T]) *ListIterator[T] {
+	return &ListIterator[T]{
+		client:   c,
+		endpoint: endpoint,
+	}
+}
+
+// Next advances the iterator to the next item, fetching the items
+// on the first call. It returns false once all items have been
+// returned or the request failed, see Err.
+func (it *ListIterator[T]) Next() bool {
+	if !it.fetched {
+		it.fetched = true
+		it.err = it.fetch()
+	}
+	if it.err != nil || len(it.items) == 0 {
+		return false
+	}
+	it.item, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Item returns the current item of the iterator.
+func (it *ListIterator[T]) Item() T {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ListIterator[T]) Err() error {
+	return it.err
+}
+
+// fetch requests the items.
+func (it *ListIterator[T]) fetch() error {
+	url := it.endpoint.url
+	if len(it.endpoint.query) > 0 {
+		url += "?" + it.endpoint.query.Encode()
+	}
+	request, err := signer.CreateJSONRequestWithContext(it.client.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	page := listPage[T]{
+		prepare: it.endpoint.prepare,
+		match:   it.endpoint.match,
+	}
+	err = it.client.Do(request, &page)
+	if err != nil {
+		return err
+	}
+	it.items = page.items
+	return nil
+}
+
+// collect drains the iterator, returning all of its items.
+func collect[T any](it *ListIterator[T]) ([]T, error) {
+	var items []T
+	for it.Next() {
+		items = append(items, it.Item())
+	}
+	return items, it.Err()
+}
+
+// listPage decodes a list response, one item at a time.
+type listPage[T any] struct {
+	prepare func(*T) error
+	match   func(T) bool
+	// Items kept after matching.
+	items []T
+}
+
+func (p *listPage[T]) decodeStream(decoder *json.Decoder) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	// Some endpoints return null rather than an empty list
+	if token == nil {
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected a list in the response, got %v", token)
+	}
+	for decoder.More() {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+		if p.prepare != nil {
+			if err := p.prepare(&item); err != nil {
+				return err
+			}
+		}
+		if p.match == nil || p.match(item) {
+			p.items = append(p.items, item)
+		}
+	}
+	// Consume the closing bracket
+	_, err = decoder.Token()
+	return err
+}
+
+// unmarshalQuery parses a raw query returned by the API, if any.
+func unmarshalQuery(queryJSON json.RawMessage, query *map[string]interface{}) error {
+	if len(queryJSON) == 0 {
+		return nil
+	}
+	return json.Unmarshal(queryJSON, query)
+}
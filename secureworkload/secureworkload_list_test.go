@@ -0,0 +1,66 @@
+// +build all unittests
+
+package secureworkload
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIterateFiltersSendsSingleRequest(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		fmt.Fprint(w, `[{"id": "1"}, {"id": "2", "query": {"type": "eq", "field": "ip", "value": "10.0.0.2"}}]`)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	filters, err := collect(client.IterateFilters(ListOptions{}))
+	if err != nil {
+		t.Fatalf("Expected no error listing filters, got %s", err)
+	}
+	if len(filters) != 2 || len(requests) != 1 || requests[0] != "" {
+		t.Errorf("Expected 2 filters from a single request without paging parameters, got %d from %q", len(filters), requests)
+	}
+	if filters[1].Query["value"] != "10.0.0.2" {
+		t.Errorf("Expected the query of listed filters to be parsed, got %+v", filters[1].Query)
+	}
+}
+
+func TestIterateScopesFiltersByName(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		// Answer as an API matching names by prefix would
+		fmt.Fprint(w, `[{"id": "1", "name": "Root:Web", "short_name": "Web"}, {"id": "2", "name": "Root:Web:Frontend", "short_name": "Frontend"}]`)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	scopes, err := collect(client.IterateScopes(ListOptions{Name: "Root:Web"}))
+	if err != nil {
+		t.Fatalf("Expected no error listing scopes, got %s", err)
+	}
+	if query != "exact_name=Root%3AWeb" {
+		t.Errorf("Expected the name to be sent to the API, got query %q", query)
+	}
+	if len(scopes) != 1 || scopes[0].Id != "1" {
+		t.Errorf("Expected only the scope with the exact name, got %+v", scopes)
+	}
+}
+
+func TestListIteratorReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"error": "not a list"}`)
+	}))
+	defer server.Close()
+	client, _ := newUnitTestClient(t, server, 0)
+	users := client.IterateUsers(ListUsersRequest{})
+	if users.Next() {
+		t.Errorf("Expected no users, got %+v", users.Item())
+	}
+	if users.Err() == nil {
+		t.Error("Expected an error decoding a response which is not a list")
+	}
+}
@@ -1,7 +1,6 @@
 package secureworkload
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -14,13 +13,26 @@ var (
 )
 
 type Policies struct {
-	Id         string `json:"id"`
-	ConsumerId string `json:"consumer_filter_id"`
-	ProviderId string `json:"provider_filter_id"`
-	Version    string `json:"version,omitempty"`
-	Rank       string `json:"rank,omitempty"`
-	Action     string `json:"policy_action"`
-	Priority   int    `json:"priority,omitempty"`
+	Id         string          `json:"id"`
+	ConsumerId string          `json:"consumer_filter_id"`
+	ProviderId string          `json:"provider_filter_id"`
+	Version    string          `json:"version,omitempty"`
+	Rank       string          `json:"rank,omitempty"`
+	Action     string          `json:"policy_action"`
+	Priority   int             `json:"priority,omitempty"`
+	L4Params   []PolicyL4Param `json:"l4_params,omitempty"`
+}
+
+// PolicyL4Param wraps a protocol and port range
+// attached to a policy, as returned by the API.
+type PolicyL4Param struct {
+	Id string `json:"id"`
+	// Protocol integer value (NULL means all protocols).
+	Proto int `json:"proto,omitempty"`
+	// Inclusive range of ports; for example, [80, 80] or [5000, 6000].
+	Port        [2]int `json:"port"`
+	Approved    bool   `json:"approved"`
+	Description string `json:"description,omitempty"`
 }
 
 type CreatePolicyRequest struct {
@@ -30,19 +42,12 @@ type CreatePolicyRequest struct {
 	Rank       string `json:"rank,omitempty"`
 	Action     string `json:"policy_action"`
 	Priority   int    `json:"priority,omitempty"`
-	// Layer4NetworkPolicies []Layer4Network `json:"l4_params"`
 }
 
-// type Layer4Network struct {
-// 	Protocol  int    `json:"proto,omitempty"`
-// 	PortRange [2]int `json:"port"`
-// 	Approved  bool   `json:"approved"`
-// }
-
 func (c Client) CreatePolicy(params CreatePolicyRequest, workspace_id string) (Policies, error) {
 	var policy Policies
 	url := c.Config.APIURL + PolicyAPIV1BasePath + workspace_id + "/policies"
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return policy, err
 	}
@@ -53,7 +58,7 @@ func (c Client) CreatePolicy(params CreatePolicyRequest, workspace_id string) (P
 func (c Client) DescribePolicy(policyId string) (Policies, error) {
 	var policy Policies
 	url := c.Config.APIURL + SecureWorkloadAPIV1BasePath + "/policies" + fmt.Sprintf("/%s", policyId)
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return policy, err
 	}
@@ -63,29 +68,32 @@ func (c Client) DescribePolicy(policyId string) (Policies, error) {
 
 func (c Client) DeletePolicy(workspace_id string, policyId string) error {
 	url := c.Config.APIURL + SecureWorkloadAPIV1BasePath + "/policies" + fmt.Sprintf("/%s", policyId)
-	request, err := signer.CreateJSONRequest(http.MethodDelete, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
 	return c.Do(request, nil)
 }
 
-func (c Client) ListPolicy(workspace_id string) ([]Clusters, error) {
-	var clusters []Clusters
-	url := c.Config.APIURL + ClustersAPIV1BasePath + workspace_id + "/clusters"
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return clusters, err
+// ApplicationPolicies wraps the policies of a workspace version grouped by rank.
+type ApplicationPolicies struct {
+	AbsolutePolicies []Policies `json:"absolute_policies"`
+	DefaultPolicies  []Policies `json:"default_policies"`
+}
+
+// ListPolicies lists the policies of a workspace for the given
+// version (defaulting to latest) along with their layer 4 parameters,
+// returning the listed policies and error (if any).
+func (c Client) ListPolicies(workspace_id string, version string) (ApplicationPolicies, error) {
+	var policies ApplicationPolicies
+	url := c.Config.APIURL + PolicyAPIV1BasePath + workspace_id + "/policies"
+	if version != "" {
+		url += fmt.Sprintf("?version=%s", version)
 	}
-	err = c.Do(request, &clusters)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
-		return clusters, err
-	}
-	for _, filter := range clusters {
-		err = json.Unmarshal(filter.QueryJSON, &filter.Query)
-		if err != nil {
-			return clusters, err
-		}
+		return policies, err
 	}
-	return clusters, err
+	err = c.Do(request, &policies)
+	return policies, err
 }
@@ -27,12 +27,13 @@ type CreatePortRequest struct {
 	Version     string `json:"version,omitempty"`
 	Description string `json:"description,omitempty"`
 	Proto       int    `json:"proto,omitempty"`
+	Approved    bool   `json:"approved,omitempty"`
 }
 
 func (c Client) CreatePort(params CreatePortRequest, policy_id string) (Port, error) {
 	var port Port
 	url := c.Config.APIURL + PortsAPIV1BasePath + policy_id + "/l4_params"
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return port, err
 	}
@@ -43,7 +44,7 @@ func (c Client) CreatePort(params CreatePortRequest, policy_id string) (Port, er
 func (c Client) DescribePort(policy_id string, portId string) (Port, error) {
 	var port Port
 	url := c.Config.APIURL + SecureWorkloadAPIV1BasePath + "/l4_params" + fmt.Sprintf("/%s", portId)
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return port, err
 	}
@@ -53,9 +54,9 @@ func (c Client) DescribePort(policy_id string, portId string) (Port, error) {
 
 func (c Client) DeletePort(policy_id string, portId string) error {
 	url := c.Config.APIURL + PortsAPIV1BasePath + policy_id + "/l4_params" + fmt.Sprintf("/%s", portId)
-	_, err := signer.CreateJSONRequest(http.MethodDelete, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
-	return nil
+	return c.Do(request, nil)
 }
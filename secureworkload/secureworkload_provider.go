@@ -2,6 +2,7 @@ package secureworkload
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -9,27 +10,30 @@ import (
 	// client "github.com/secureworkload-exchange/terraform-go-sdk"
 )
 
-// Provider returns a terraform resource provider for managing secureworkload resources.
+// Provider returns a terraform resource provider for managing secureworkload resources
+// written on terraform-plugin-sdk/v2. Its schema must match the one of the framework
+// provider returned by NewFrameworkProvider, as both are served by ProviderServer.
 func Provider() *schema.Provider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"api_key": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("SECUREWORKLOAD_API_KEY", nil),
-				Description: "API key for calculating request signatures for SecureWorkload API calls.",
+				Description: "API key for calculating request signatures for SecureWorkload API calls. Can also be set with the SECUREWORKLOAD_API_KEY environment variable.",
 			},
 			"api_secret": {
-				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("SECUREWORKLOAD_API_SECRET", nil),
-				Description: "API secret for calculating request signatures for SecureWorkload API calls.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("SECUREWORKLOAD_API_SECRET", nil),
+				ValidateFunc: validateAPISecret,
+				Description:  "API secret for calculating request signatures for SecureWorkload API calls. Can also be set with the SECUREWORKLOAD_API_SECRET environment variable.",
 			},
 			"api_url": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("SECUREWORKLOAD_API_URL", nil),
-				Description: "URL for a SecureWorkload API.",
+				Description: "URL for a SecureWorkload API. Can also be set with the SECUREWORKLOAD_API_URL environment variable.",
 			},
 			"disable_tls_verification": {
 				Type:        schema.TypeBool,
@@ -37,18 +41,67 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("SECUREWORKLOAD_DISABLE_TLS_VERIFICATION", false),
 				Description: "Allow connections to SecureWorkload endpoints without validating their TLS certificate.",
 			},
+			"max_retries": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SECUREWORKLOAD_MAX_RETRIES", DefaultMaxRetries),
+				Description: "Maximum number of times a request is retried after being rate limited (429), failing with a server error (5xx) or losing its connection. Set to 0 to disable retries.",
+			},
+			"retry_max_wait": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SECUREWORKLOAD_RETRY_MAX_WAIT", int(DefaultRetryMaxWait/time.Second)),
+				Description: "Maximum number of seconds to wait between two attempts of the same request, including waits requested by the API through the Retry-After header.",
+			},
+			"request_timeout": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SECUREWORKLOAD_REQUEST_TIMEOUT", int(DefaultRequestTimeout/time.Second)),
+				Description: "Maximum number of seconds a single attempt of a request may take, including reading the response, before it is cancelled.",
+			},
+			"membership_warning_threshold": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SECUREWORKLOAD_MEMBERSHIP_WARNING_THRESHOLD", DefaultMembershipWarningThreshold),
+				Description: "Number of inventory items that may join or leave a scope or filter whose query changes before plans warn about it, listing example addresses. The members are previewed by searching the inventory with both queries, so previews are disabled by default, with -1. Set to 0 to warn about any change. Can also be set with the SECUREWORKLOAD_MEMBERSHIP_WARNING_THRESHOLD environment variable.",
+			},
+			"service": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Services policies can reference by name, in addition to the default ones. Several blocks with the same name make up a single service, e.g. one for tcp and one for udp. Services named like a default one replace it.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Name of the service, referenced by the service attribute of policies.",
+						},
+						"protocol": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateProtocol,
+							Description:  "Protocol name (any, icmp, tcp, udp or icmpv6) or number.",
+						},
+						"ports": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validatePorts,
+							Description:  "Ports of the service, as a comma separated list of ports and port ranges, e.g. 80,443,8000-8100. Empty means all ports.",
+						},
+					},
+				},
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"secureworkload_filter":    resourceSecureWorkloadFilter(),
-			"secureworkload_scope":     resourceSecureWorkloadScope(),
-			"secureworkload_label":     resourceSecureWorkloadLabel(),
-			"secureworkload_user":      resourceSecureWorkloadUser(),
-			"secureworkload_workspace": resourceSecureWorkloadApplication(),
-			"secureworkload_role":      resourceSecureWorkloadRole(),
-			"secureworkload_cluster":   resourceSecureWorkloadCluster(),
-			"secureworkload_policies":  resourceSecureWorkloadPolicy(),
-			"secureworkload_port":      resourceSecureWorkloadPort(),
-			"secureworkload_enforce":   resourceSecureWorkloadEnforce(),
+			"secureworkload_scope_commit":            resourceSecureWorkloadScopeCommit(),
+			"secureworkload_user":                    resourceSecureWorkloadUser(),
+			"secureworkload_workspace":               resourceSecureWorkloadApplication(),
+			"secureworkload_workspace_policies_json": resourceSecureWorkloadWorkspacePoliciesJSON(),
+			"secureworkload_role":                    resourceSecureWorkloadRole(),
+			"secureworkload_cluster":                 resourceSecureWorkloadCluster(),
+			"secureworkload_policies":                resourceSecureWorkloadPolicy(),
+			"secureworkload_port":                    resourceSecureWorkloadPort(),
+			"secureworkload_enforce":                 resourceSecureWorkloadEnforce(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"secureworkload_scope":     dataSourceSecureWorkloadScope(),
@@ -63,10 +116,15 @@ func Provider() *schema.Provider {
 
 func configureClient(d *schema.ResourceData) (interface{}, error) {
 	config := Config{
-		APIKey:                 d.Get("api_key").(string),
-		APISecret:              d.Get("api_secret").(string),
-		APIURL:                 d.Get("api_url").(string),
-		DisableTLSVerification: d.Get("disable_tls_verification").(bool),
+		APIKey:                     d.Get("api_key").(string),
+		APISecret:                  d.Get("api_secret").(string),
+		APIURL:                     d.Get("api_url").(string),
+		DisableTLSVerification:     d.Get("disable_tls_verification").(bool),
+		MaxRetries:                 d.Get("max_retries").(int),
+		RetryMaxWait:               time.Duration(d.Get("retry_max_wait").(int)) * time.Second,
+		RequestTimeout:             time.Duration(d.Get("request_timeout").(int)) * time.Second,
+		Services:                   servicesFromTerraform(d.Get("service").([]interface{})),
+		MembershipWarningThreshold: d.Get("membership_warning_threshold").(int),
 	}
 	if err := validate(config); err != nil {
 		return nil, err
@@ -91,5 +149,14 @@ func validate(config Config) error {
 	if config.APIURL == "" {
 		err = multierror.Append(err, fmt.Errorf("API URL must be configured for the Secure Workload provider"))
 	}
+	if config.MaxRetries < 0 {
+		err = multierror.Append(err, fmt.Errorf("Max retries must not be negative for the Secure Workload provider"))
+	}
+	if config.RetryMaxWait <= 0 {
+		err = multierror.Append(err, fmt.Errorf("Retry max wait must be a positive number of seconds for the Secure Workload provider"))
+	}
+	if config.RequestTimeout <= 0 {
+		err = multierror.Append(err, fmt.Errorf("Request timeout must be a positive number of seconds for the Secure Workload provider"))
+	}
 	return err.ErrorOrNil()
 }
@@ -36,12 +36,14 @@ type Role struct {
 	Name string `json:"name"`
 	// User-specified description for the role
 	Description string `json:"description"`
+	// Scope access abilities granted to the role
+	Capabilities []RoleScopeResponse `json:"capabilities,omitempty"`
 }
 
 func (c Client) GetRoleByParam(getUrl string) ([]Role, error) {
 	var role []Role
 	url := c.Config.APIURL + RolesAPIV1BasePath + getUrl
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return role, err
 	}
@@ -55,7 +57,7 @@ func (c Client) GetRoleByParam(getUrl string) ([]Role, error) {
 func (c Client) ListRoles() ([]Role, error) {
 	var roles []Role
 	url := c.Config.APIURL + RolesAPIV1BasePath
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return roles, err
 	}
@@ -65,8 +67,9 @@ func (c Client) ListRoles() ([]Role, error) {
 
 // DeleteRole deletes a role by id, returning error (if any).
 func (c Client) DeleteRole(roleId string) error {
+	defer c.invalidateLookups(roleLookups, userLookups)
 	url := fmt.Sprintf("%s%s/%s", c.Config.APIURL, RolesAPIV1BasePath, roleId)
-	request, err := signer.CreateJSONRequest(http.MethodDelete, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
@@ -87,9 +90,10 @@ type CreateRoleRequest struct {
 // CreateRole creates a role with the specified params,
 // returning the created role and error (if any).
 func (c Client) CreateRole(params CreateRoleRequest) (Role, error) {
+	defer c.invalidateLookups(roleLookups)
 	var role Role
 	url := c.Config.APIURL + RolesAPIV1BasePath
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return role, err
 	}
@@ -103,7 +107,7 @@ func (c Client) GetRole(roleId string) (Role, error) {
 	var role Role
 	url := fmt.Sprintf("%s%s/%s", c.Config.APIURL, RolesAPIV1BasePath, roleId)
 
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return role, err
 	}
@@ -139,6 +143,7 @@ type RoleScopeResponse struct {
 // GiveScopeAccessToRole gives a role a specific level of access to a scope.
 // It returns a RoleScopeResponse and an error, if any
 func (c Client) GiveScopeAccessToRole(params GiveScopeAccessToRoleRequest) (RoleScopeResponse, error) {
+	defer c.invalidateLookups(roleLookups)
 	var roleScopeResponse RoleScopeResponse
 	uppercasedAbility := strings.ToUpper(params.Ability)
 	if !isAbilityValid(uppercasedAbility) {
@@ -147,7 +152,7 @@ func (c Client) GiveScopeAccessToRole(params GiveScopeAccessToRoleRequest) (Role
 	params.Ability = uppercasedAbility
 
 	url := fmt.Sprintf("%s%s/%s/capabilities", c.Config.APIURL, RolesAPIV1BasePath, params.RoleId)
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return roleScopeResponse, err
 	}
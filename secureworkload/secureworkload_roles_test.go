@@ -101,14 +101,14 @@ func deleteApplications(applicationsToDelete []Application, client Client, t *te
 		if err != nil {
 			t.Errorf("Error deleting Application: %s", err)
 		}
-		if !Await(func() bool {
+		if !AwaitTimeout(func() bool {
 			_, err := client.DescribeApplication(DescribeApplicationRequest{ApplicationId: application.Id})
 			// easy way to check error status?
 			if err == nil {
 				return false
 			}
 			return true
-		}, 5) {
+		}, 31*time.Second, 16*time.Second) {
 			t.Errorf("Could not delete application %s", application.Id)
 		}
 	}
@@ -118,13 +118,13 @@ func deleteApplications(applicationsToDelete []Application, client Client, t *te
 // It's taken over a minute multiple times
 func deleteScopes(scopesToDelete []Scope, client Client, t *testing.T) {
 	// for _, scope := range scopesToDelete {
-	// 	if !Await(func() bool {
+	// 	if !AwaitTimeout(func() bool {
 	// 		err := client.DeleteScope(scope.Id)
 	// 		if err != nil {
 	// 			return false
 	// 		}
 	// 		return true
-	// 	}, 3) {
+	// 	}, 7*time.Second, 4*time.Second) {
 	// 		t.Logf("Could not delete scope %s", scope.Id)
 	// 	}
 	// }
@@ -288,7 +288,7 @@ func TestAddingRoleToScopeAndUser(t *testing.T) {
 	// verify addition and removal of role from a user
 	var userWithRole, userWithoutRole User
 
-	if !Await(func() bool {
+	if !AwaitTimeout(func() bool {
 		userWithRole, err = client.AddRoleToUser(AddRoleToUserRequest{
 			UserId: createdUser.Id,
 			RoleId: createdRole.Id,
@@ -297,11 +297,11 @@ func TestAddingRoleToScopeAndUser(t *testing.T) {
 			t.Fatalf("Error %s adding role %+v to user %+v", err, createdRole, userWithRole)
 		}
 		return doesUserHaveRole(userWithRole, createdRole)
-	}, 3) {
+	}, 7*time.Second, 4*time.Second) {
 		t.Fatalf("Expected user to have role %s, but user only had [%s]", createdRole.Id, strings.Join(userWithRole.RoleIds, ", "))
 	}
 
-	if !Await(func() bool {
+	if !AwaitTimeout(func() bool {
 		userWithoutRole, err = client.RemoveRoleFromUser(RemoveRoleFromUserRequest{
 			RoleId: createdRole.Id,
 			UserId: createdUser.Id,
@@ -310,7 +310,7 @@ func TestAddingRoleToScopeAndUser(t *testing.T) {
 			t.Fatalf("Error %s removing role %s from user %+v", err, createdRole.Id, userWithoutRole)
 		}
 		return !doesUserHaveRole(userWithoutRole, createdRole)
-	}, 3) {
+	}, 7*time.Second, 4*time.Second) {
 		t.Fatalf("Role %s should have been removed from the user but wasn't", createdRole.Id)
 	}
 
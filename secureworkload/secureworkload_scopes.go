@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	// "github.com/secureworkload-exchange/terraform-go-sdk/signer"
 	"terraform-provider-secureworkload/secureworkload/signer"
@@ -21,6 +22,13 @@ type ScopeQuery struct {
 	Field   string       `json:"field,omitempty"`
 	Value   interface{}  `json:"value,omitempty"`
 	Filters []ScopeQuery `json:"filters,omitempty"`
+	// Query negated by not queries.
+	Filter *ScopeQuery `json:"filter,omitempty"`
+	// Values matched by in queries.
+	Values []interface{} `json:"values,omitempty"`
+	// Inclusive bounds of range queries.
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
 }
 
 // Scope wraps a secureworkload scope attributes including the
@@ -92,7 +100,7 @@ type CreateScopeRequest struct {
 func (c Client) GetScopeByParam(getUrl string) ([]GetScope, error) {
 	var scope []GetScope
 	url := c.Config.APIURL + ScopesAPIV1BasePath + getUrl
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return scope, err
 	}
@@ -103,9 +111,39 @@ func (c Client) GetScopeByParam(getUrl string) ([]GetScope, error) {
 // CreateScope creates a scope with the specified params,
 // returning the created scope and error (if any).
 func (c Client) CreateScope(params CreateScopeRequest) (Scope, error) {
+	defer c.invalidateLookups(scopeLookups)
 	var scope Scope
 	url := c.Config.APIURL + ScopesAPIV1BasePath
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
+	if err != nil {
+		return scope, err
+	}
+	err = c.Do(request, &scope)
+	return scope, err
+}
+
+// UpdateScopeRequest wraps parameters for making a request
+// to update a scope in place
+type UpdateScopeRequest struct {
+	// User-specified name for the scope.
+	ShortName string `json:"short_name,omitempty"`
+	// User-specified description of the scope.
+	Description string `json:"description"`
+	// Filter (or match criteria) associated with the scope.
+	ShortQuery json.RawMessage `json:"short_query,omitempty"`
+	// Used to sort application priorities, left unchanged when nil.
+	// A pointer so that the priority can be updated to 0.
+	PolicyPriority *int `json:"policy_priority,omitempty"`
+}
+
+// UpdateScope updates a scope by id with the specified params,
+// returning the updated scope and error (if any).
+// Query changes leave the scope dirty until they are committed.
+func (c Client) UpdateScope(scopeId string, params UpdateScopeRequest) (Scope, error) {
+	defer c.invalidateLookups(scopeLookups)
+	var scope Scope
+	url := c.Config.APIURL + ScopesAPIV1BasePath + fmt.Sprintf("/%s", scopeId)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPut, url, params)
 	if err != nil {
 		return scope, err
 	}
@@ -118,7 +156,7 @@ func (c Client) CreateScope(params CreateScopeRequest) (Scope, error) {
 func (c Client) DescribeScope(scopeId string) (Scope, error) {
 	var scope Scope
 	url := c.Config.APIURL + ScopesAPIV1BasePath + fmt.Sprintf("/%s", scopeId)
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return scope, err
 	}
@@ -128,24 +166,78 @@ func (c Client) DescribeScope(scopeId string) (Scope, error) {
 
 // DeleteScope deletes a scope by id returning error (if any).
 func (c Client) DeleteScope(scopeId string) error {
+	defer c.invalidateLookups(scopeLookups)
 	url := c.Config.APIURL + ScopesAPIV1BasePath + fmt.Sprintf("/%s", scopeId)
-	request, err := signer.CreateJSONRequest(http.MethodDelete, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, nil)
+}
+
+// CommitScopeQueriesRequest wraps parameters for making a request
+// to commit the pending query changes of a scope tree
+type CommitScopeQueriesRequest struct {
+	// ID of the root scope whose dirty scopes will be committed.
+	RootAppScopeId string `json:"root_app_scope_id"`
+	// (Optional) When true the API waits for the commit to finish before responding.
+	Sync bool `json:"sync,omitempty"`
+}
+
+// CommitScopeQueries commits the query changes of all dirty
+// scopes under the given root scope, returning error (if any).
+func (c Client) CommitScopeQueries(params CommitScopeQueriesRequest) error {
+	defer c.invalidateLookups(scopeLookups)
+	url := c.Config.APIURL + ScopesAPIV1BasePath + "/commit_dirty"
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return err
 	}
 	return c.Do(request, nil)
 }
 
+// ListDirtyScopes lists the scopes under the given root scope
+// whose query changes have not been committed yet,
+// returning the dirty scopes and error (if any).
+func (c Client) ListDirtyScopes(rootAppScopeId string) ([]Scope, error) {
+	var dirtyScopes []Scope
+	scopes := c.IterateScopes(ListOptions{})
+	for scopes.Next() {
+		scope := scopes.Item()
+		if scope.RootAppScopeId == rootAppScopeId && scope.Dirty {
+			dirtyScopes = append(dirtyScopes, scope)
+		}
+	}
+	return dirtyScopes, scopes.Err()
+}
+
 // ListScopes lists all scopes readable by the API
 // credentials for the given client, returning
 // the listed scopes and error (if any)
 func (c Client) ListScopes() ([]Scope, error) {
-	var scopes []Scope
-	url := c.Config.APIURL + ScopesAPIV1BasePath
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return scopes, err
+	return collect(c.IterateScopes(ListOptions{}))
+}
+
+// IterateScopes returns an iterator over the scopes readable by the
+// API credentials for the given client. Each call sends a single GET,
+// whose response is decoded as a stream. Scopes are filtered by name
+// and short name server-side.
+func (c Client) IterateScopes(options ListOptions) *ListIterator[Scope] {
+	endpoint := listEndpoint[Scope]{
+		url:   c.Config.APIURL + ScopesAPIV1BasePath,
+		query: url.Values{},
+	}
+	if options.Name != "" {
+		endpoint.query.Set("exact_name", options.Name)
+	}
+	if options.ShortName != "" {
+		endpoint.query.Set("exact_short_name", options.ShortName)
+	}
+	if options.Name != "" || options.ShortName != "" {
+		endpoint.match = func(scope Scope) bool {
+			return (options.Name == "" || scope.Name == options.Name) &&
+				(options.ShortName == "" || scope.ShortName == options.ShortName)
+		}
 	}
-	err = c.Do(request, &scopes)
-	return scopes, err
+	return newListIterator(c, endpoint)
 }
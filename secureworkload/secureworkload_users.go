@@ -3,6 +3,7 @@ package secureworkload
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 
 	// "github.com/secureworkload-exchange/terraform-go-sdk/signer"
 	"terraform-provider-secureworkload/secureworkload/signer"
@@ -49,9 +50,10 @@ type User struct {
 // CreateUser creates a user with the specified params,
 // returning the created user and error (if any).
 func (c Client) CreateUser(params CreateUserRequest) (User, error) {
+	defer c.invalidateLookups(userLookups)
 	var user User
 	url := c.Config.APIURL + UsersAPIV1BasePath
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, params)
 	if err != nil {
 		return user, err
 	}
@@ -64,7 +66,7 @@ func (c Client) CreateUser(params CreateUserRequest) (User, error) {
 func (c Client) DescribeUser(userId string) (User, error) {
 	var user User
 	url := c.Config.APIURL + UsersAPIV1BasePath + fmt.Sprintf("/%s", userId)
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodGet, url, nil)
 	if err != nil {
 		return user, err
 	}
@@ -74,8 +76,9 @@ func (c Client) DescribeUser(userId string) (User, error) {
 
 // DeleteUser deletes a user by id returning error (if any).
 func (c Client) DeleteUser(userId string) error {
+	defer c.invalidateLookups(userLookups)
 	url := c.Config.APIURL + UsersAPIV1BasePath + fmt.Sprintf("/%s", userId)
-	request, err := signer.CreateJSONRequest(http.MethodDelete, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
@@ -93,9 +96,10 @@ type AddRoleToUserRequest struct {
 // AddRoleToUser adds the specified role to the user.
 // It returns the modified user object and an error, if any
 func (c Client) AddRoleToUser(params AddRoleToUserRequest) (User, error) {
+	defer c.invalidateLookups(userLookups)
 	var user User
 	url := c.Config.APIURL + UsersAPIV1BasePath + fmt.Sprintf("/%s/add_role", params.UserId)
-	request, err := signer.CreateJSONRequest(http.MethodPut, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPut, url, params)
 	if err != nil {
 		return user, err
 	}
@@ -115,9 +119,10 @@ type RemoveRoleFromUserRequest struct {
 // RemoveRoleFromUser removes the specified role from the user.
 // It returns the modified user object and an error, if any
 func (c Client) RemoveRoleFromUser(params RemoveRoleFromUserRequest) (User, error) {
+	defer c.invalidateLookups(userLookups)
 	var user User
 	url := c.Config.APIURL + UsersAPIV1BasePath + fmt.Sprintf("/%s/remove_role", params.UserId)
-	request, err := signer.CreateJSONRequest(http.MethodDelete, url, params)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodDelete, url, params)
 	if err != nil {
 		return user, err
 	}
@@ -138,34 +143,39 @@ type ListUsersRequest struct {
 // credentials for the given client, returning
 // the listed users and error (if any)
 func (c Client) ListUsers(params ListUsersRequest) ([]User, error) {
-	var users []User
-	url := c.Config.APIURL + UsersAPIV1BasePath
-	if params.IncludeDisabled || params.AppScopeId != "" {
-		url += "?"
-		if params.IncludeDisabled {
-			url += "include_disabled=true"
-			if params.AppScopeId != "" {
-				url += fmt.Sprintf("&app_scope_id=%s", params.AppScopeId)
-			}
-		}
-		if params.AppScopeId != "" {
-			url += fmt.Sprintf("app_scope_id=%s", params.AppScopeId)
-		}
+	return collect(c.IterateUsers(params))
+}
+
+// IterateUsers returns an iterator over the users readable by
+// the API credentials for the given client. Each call sends a
+// single GET, whose response is decoded as a stream.
+func (c Client) IterateUsers(params ListUsersRequest) *ListIterator[User] {
+	endpoint := listEndpoint[User]{
+		url:   c.Config.APIURL + UsersAPIV1BasePath,
+		query: params.query(),
 	}
-	request, err := signer.CreateJSONRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return users, err
+	return newListIterator(c, endpoint)
+}
+
+// query returns the query parameters for listing users.
+func (params ListUsersRequest) query() url.Values {
+	query := url.Values{}
+	if params.IncludeDisabled {
+		query.Set("include_disabled", "true")
+	}
+	if params.AppScopeId != "" {
+		query.Set("app_scope_id", params.AppScopeId)
 	}
-	err = c.Do(request, &users)
-	return users, err
+	return query
 }
 
 // EnableUser makes a request to enable or reactivate a
 // deactivated user, returning the user and error (if any).
 func (c Client) EnableUser(userId string) (User, error) {
+	defer c.invalidateLookups(userLookups)
 	var user User
 	url := c.Config.APIURL + UsersAPIV1BasePath + fmt.Sprintf("/%s/enable", userId)
-	request, err := signer.CreateJSONRequest(http.MethodPost, url, nil)
+	request, err := signer.CreateJSONRequestWithContext(c.Context(), http.MethodPost, url, nil)
 	if err != nil {
 		return user, err
 	}
@@ -4,6 +4,7 @@
 package signer
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -121,13 +122,19 @@ func CalculateBodyChecksum(body []byte) string {
 // http request for the given method and url with the params
 // as JSON encoded body into the body and error (if any).
 func CreateJSONRequest(method string, url string, params interface{}) (*http.Request, error) {
+	return CreateJSONRequestWithContext(context.Background(), method, url, params)
+}
+
+// CreateJSONRequestWithContext is like CreateJSONRequest,
+// but the returned request is cancelled along with ctx.
+func CreateJSONRequestWithContext(ctx context.Context, method string, url string, params interface{}) (*http.Request, error) {
 	var request *http.Request
 	var buf bytes.Buffer
 	err := json.NewEncoder(&buf).Encode(&params)
 	if err != nil {
 		return request, err
 	}
-	request, err = http.NewRequest(method, url, &buf)
+	request, err = http.NewRequestWithContext(ctx, method, url, &buf)
 	if err != nil {
 		return request, err
 	}